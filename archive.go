@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// nowFn is replaceable for testing
+var nowFn = time.Now
+
+// archiveTimeFormat is the timestamp layout used in archive file names
+const archiveTimeFormat = "20060102-150405"
+
+// archiveWorktree writes a tar.gz of the worktree directory src into destDir and returns its path
+// The archive is named after the worktree and the current time, and its entries live under name/
+func archiveWorktree(src, destDir, name string) (string, error) {
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	base := strings.ReplaceAll(name, "/", "-")
+	path := filepath.Join(destDir, base+"-"+nowFn().Format(archiveTimeFormat)+".tar.gz")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	err = writeTarGz(f, src, base)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Don't leave a truncated archive that looks like a valid backup
+		os.Remove(path)
+		return "", fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// writeTarGz writes a gzip-compressed tar of src to w, placing entries under prefix/
+// Symlinks are stored as links; special files such as sockets and devices are skipped
+func writeTarGz(w io.Writer, src, prefix string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		var link string
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if link, err = readlinkFn(path); err != nil {
+				return err
+			}
+		case !info.IsDir() && !info.Mode().IsRegular():
+			return nil
+		}
+
+		// FileInfoHeader only fails for special files, which were skipped above
+		hdr, _ := tar.FileInfoHeader(info, link)
+		// Walk only visits paths under src, so Rel cannot fail
+		rel, _ := filepath.Rel(src, path)
+		hdr.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Both closes flush buffered data; report the first failure
+	err = tw.Close()
+	if gzErr := gz.Close(); err == nil {
+		err = gzErr
+	}
+	return err
+}
+
+// copyFileTo copies the contents of the file at path to w
+func copyFileTo(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
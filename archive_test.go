@@ -0,0 +1,201 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// readTarGz returns the entries of the tar.gz at path, mapping each name to its contents
+// Directories map to "" and symlinks to "-> target"
+func readTarGz(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("failed to read gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	entries := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %v", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			entries[hdr.Name] = "-> " + hdr.Linkname
+		default:
+			data, _ := io.ReadAll(tr)
+			entries[hdr.Name] = string(data)
+		}
+	}
+}
+
+func TestArchiveWorktree(t *testing.T) {
+	origNow := nowFn
+	defer func() {
+		nowFn = origNow
+	}()
+	nowFn = func() time.Time {
+		return time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)
+	}
+
+	t.Run("archives files, directories and symlinks", func(t *testing.T) {
+		src := t.TempDir()
+		os.MkdirAll(filepath.Join(src, "notes"), 0755)
+		os.WriteFile(filepath.Join(src, "scratch.txt"), []byte("experiment"), 0644)
+		os.WriteFile(filepath.Join(src, "notes", "todo.md"), []byte("- finish"), 0644)
+		os.Symlink("scratch.txt", filepath.Join(src, "link"))
+		if err := syscall.Mkfifo(filepath.Join(src, "fifo"), 0644); err != nil {
+			t.Skipf("mkfifo not supported: %v", err)
+		}
+
+		dest := filepath.Join(t.TempDir(), "archives")
+		path, err := archiveWorktree(src, dest, "feature/login")
+		if err != nil {
+			t.Fatalf("archiveWorktree() unexpected error: %v", err)
+		}
+		if want := filepath.Join(dest, "feature-login-20240305-140709.tar.gz"); path != want {
+			t.Errorf("archiveWorktree() path = %q, want %q", path, want)
+		}
+
+		want := map[string]string{
+			"feature-login/":              "",
+			"feature-login/link":          "-> scratch.txt",
+			"feature-login/notes/":        "",
+			"feature-login/notes/todo.md": "- finish",
+			"feature-login/scratch.txt":   "experiment",
+		}
+		if got := readTarGz(t, path); !reflect.DeepEqual(got, want) {
+			t.Errorf("archive entries = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("archive directory cannot be created", func(t *testing.T) {
+		blocker := filepath.Join(t.TempDir(), "file")
+		os.WriteFile(blocker, []byte{}, 0644)
+
+		_, err := archiveWorktree(t.TempDir(), filepath.Join(blocker, "archives"), "feat")
+		if err == nil || !errors.Is(err, syscall.ENOTDIR) {
+			t.Errorf("archiveWorktree() error = %v, want archive directory error", err)
+		}
+	})
+
+	t.Run("existing archive is not overwritten", func(t *testing.T) {
+		dest := t.TempDir()
+		existing := filepath.Join(dest, "feat-20240305-140709.tar.gz")
+		os.WriteFile(existing, []byte("older backup"), 0644)
+
+		_, err := archiveWorktree(t.TempDir(), dest, "feat")
+		if err == nil || !errors.Is(err, os.ErrExist) {
+			t.Errorf("archiveWorktree() error = %v, want file exists error", err)
+		}
+		if data, _ := os.ReadFile(existing); string(data) != "older backup" {
+			t.Errorf("existing archive = %q, want it untouched", data)
+		}
+	})
+
+	t.Run("failed archive is removed", func(t *testing.T) {
+		dest := t.TempDir()
+
+		_, err := archiveWorktree(filepath.Join(t.TempDir(), "missing"), dest, "feat")
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("archiveWorktree() error = %v, want missing source error", err)
+		}
+		if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+			t.Errorf("archive directory has %d entries, want the partial archive removed", len(entries))
+		}
+	})
+}
+
+// failingWriter fails every write after the first n bytes
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteTarGz(t *testing.T) {
+	src := t.TempDir()
+	os.WriteFile(filepath.Join(src, "file.txt"), []byte("data"), 0644)
+
+	t.Run("write fails", func(t *testing.T) {
+		err := writeTarGz(&failingWriter{}, src, "feat")
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("writeTarGz() error = %v, want 'disk full'", err)
+		}
+	})
+
+	t.Run("flush fails", func(t *testing.T) {
+		// The gzip header fits, but the compressed data is only written on close
+		err := writeTarGz(&failingWriter{n: 10}, src, "feat")
+		if err == nil || err.Error() != "disk full" {
+			t.Errorf("writeTarGz() error = %v, want 'disk full'", err)
+		}
+	})
+
+	t.Run("readlink error", func(t *testing.T) {
+		origReadlink := readlinkFn
+		defer func() { readlinkFn = origReadlink }()
+		readlinkFn = func(string) (string, error) {
+			return "", errors.New("readlink failed")
+		}
+
+		linkDir := t.TempDir()
+		os.Symlink("target", filepath.Join(linkDir, "link"))
+
+		err := writeTarGz(io.Discard, linkDir, "feat")
+		if err == nil || err.Error() != "readlink failed" {
+			t.Errorf("writeTarGz() error = %v, want 'readlink failed'", err)
+		}
+	})
+}
+
+func TestCopyFileTo(t *testing.T) {
+	t.Run("copies contents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		os.WriteFile(path, []byte("data"), 0644)
+
+		var buf bytes.Buffer
+		if err := copyFileTo(&buf, path); err != nil || buf.String() != "data" {
+			t.Errorf("copyFileTo() = %q, %v; want %q", buf.String(), err, "data")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		err := copyFileTo(io.Discard, filepath.Join(t.TempDir(), "missing"))
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("copyFileTo() error = %v, want not exist", err)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		// Opening a directory succeeds but reading it fails
+		if err := copyFileTo(io.Discard, t.TempDir()); err == nil {
+			t.Error("copyFileTo() expected error reading a directory")
+		}
+	})
+}
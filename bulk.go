@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// forEachWorktree calls fn for each of the named worktrees in order, for commands acting on several
+// Without keepGoing it stops at the first failure and returns it as "failed to <action> <name>: ...";
+// with keepGoing each failure is reported on stderr and the rest still run, and the error names them all
+func forEachWorktree(names []string, action string, keepGoing bool, fn func(name string) error) error {
+	var failed []string
+	for _, name := range names {
+		err := fn(name)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("failed to %s %s: %w", action, name, err)
+		if !keepGoing {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		failed = append(failed, name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to %s %d of %d worktrees: %s", action, len(failed), len(names), strings.Join(failed, ", "))
+	}
+	return nil
+}
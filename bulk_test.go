@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestForEachWorktree(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	failing := map[string]bool{"a": true, "c": true}
+
+	tests := []struct {
+		name       string
+		keepGoing  bool
+		wantCalls  []string
+		wantStderr string
+		wantErr    string
+	}{
+		{
+			name:      "stops at the first failure",
+			wantCalls: []string{"a"},
+			wantErr:   "failed to check a: boom",
+		},
+		{
+			name:       "keep going runs the rest and names every failure",
+			keepGoing:  true,
+			wantCalls:  []string{"a", "b", "c"},
+			wantStderr: "error: failed to check a: boom\nerror: failed to check c: boom\n",
+			wantErr:    "failed to check 2 of 3 worktrees: a, c",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			fn := func(name string) error {
+				calls = append(calls, name)
+				if failing[name] {
+					return errors.New("boom")
+				}
+				return nil
+			}
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := forEachWorktree(names, "check", tt.keepGoing, fn)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("forEachWorktree() error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("all succeed", func(t *testing.T) {
+		for _, keepGoing := range []bool{false, true} {
+			if err := forEachWorktree(names, "check", keepGoing, func(string) error { return nil }); err != nil {
+				t.Errorf("forEachWorktree(keepGoing=%v) unexpected error: %v", keepGoing, err)
+			}
+		}
+	})
+}
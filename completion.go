@@ -7,17 +7,19 @@ import (
 	"path/filepath"
 )
 
-// listWorktreesFn is replaceable for testing
-var listWorktreesFn = defaultListWorktrees
+// Function variables for testing
+var (
+	listWorktreesFn = defaultListWorktrees
+	shellEnvFn      = func() string { return os.Getenv("SHELL") }
+)
 
 func defaultListWorktrees() ([]string, error) {
-	root, err := gitMainRoot()
+	wm, err := NewWorktreeManager()
 	if err != nil {
 		return nil, err
 	}
 
-	worktreesDir := filepath.Join(root, ".worktrees")
-	entries, err := os.ReadDir(worktreesDir)
+	entries, err := os.ReadDir(wm.WorktreesPath())
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []string{}, nil
@@ -39,7 +41,15 @@ func listWorktrees() ([]string, error) {
 }
 
 // completion generates shell completion scripts
+// The shell "auto" is detected from $SHELL
 func completion(shell string, w io.Writer) error {
+	if shell == "auto" {
+		var err error
+		if shell, err = detectShell(); err != nil {
+			return err
+		}
+	}
+
 	switch shell {
 	case "bash":
 		return bashCompletion(w)
@@ -52,12 +62,44 @@ func completion(shell string, w io.Writer) error {
 	}
 }
 
+// detectShell returns the supported shell named by $SHELL, such as zsh for /bin/zsh
+func detectShell() (string, error) {
+	shellPath := shellEnvFn()
+	switch shell := filepath.Base(shellPath); shell {
+	case "bash", "zsh", "fish":
+		return shell, nil
+	}
+	if shellPath == "" {
+		return "", fmt.Errorf("cannot detect shell: $SHELL is not set (supported: bash, zsh, fish)")
+	}
+	return "", fmt.Errorf("cannot detect shell from $SHELL=%s (supported: bash, zsh, fish)", shellPath)
+}
+
 func bashCompletion(w io.Writer) error {
-	script := `_wt_completions() {
+	script := `# Complete file names, or directories with -d, with bash-completion's _filedir when it is loaded
+_wt_filedir() {
+    if declare -F _filedir >/dev/null; then
+        _filedir "$@"
+    elif [[ $1 == -d ]]; then
+        COMPREPLY=($(compgen -d -- "${cur}"))
+    else
+        COMPREPLY=($(compgen -f -- "${cur}"))
+    fi
+}
+
+_wt_completions() {
     local cur prev words cword
-    _init_completion || return
+    if declare -F _init_completion >/dev/null; then
+        _init_completion || return
+    else
+        # Without bash-completion, take the words from bash itself
+        words=("${COMP_WORDS[@]}")
+        cword=${COMP_CWORD}
+        cur=${COMP_WORDS[COMP_CWORD]}
+        prev=${COMP_WORDS[COMP_CWORD-1]}
+    fi
 
-    local commands="jump create remove list completion"
+    local commands="init jump create remove list config repo-root completion"
 
     case "${prev}" in
         wt)
@@ -70,25 +112,57 @@ func bashCompletion(w io.Writer) error {
             COMPREPLY=($(compgen -W "${worktrees}" -- "${cur}"))
             return
             ;;
-        remove)
+        remove|--after)
             local worktrees
             worktrees=$(wt __complete remove 2>/dev/null)
             COMPREPLY=($(compgen -W "${worktrees}" -- "${cur}"))
             return
             ;;
+        config)
+            COMPREPLY=($(compgen -W "get set list" -- "${cur}"))
+            return
+            ;;
+        get|set)
+            COMPREPLY=($(compgen -W "worktrees_dir default_hook copy_dirs hook_shell branch_prefix post_remove_hook copy_max_depth copy_max_size" -- "${cur}"))
+            return
+            ;;
         completion)
-            COMPREPLY=($(compgen -W "bash zsh fish" -- "${cur}"))
+            COMPREPLY=($(compgen -W "auto bash zsh fish" -- "${cur}"))
+            return
+            ;;
+        --on-conflict)
+            COMPREPLY=($(compgen -W "error skip checkout" -- "${cur}"))
+            return
+            ;;
+        --reuse-branch-dir)
+            COMPREPLY=($(compgen -W "slug last" -- "${cur}"))
+            return
+            ;;
+        --output)
+            COMPREPLY=($(compgen -W "text json null" -- "${cur}"))
             return
             ;;
-        --hook)
-            _filedir
+        --base|--detach)
+            COMPREPLY=($(compgen -W "$(git for-each-ref --format='%(refname:short)' refs/heads refs/remotes refs/tags 2>/dev/null)" -- "${cur}"))
+            return
+            ;;
+        --attach)
+            COMPREPLY=($(compgen -W "$(git for-each-ref --format='%(refname:short)' refs/heads 2>/dev/null)" -- "${cur}"))
+            return
+            ;;
+        --hook|--env-file)
+            _wt_filedir
+            return
+            ;;
+        --archive|--copy|--template-dir|--relative-to)
+            _wt_filedir -d
             return
             ;;
     esac
 
     case "${cur}" in
         -*)
-            COMPREPLY=($(compgen -W "--hook -h --help" -- "${cur}"))
+            COMPREPLY=($(compgen -W "--relative --relative-to --create --hook --no-verify --env-file --quiet-hook --hook-retries --hook-timeout --no-checkout --sparse --dry-run --copy --base --slug --push --open-url --recurse-submodules --template-dir --on-conflict --checkout-existing-ok --from-pr --attach --reuse-branch-dir --run-hook --detach --if-missing --branch-from-current --after --no-claude --keep-dir --archive --force --yes --older-than -k --keep-going --no-cd --select --merged --unmerged --limit --offset --attached-only --detached-only --check --prunable --branches --pager --no-pager --active-first --paths --abbrev --show-head --name-only --with-branch --orphan-branches -0 --null --output --exec --no-gitignore-check -h --help" -- "${cur}"))
             return
             ;;
     esac
@@ -109,27 +183,104 @@ func zshCompletion(w io.Writer) error {
 	script := `#compdef wt
 
 _wt_worktrees() {
-    local worktrees
-    worktrees=(${(f)"$(wt __complete jump 2>/dev/null)"})
+    # Each line is "name<TAB>branch"; _describe expects "name:description"
+    local -a worktrees
+    local line name
+    for line in ${(f)"$(wt __complete jump --descriptions 2>/dev/null)"}; do
+        name=${line%%$'\t'*}
+        if [[ $line == *$'\t'* ]]; then
+            worktrees+=("${name//:/\\:}:${line#*$'\t'}")
+        else
+            worktrees+=("${name//:/\\:}")
+        fi
+    done
     _describe -t worktrees 'worktrees' worktrees
 }
 
 _wt() {
     local -a commands
     commands=(
+        'init:Set up the worktrees directory'
         'jump:Jump to a worktree or repo root'
         'create:Create a new worktree with branch'
         'remove:Remove a worktree and its branch'
         'list:List all worktrees'
+        'config:Get or set configuration values'
+        'repo-root:Print the main repository root'
         'completion:Generate shell completion script'
     )
 
     local -a shells
-    shells=(bash zsh fish)
+    shells=(auto bash zsh fish)
+
+    local -a config_commands
+    config_commands=(get set list)
+
+    local -a config_keys
+    config_keys=(worktrees_dir default_hook copy_dirs hook_shell branch_prefix post_remove_hook copy_max_depth copy_max_size)
 
     _arguments -C \
         '(-h --help)'{-h,--help}'[Show help message]' \
+        '(--relative-to)--relative[Print the path relative to the current directory]' \
+        '(--relative)--relative-to[Print the path relative to a directory]:directory:_files -/' \
+        '--create[Create the worktree first if it does not exist]' \
         '--hook[Custom hook script to run after create]:hook file:_files' \
+        '--no-verify[Skip git hooks while adding the worktree]' \
+        '--env-file[Add KEY=VALUE lines to the hook environment]:env file:_files' \
+        '--quiet-hook[Hide hook output unless the hook fails]' \
+        '--hook-retries[Run a failing hook again up to n times]:retries:' \
+        '--hook-timeout[Kill the hook if a run takes longer than a duration]:duration:' \
+        '--no-checkout[Register the worktree without checking out files]' \
+        '--sparse[Check out only paths matching pattern]:pattern:' \
+        '--dry-run[Print what create would do without changing anything]' \
+        '--copy[Also copy a directory into the worktree]:directory:_files -/' \
+        '--on-conflict[What to do when the branch already exists]:policy:(error skip checkout)' \
+        '--checkout-existing-ok[Replace a stale worktree left at the target]' \
+        '--from-pr[Fetch a pull request and check out its branch]:pull request number' \
+        '--attach[Check out an existing branch instead of creating one]:branch:' \
+        '--reuse-branch-dir[How to name a worktree after its branch]:style:(slug last)' \
+        '--run-hook[Run the hook for an existing branch]' \
+        '--detach[Check out a ref such as a tag on a detached HEAD]:ref:' \
+        '--if-missing[Succeed without changes if the worktree already exists]' \
+        '(--base)--branch-from-current[Start the branch at the HEAD of the current worktree]' \
+        '--after[Stack the branch on the branch of another worktree]:worktree:_wt_worktrees' \
+        '--no-claude[Do not symlink .claude/ into the worktree]' \
+        '(--branch-from-current)--base[Start the branch at a ref]:ref:' \
+        '--slug[Turn the name into a lowercase, dash-separated slug]' \
+        '--push[Push the new branch to origin and set it as the upstream]' \
+        '--open-url[Open the page comparing the branch with its base]' \
+        '--recurse-submodules[Initialize and update submodules in the new worktree]' \
+        '--template-dir[Copy the contents of a directory into the new worktree]:template directory:_files -/' \
+        '--keep-dir[Delete only the branch and keep the directory]' \
+        '--archive[Save a tar.gz of the worktree before removing it]:archive directory:_files -/' \
+        '--force[Delete the branch even if it has unmerged commits]' \
+        '--yes[Do not ask for confirmation]' \
+        '--older-than[Remove every worktree whose last commit is older than a duration]:duration (e.g. 30d)' \
+        '(-k --keep-going)'{-k,--keep-going}'[Carry on when a worktree fails to be removed]' \
+        '--no-cd[Do not cd to the repository root after removing the current worktree]' \
+        '--select[Pick the worktrees to remove from a numbered list]' \
+        '(--unmerged)--merged[List only worktrees merged into a base branch]' \
+        '(--merged)--unmerged[List only worktrees not merged into a base branch]' \
+        '--limit[Show at most n worktrees]:number:' \
+        '--offset[Skip the first n worktrees]:number:' \
+        '(--detached-only)--attached-only[List only worktrees with a branch checked out]' \
+        '(--attached-only)--detached-only[List only worktrees on a detached HEAD]' \
+        '--check[Mark worktrees git no longer tracks as stale]' \
+        '--prunable[List only worktrees git can prune]' \
+        '(--paths)--branches[Show checked out branches instead of directory names]' \
+        '--pager[Page output longer than the terminal]' \
+        '--no-pager[Never page output]' \
+        '--active-first[List the current worktree first]' \
+        '(--branches)--paths[Show absolute paths instead of directory names]' \
+        '--abbrev[With --paths, show paths under the home directory as ~/...]' \
+        '--show-head[Append the short HEAD SHA of each worktree]' \
+        '(--with-branch)--name-only[Show only worktree names]' \
+        '(--name-only)--with-branch[Show worktree names and branches in two columns]' \
+        '--orphan-branches[List branches no worktree has checked out]' \
+        '(-0 --null)'{-0,--null}'[End each entry with a NUL byte instead of a newline]' \
+        '--output[How to print the entries]:format:(text json null)' \
+        '--exec[Run a shell command in each listed worktree]:command:' \
+        '--no-gitignore-check[Do not add the worktrees directory to .gitignore]' \
         '1: :->command' \
         '*: :->args'
 
@@ -145,6 +296,13 @@ _wt() {
                 remove)
                     _wt_worktrees
                     ;;
+                config)
+                    if (( CURRENT == 3 )); then
+                        _describe -t config-commands 'config commands' config_commands
+                    elif (( CURRENT == 4 )) && [[ $words[3] == (get|set) ]]; then
+                        _describe -t config-keys 'config keys' config_keys
+                    fi
+                    ;;
                 completion)
                     _describe -t shells 'shells' shells
                     ;;
@@ -166,22 +324,85 @@ func fishCompletion(w io.Writer) error {
 	script := `# Fish completion for wt
 
 function __wt_worktrees
-    wt __complete jump 2>/dev/null
+    wt __complete jump --descriptions 2>/dev/null
 end
 
 # Disable file completion by default
 complete -c wt -f
 
 # Commands
+complete -c wt -n "__fish_use_subcommand" -a "init" -d "Set up the worktrees directory"
 complete -c wt -n "__fish_use_subcommand" -a "jump" -d "Jump to a worktree or repo root"
 complete -c wt -n "__fish_use_subcommand" -a "create" -d "Create a new worktree with branch"
 complete -c wt -n "__fish_use_subcommand" -a "remove" -d "Remove a worktree and its branch"
 complete -c wt -n "__fish_use_subcommand" -a "list" -d "List all worktrees"
+complete -c wt -n "__fish_use_subcommand" -a "repo-root" -d "Print the main repository root"
+complete -c wt -n "__fish_use_subcommand" -a "config" -d "Get or set configuration values"
 complete -c wt -n "__fish_use_subcommand" -a "completion" -d "Generate shell completion script"
 
 # Options
 complete -c wt -s h -l help -d "Show help message"
 complete -c wt -l hook -r -d "Custom hook script to run after create"
+complete -c wt -n "__fish_seen_subcommand_from jump" -l relative -d "Print the path relative to the current directory"
+complete -c wt -n "__fish_seen_subcommand_from jump repo-root" -l relative-to -r -a "(__fish_complete_directories)" -d "Print the path relative to a directory"
+complete -c wt -n "__fish_seen_subcommand_from jump" -l create -d "Create the worktree first if it does not exist"
+complete -c wt -n "__fish_seen_subcommand_from create" -l no-verify -d "Skip git hooks while adding the worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l quiet-hook -d "Hide hook output unless the hook fails"
+complete -c wt -n "__fish_seen_subcommand_from create" -l hook-retries -x -d "Run a failing hook again up to n times"
+complete -c wt -n "__fish_seen_subcommand_from create" -l hook-timeout -x -d "Kill the hook if a run takes longer than a duration"
+complete -c wt -n "__fish_seen_subcommand_from create" -l no-checkout -d "Register the worktree without checking out files"
+complete -c wt -n "__fish_seen_subcommand_from create" -l sparse -x -d "Check out only paths matching pattern"
+complete -c wt -n "__fish_seen_subcommand_from create" -l dry-run -d "Print what create would do without changing anything"
+complete -c wt -n "__fish_seen_subcommand_from create" -l copy -r -a "(__fish_complete_directories)" -d "Also copy a directory into the worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l base -x -d "Start the branch at a ref"
+complete -c wt -n "__fish_seen_subcommand_from create" -l slug -d "Turn the name into a lowercase, dash-separated slug"
+complete -c wt -n "__fish_seen_subcommand_from create" -l push -d "Push the new branch to origin and set it as the upstream"
+complete -c wt -n "__fish_seen_subcommand_from create" -l open-url -d "Open the page comparing the branch with its base"
+complete -c wt -n "__fish_seen_subcommand_from create" -l recurse-submodules -d "Initialize and update submodules in the new worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l template-dir -r -a "(__fish_complete_directories)" -d "Copy the contents of a directory into the new worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l on-conflict -x -a "error skip checkout" -d "What to do when the branch already exists"
+complete -c wt -n "__fish_seen_subcommand_from create" -l checkout-existing-ok -d "Replace a stale worktree left at the target"
+complete -c wt -n "__fish_seen_subcommand_from create" -l from-pr -x -d "Fetch a pull request and check out its branch"
+complete -c wt -n "__fish_seen_subcommand_from create" -l attach -x -d "Check out an existing branch instead of creating one"
+complete -c wt -n "__fish_seen_subcommand_from create" -l reuse-branch-dir -x -a "slug last" -d "How to name a worktree after its branch"
+complete -c wt -n "__fish_seen_subcommand_from create" -l run-hook -d "Run the hook for an existing branch"
+complete -c wt -n "__fish_seen_subcommand_from create" -l detach -x -d "Check out a ref such as a tag on a detached HEAD"
+complete -c wt -n "__fish_seen_subcommand_from create" -l if-missing -d "Succeed without changes if the worktree already exists"
+complete -c wt -n "__fish_seen_subcommand_from create" -l branch-from-current -d "Start the branch at the HEAD of the current worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l after -x -a "(wt __complete remove --descriptions 2>/dev/null)" -d "Stack the branch on the branch of another worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l no-claude -d "Do not symlink .claude/ into the worktree"
+complete -c wt -n "__fish_seen_subcommand_from create" -l env-file -r -d "Add KEY=VALUE lines to the hook environment"
+complete -c wt -n "__fish_seen_subcommand_from init" -l no-gitignore-check -d "Do not add the worktrees directory to .gitignore"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l keep-dir -d "Delete only the branch and keep the directory"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l archive -r -a "(__fish_complete_directories)" -d "Save a tar.gz of the worktree before removing it"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l force -d "Delete the branch even if it has unmerged commits"
+complete -c wt -n "__fish_seen_subcommand_from remove create jump" -l yes -d "Do not ask for confirmation"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l older-than -x -d "Remove every worktree whose last commit is older than a duration"
+complete -c wt -n "__fish_seen_subcommand_from remove" -s k -l keep-going -d "Carry on when a worktree fails to be removed"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l no-cd -d "Do not cd to the repository root after removing the current worktree"
+complete -c wt -n "__fish_seen_subcommand_from remove" -l select -d "Pick the worktrees to remove from a numbered list"
+complete -c wt -n "__fish_seen_subcommand_from list" -l merged -d "List only worktrees merged into a base branch"
+complete -c wt -n "__fish_seen_subcommand_from list" -l unmerged -d "List only worktrees not merged into a base branch"
+complete -c wt -n "__fish_seen_subcommand_from list" -l limit -x -d "Show at most n worktrees"
+complete -c wt -n "__fish_seen_subcommand_from list" -l offset -x -d "Skip the first n worktrees"
+complete -c wt -n "__fish_seen_subcommand_from list" -l attached-only -d "List only worktrees with a branch checked out"
+complete -c wt -n "__fish_seen_subcommand_from list" -l detached-only -d "List only worktrees on a detached HEAD"
+complete -c wt -n "__fish_seen_subcommand_from list" -l check -d "Mark worktrees git no longer tracks as stale"
+complete -c wt -n "__fish_seen_subcommand_from list" -l prunable -d "List only worktrees git can prune"
+complete -c wt -n "__fish_seen_subcommand_from list" -l branches -d "Show checked out branches instead of directory names"
+complete -c wt -n "__fish_seen_subcommand_from list" -l pager -d "Page output longer than the terminal"
+complete -c wt -n "__fish_seen_subcommand_from list" -l no-pager -d "Never page output"
+complete -c wt -n "__fish_seen_subcommand_from list" -l active-first -d "List the current worktree first"
+complete -c wt -n "__fish_seen_subcommand_from list" -l paths -d "Show absolute paths instead of directory names"
+complete -c wt -n "__fish_seen_subcommand_from list" -l abbrev -d "With --paths, show paths under the home directory as ~/..."
+complete -c wt -n "__fish_seen_subcommand_from list" -l show-head -d "Append the short HEAD SHA of each worktree"
+complete -c wt -n "__fish_seen_subcommand_from list" -l name-only -d "Show only worktree names"
+complete -c wt -n "__fish_seen_subcommand_from list" -l with-branch -d "Show worktree names and branches in two columns"
+complete -c wt -n "__fish_seen_subcommand_from list" -l orphan-branches -d "List branches no worktree has checked out"
+complete -c wt -n "__fish_seen_subcommand_from list" -s 0 -l null -d "End each entry with a NUL byte instead of a newline"
+complete -c wt -n "__fish_seen_subcommand_from list" -l output -x -a "text json null" -d "How to print the entries"
+complete -c wt -n "__fish_seen_subcommand_from list" -l exec -x -d "Run a shell command in each listed worktree"
+complete -c wt -n "__fish_seen_subcommand_from version" -l check -d "Check GitHub for a newer release"
 
 # Worktree completion for jump
 complete -c wt -n "__fish_seen_subcommand_from jump" -a "(__wt_worktrees)"
@@ -189,21 +410,69 @@ complete -c wt -n "__fish_seen_subcommand_from jump" -a "(__wt_worktrees)"
 # Worktree completion for remove
 complete -c wt -n "__fish_seen_subcommand_from remove" -a "(__wt_worktrees)"
 
+# Subcommand and key completion for config command
+complete -c wt -n "__fish_seen_subcommand_from config; and not __fish_seen_subcommand_from get set list" -a "get set list"
+complete -c wt -n "__fish_seen_subcommand_from config; and __fish_seen_subcommand_from get set" -a "worktrees_dir default_hook copy_dirs hook_shell branch_prefix post_remove_hook copy_max_depth copy_max_size"
+
 # Shell completion for completion command
-complete -c wt -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+complete -c wt -n "__fish_seen_subcommand_from completion" -a "auto bash zsh fish"
 `
 	_, err := fmt.Fprint(w, script)
 	return err
 }
 
 // completeWorktrees outputs worktree names for shell completion
-func completeWorktrees(w io.Writer) error {
+// With descriptions, each line is "name<TAB>branch" for shells that display descriptions
+// With root, RootJumpName is offered first for jumping to the repository root
+func completeWorktrees(w io.Writer, descriptions, root bool) error {
 	worktrees, err := listWorktrees()
 	if err != nil {
 		return err
 	}
+
+	if root {
+		if descriptions {
+			fmt.Fprintf(w, "%s\trepository root\n", RootJumpName)
+		} else {
+			fmt.Fprintln(w, RootJumpName)
+		}
+	}
+
+	// One git worktree list describes every worktree, however many there are
+	var wm *WorktreeManager
+	var infos []Worktree
+	if descriptions {
+		wm, err = NewWorktreeManager()
+		if err != nil {
+			return err
+		}
+		// Completion still offers the names when git cannot describe them
+		infos, _ = listWorktreeInfos()
+	}
+
 	for _, wt := range worktrees {
-		fmt.Fprintln(w, wt)
+		if wm == nil {
+			fmt.Fprintln(w, wt)
+			continue
+		}
+		if desc := worktreeDescription(infos, wm.WorktreePath(wt)); desc != "" {
+			fmt.Fprintf(w, "%s\t%s\n", wt, desc)
+		} else {
+			fmt.Fprintln(w, wt)
+		}
 	}
 	return nil
 }
+
+// worktreeDescription returns a short completion description for the worktree at path
+// It is the checked-out branch, "detached HEAD", or empty if git does not track the worktree
+func worktreeDescription(infos []Worktree, path string) string {
+	info, ok := findWorktree(infos, path)
+	switch {
+	case !ok:
+		return ""
+	case info.Detached:
+		return "detached HEAD"
+	}
+	return info.Branch
+}
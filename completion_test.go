@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -111,6 +113,86 @@ func TestListWorktrees(t *testing.T) {
 	})
 }
 
+func TestDetectShell(t *testing.T) {
+	origShellEnv := shellEnvFn
+	defer func() {
+		shellEnvFn = origShellEnv
+	}()
+
+	tests := []struct {
+		shellEnv string
+		want     string
+		wantErr  string
+	}{
+		{shellEnv: "/bin/bash", want: "bash"},
+		{shellEnv: "/usr/local/bin/zsh", want: "zsh"},
+		{shellEnv: "/opt/homebrew/bin/fish", want: "fish"},
+		{shellEnv: "zsh", want: "zsh"},
+		{shellEnv: "/bin/tcsh", wantErr: "cannot detect shell from $SHELL=/bin/tcsh (supported: bash, zsh, fish)"},
+		{shellEnv: "", wantErr: "cannot detect shell: $SHELL is not set (supported: bash, zsh, fish)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shellEnv, func(t *testing.T) {
+			shellEnvFn = func() string { return tt.shellEnv }
+
+			got, err := detectShell()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("detectShell() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("detectShell() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("reads $SHELL", func(t *testing.T) {
+		shellEnvFn = origShellEnv
+		t.Setenv("SHELL", "/bin/zsh")
+
+		if got, err := detectShell(); err != nil || got != "zsh" {
+			t.Errorf("detectShell() = %q, %v; want %q", got, err, "zsh")
+		}
+	})
+}
+
+func TestCompletionAuto(t *testing.T) {
+	origShellEnv := shellEnvFn
+	defer func() {
+		shellEnvFn = origShellEnv
+	}()
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			shellEnvFn = func() string { return "/bin/" + shell }
+
+			var auto, explicit bytes.Buffer
+			if err := completion("auto", &auto); err != nil {
+				t.Fatalf("completion(auto) unexpected error: %v", err)
+			}
+			completion(shell, &explicit)
+			if auto.String() != explicit.String() {
+				t.Errorf("completion(auto) with $SHELL=/bin/%s differs from completion(%s)", shell, shell)
+			}
+		})
+	}
+
+	t.Run("undetectable shell", func(t *testing.T) {
+		shellEnvFn = func() string { return "/bin/tcsh" }
+
+		var buf bytes.Buffer
+		err := completion("auto", &buf)
+		if err == nil || !strings.Contains(err.Error(), "supported: bash, zsh, fish") {
+			t.Errorf("completion(auto) error = %v, want error listing supported shells", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("completion(auto) wrote %q, want nothing", buf.String())
+		}
+	})
+}
+
 func TestCompletion(t *testing.T) {
 	t.Run("bash completion", func(t *testing.T) {
 		var buf bytes.Buffer
@@ -146,6 +228,12 @@ func TestCompletion(t *testing.T) {
 		if !strings.Contains(output, "__complete jump") {
 			t.Error("zsh completion missing dynamic worktree completion for jump")
 		}
+		if !strings.Contains(output, "__complete jump --descriptions") {
+			t.Error("zsh completion should request worktree descriptions")
+		}
+		if !strings.Contains(output, `worktrees+=("${name//:/\\:}:${line#*$'\t'}")`) {
+			t.Error("zsh completion should build name:description pairs for _describe")
+		}
 	})
 
 	t.Run("fish completion", func(t *testing.T) {
@@ -164,6 +252,9 @@ func TestCompletion(t *testing.T) {
 		if !strings.Contains(output, "__complete jump") {
 			t.Error("fish completion missing dynamic worktree completion for jump")
 		}
+		if !strings.Contains(output, "__complete jump --descriptions") {
+			t.Error("fish completion should request worktree descriptions")
+		}
 	})
 
 	t.Run("unsupported shell", func(t *testing.T) {
@@ -189,6 +280,71 @@ func TestBashCompletion(t *testing.T) {
 	}
 }
 
+// completeBash sources the bash completion script without bash-completion loaded and returns
+// the candidates it offers for line, whose last word is the one being completed
+func completeBash(t *testing.T, dir, line string) []string {
+	t.Helper()
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	var script bytes.Buffer
+	bashCompletion(&script)
+	test := script.String() + `
+read -ra COMP_WORDS <<< "$LINE"
+[[ $LINE == *" " ]] && COMP_WORDS+=("")
+COMP_CWORD=$((${#COMP_WORDS[@]} - 1))
+_wt_completions
+printf '%s\n' "${COMPREPLY[@]}"
+`
+	cmd := exec.Command(bash, "--norc", "--noprofile", "-c", test)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "LINE="+line)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("bash completion failed: %v", err)
+	}
+	return strings.Fields(string(out))
+}
+
+func TestBashCompletionWithoutBashCompletion(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "skeleton"), 0755)
+	os.WriteFile(filepath.Join(dir, "setup.sh"), nil, 0755)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=wt", "-c", "user.email=wt@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"tag", "v2.0.0"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "commands", line: "wt co", want: []string{"config", "completion"}},
+		{name: "flag values", line: "wt create --on-conflict c", want: []string{"checkout"}},
+		{name: "files", line: "wt create --hook se", want: []string{"setup.sh"}},
+		{name: "directories", line: "wt create --template-dir s", want: []string{"skeleton"}},
+		{name: "flags", line: "wt list --ab", want: []string{"--abbrev"}},
+		{name: "refs", line: "wt create --base ", want: []string{"main", "v2.0.0"}},
+		{name: "branches", line: "wt create --attach ", want: []string{"main"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completeBash(t, dir, tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("completions for %q = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestZshCompletion(t *testing.T) {
 	var buf bytes.Buffer
 	err := zshCompletion(&buf)
@@ -224,7 +380,7 @@ func TestCompleteWorktrees(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		err := completeWorktrees(&buf)
+		err := completeWorktrees(&buf, false, false)
 		if err != nil {
 			t.Errorf("completeWorktrees() unexpected error: %v", err)
 		}
@@ -248,7 +404,7 @@ func TestCompleteWorktrees(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		err := completeWorktrees(&buf)
+		err := completeWorktrees(&buf, false, false)
 		if err == nil || err.Error() != "mock error" {
 			t.Errorf("completeWorktrees() error = %v, want 'mock error'", err)
 		}
@@ -260,7 +416,7 @@ func TestCompleteWorktrees(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		err := completeWorktrees(&buf)
+		err := completeWorktrees(&buf, false, false)
 		if err != nil {
 			t.Errorf("completeWorktrees() unexpected error: %v", err)
 		}
@@ -269,3 +425,101 @@ func TestCompleteWorktrees(t *testing.T) {
 		}
 	})
 }
+
+func TestCompleteWorktreesDescriptions(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		listWorktreeInfosFn = origInfos
+	}()
+
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"feature-a", "review", "broken"}, nil
+	}
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// broken is not tracked by git, so it has no description
+	lists := 0
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		lists++
+		return []Worktree{
+			{Path: tmpDir, Branch: "main"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "feature-a"), Branch: "team/feature-a"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "review"), Detached: true},
+		}, nil
+	}
+
+	t.Run("tab-separated descriptions", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := completeWorktrees(&buf, true, false); err != nil {
+			t.Fatalf("completeWorktrees() unexpected error: %v", err)
+		}
+		want := "feature-a\tteam/feature-a\nreview\tdetached HEAD\nbroken\n"
+		if buf.String() != want {
+			t.Errorf("completeWorktrees() output = %q, want %q", buf.String(), want)
+		}
+		if lists != 1 {
+			t.Errorf("completeWorktrees() listed the worktrees %d times, want once", lists)
+		}
+	})
+
+	t.Run("plain output without descriptions", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := completeWorktrees(&buf, false, false); err != nil {
+			t.Fatalf("completeWorktrees() unexpected error: %v", err)
+		}
+		if buf.String() != "feature-a\nreview\nbroken\n" {
+			t.Errorf("completeWorktrees() output = %q, want plain names", buf.String())
+		}
+	})
+
+	t.Run("root entry for jump", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := completeWorktrees(&buf, true, true); err != nil {
+			t.Fatalf("completeWorktrees() unexpected error: %v", err)
+		}
+		want := "@\trepository root\nfeature-a\tteam/feature-a\nreview\tdetached HEAD\nbroken\n"
+		if buf.String() != want {
+			t.Errorf("completeWorktrees() output = %q, want %q", buf.String(), want)
+		}
+
+		buf.Reset()
+		if err := completeWorktrees(&buf, false, true); err != nil {
+			t.Fatalf("completeWorktrees() unexpected error: %v", err)
+		}
+		if buf.String() != "@\nfeature-a\nreview\nbroken\n" {
+			t.Errorf("completeWorktrees() output = %q, want @ first", buf.String())
+		}
+	})
+
+	t.Run("names without descriptions when git worktree list fails", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("failed to list git worktrees: exit status 128")
+		}
+
+		var buf bytes.Buffer
+		if err := completeWorktrees(&buf, true, false); err != nil {
+			t.Fatalf("completeWorktrees() unexpected error: %v", err)
+		}
+		if buf.String() != "feature-a\nreview\nbroken\n" {
+			t.Errorf("completeWorktrees() output = %q, want plain names", buf.String())
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := completeWorktrees(&buf, true, false)
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("completeWorktrees() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
@@ -1,11 +1,421 @@
 package main
 
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
 // Version is set at build time via ldflags
 var Version = "dev"
 
 // Directory structure constants
 const (
-	WorktreesDir = ".worktrees"
-	ClaudeDir    = ".claude"
-	DefaultHook  = ".worktree-hook"
+	WorktreesDir          = ".worktrees"
+	ClaudeDir             = ".claude"
+	DefaultHook           = ".worktree-hook"
+	DefaultPostRemoveHook = ".worktree-post-remove"
+	ConfigFile            = ".wtconfig"
+	CopyManifest          = ".worktree-copy"
+)
+
+// NoClaudeCopyEnv disables the .claude/ symlink in new worktrees when set to 1
+const NoClaudeCopyEnv = "WT_NO_CLAUDE_COPY"
+
+// HookEnv names a hook script used by create when --hook is not given
+const HookEnv = "WT_HOOK"
+
+// GitConfigHookKey is the git config key naming a hook script used by create when neither --hook nor $WT_HOOK is set
+const GitConfigHookKey = "wt.hook"
+
+// NameEnv passes the name of the removed worktree to the post-remove hook
+const NameEnv = "WT_NAME"
+
+// GlobalConfigFile is the per-user config file, relative to the user config directory
+var GlobalConfigFile = filepath.Join("wt", "config")
+
+// Function variables for testing
+var (
+	configPathFn    = defaultConfigPath
+	userConfigDirFn = os.UserConfigDir
 )
+
+// Config holds the settings read from the repo-local config file
+type Config struct {
+	WorktreesDir   string
+	DefaultHook    string
+	CopyDirs       []string
+	HookShell      string
+	BranchPrefix   string
+	PostRemoveHook string
+	CopyMaxDepth   int
+	CopyMaxSize    int64
+	FlagDefaults   flagDefaults
+}
+
+// configKey describes a supported configuration key
+type configKey struct {
+	name string
+	get  func(c *Config) string
+	set  func(c *Config, value string) error
+}
+
+// configKeys lists all supported configuration keys in display order
+var configKeys = []configKey{
+	{
+		name: "worktrees_dir",
+		get:  func(c *Config) string { return c.WorktreesDir },
+		set: func(c *Config, value string) error {
+			if err := validateRelPath(value); err != nil {
+				return err
+			}
+			c.WorktreesDir = value
+			return nil
+		},
+	},
+	{
+		name: "default_hook",
+		get:  func(c *Config) string { return c.DefaultHook },
+		set: func(c *Config, value string) error {
+			if value == "" {
+				return fmt.Errorf("value must not be empty")
+			}
+			c.DefaultHook = value
+			return nil
+		},
+	},
+	{
+		name: "copy_dirs",
+		get:  func(c *Config) string { return strings.Join(c.CopyDirs, ",") },
+		set: func(c *Config, value string) error {
+			var dirs []string
+			for _, dir := range strings.Split(value, ",") {
+				dir = strings.TrimSpace(dir)
+				if dir == "" {
+					continue
+				}
+				if err := validateRelPath(dir); err != nil {
+					return err
+				}
+				dirs = append(dirs, dir)
+			}
+			c.CopyDirs = dirs
+			return nil
+		},
+	},
+	{
+		name: "hook_shell",
+		get:  func(c *Config) string { return c.HookShell },
+		set: func(c *Config, value string) error {
+			c.HookShell = value
+			return nil
+		},
+	},
+	{
+		name: "branch_prefix",
+		get:  func(c *Config) string { return c.BranchPrefix },
+		set: func(c *Config, value string) error {
+			c.BranchPrefix = value
+			return nil
+		},
+	},
+	{
+		name: "post_remove_hook",
+		get:  func(c *Config) string { return c.PostRemoveHook },
+		set: func(c *Config, value string) error {
+			if value == "" {
+				return fmt.Errorf("value must not be empty")
+			}
+			c.PostRemoveHook = value
+			return nil
+		},
+	},
+	{
+		name: "copy_max_depth",
+		get: func(c *Config) string {
+			if c.CopyMaxDepth == 0 {
+				return ""
+			}
+			return strconv.Itoa(c.CopyMaxDepth)
+		},
+		set: func(c *Config, value string) error {
+			if value == "" {
+				c.CopyMaxDepth = 0
+				return nil
+			}
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth <= 0 {
+				return fmt.Errorf("%q is not a positive number of levels", value)
+			}
+			c.CopyMaxDepth = depth
+			return nil
+		},
+	},
+	{
+		name: "copy_max_size",
+		get: func(c *Config) string {
+			if c.CopyMaxSize == 0 {
+				return ""
+			}
+			return formatSize(c.CopyMaxSize)
+		},
+		set: func(c *Config, value string) error {
+			if value == "" {
+				c.CopyMaxSize = 0
+				return nil
+			}
+			size, err := parseSize(value)
+			if err != nil {
+				return err
+			}
+			c.CopyMaxSize = size
+			return nil
+		},
+	},
+}
+
+// defaultConfig returns the configuration used when no config file is present
+func defaultConfig() *Config {
+	return &Config{
+		WorktreesDir:   WorktreesDir,
+		DefaultHook:    DefaultHook,
+		PostRemoveHook: DefaultPostRemoveHook,
+	}
+}
+
+// validateRelPath checks that a config value names a directory inside the repository root
+func validateRelPath(value string) error {
+	if value == "" {
+		return fmt.Errorf("value must not be empty")
+	}
+	if filepath.IsAbs(value) {
+		return fmt.Errorf("%s must be relative to the repository root", value)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(value), "/") {
+		if part == ".." {
+			return fmt.Errorf("%s must not contain '..'", value)
+		}
+	}
+	if filepath.Clean(value) == "." {
+		return fmt.Errorf("%s must name a directory inside the repository root", value)
+	}
+	return nil
+}
+
+// findConfigKey returns the configKey with the given name
+// A name of the form command.flag is the default for that flag
+func findConfigKey(name string) (configKey, error) {
+	for _, key := range configKeys {
+		if key.name == name {
+			return key, nil
+		}
+	}
+	if strings.Contains(name, ".") {
+		return flagDefaultKey(name)
+	}
+	return configKey{}, fmt.Errorf("unknown config key: %s", name)
+}
+
+// parseConfigLine splits a "key = value" line, returning ok=false for blanks and comments
+func parseConfigLine(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+	key, value, _ = strings.Cut(line, "=")
+	return strings.TrimSpace(key), strings.TrimSpace(value), true
+}
+
+// loadConfig reads the config file at path, returning defaults if it does not exist
+// Lines with unknown keys or invalid values are skipped and reported as problems,
+// so a single bad line doesn't lock the user out of the tool
+func loadConfig(path string) (*Config, []error, error) {
+	cfg := defaultConfig()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", ConfigFile, err)
+	}
+
+	var problems []error
+	for i, line := range strings.Split(string(data), "\n") {
+		name, value, ok := parseConfigLine(line)
+		if !ok {
+			continue
+		}
+		key, err := findConfigKey(name)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s:%d: %w", ConfigFile, i+1, err))
+			continue
+		}
+		if err := key.set(cfg, value); err != nil {
+			problems = append(problems, fmt.Errorf("%s:%d: invalid %s: %w", ConfigFile, i+1, name, err))
+		}
+	}
+	return cfg, problems, nil
+}
+
+// warnConfigProblems prints each config problem to w as an ignored-line warning
+func warnConfigProblems(w io.Writer, problems []error) {
+	for _, problem := range problems {
+		fmt.Fprintf(w, "warning: %v (ignored)\n", problem)
+	}
+}
+
+// saveConfigValue sets key to value in the config file at path, preserving other lines
+func saveConfigValue(path, name, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", ConfigFile, err)
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+
+	entry := name + " = " + value
+	replaced := false
+	for i, line := range lines {
+		if key, _, ok := parseConfigLine(line); ok && key == name {
+			lines[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ConfigFile, err)
+	}
+	return nil
+}
+
+func defaultConfigPath() (string, error) {
+	root, err := gitMainRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, ConfigFile), nil
+}
+
+// GlobalConfig holds the per-user settings shared by every repository
+type GlobalConfig struct {
+	HookTemplate string       // hook run by create when the repository has none; empty to disable
+	FlagDefaults flagDefaults // command.flag defaults, overridden by the repository's
+}
+
+// globalConfigPath returns the path of the per-user config file
+func globalConfigPath() (string, error) {
+	dir, err := userConfigDirFn()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, GlobalConfigFile), nil
+}
+
+// loadGlobalConfig reads the per-user config file at path, returning an empty config if it does not exist
+// Relative hook_template paths are resolved against the directory containing the file
+// command.flag keys set flag defaults as in .wtconfig; lines with unknown keys are skipped and reported as problems, as in loadConfig
+func loadGlobalConfig(path string) (*GlobalConfig, []error, error) {
+	cfg := &GlobalConfig{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var problems []error
+	for i, line := range strings.Split(string(data), "\n") {
+		name, value, ok := parseConfigLine(line)
+		if !ok {
+			continue
+		}
+		if name != "hook_template" {
+			if _, err := flagDefaultKey(name); err != nil {
+				problems = append(problems, fmt.Errorf("%s:%d: %w", path, i+1, err))
+				continue
+			}
+			cfg.FlagDefaults.set(name, value)
+			continue
+		}
+		cfg.HookTemplate = ""
+		if value != "" {
+			cfg.HookTemplate = expandHome(value)
+			if !filepath.IsAbs(cfg.HookTemplate) {
+				cfg.HookTemplate = filepath.Join(filepath.Dir(path), cfg.HookTemplate)
+			}
+		}
+	}
+	return cfg, problems, nil
+}
+
+// configCmd implements `wt config get|set|list`
+func configCmd(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("config subcommand required (get, set, list)")
+	}
+
+	path, err := configPathFn()
+	if err != nil {
+		return err
+	}
+
+	// set edits the file directly so it can be used to repair a config with bad lines
+	if args[0] == "set" {
+		if len(args) != 3 {
+			return fmt.Errorf("usage: wt config set <key> <value>")
+		}
+		key, err := findConfigKey(args[1])
+		if err != nil {
+			return err
+		}
+		cfg := defaultConfig()
+		if err := key.set(cfg, args[2]); err != nil {
+			return fmt.Errorf("invalid %s: %w", key.name, err)
+		}
+		return saveConfigValue(path, key.name, key.get(cfg))
+	}
+
+	cfg, problems, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	warnConfigProblems(os.Stderr, problems)
+
+	switch args[0] {
+	case "get":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: wt config get <key>")
+		}
+		key, err := findConfigKey(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, key.get(cfg))
+		return nil
+	case "list":
+		if len(args) != 1 {
+			return fmt.Errorf("unexpected argument: %s", args[1])
+		}
+		for _, key := range configKeys {
+			fmt.Fprintf(w, "%s=%s\n", key.name, key.get(cfg))
+		}
+		for _, name := range cfg.FlagDefaults.names() {
+			fmt.Fprintf(w, "%s=%s\n", name, cfg.FlagDefaults[name])
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown config subcommand: %s (supported: get, set, list)", args[0])
+	}
+}
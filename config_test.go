@@ -1,6 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -27,5 +34,525 @@ func TestConstants(t *testing.T) {
 		if DefaultHook != ".worktree-hook" {
 			t.Errorf("DefaultHook = %q, want %q", DefaultHook, ".worktree-hook")
 		}
+		if ConfigFile != ".wtconfig" {
+			t.Errorf("ConfigFile = %q, want %q", ConfigFile, ".wtconfig")
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("missing file returns defaults", func(t *testing.T) {
+		cfg, problems, err := loadConfig(filepath.Join(t.TempDir(), ConfigFile))
+		if err != nil || len(problems) != 0 {
+			t.Fatalf("loadConfig() unexpected error: %v", err)
+		}
+		if cfg.WorktreesDir != WorktreesDir {
+			t.Errorf("WorktreesDir = %q, want %q", cfg.WorktreesDir, WorktreesDir)
+		}
+		if cfg.DefaultHook != DefaultHook {
+			t.Errorf("DefaultHook = %q, want %q", cfg.DefaultHook, DefaultHook)
+		}
+		if len(cfg.CopyDirs) != 0 {
+			t.Errorf("CopyDirs = %v, want empty", cfg.CopyDirs)
+		}
+	})
+
+	t.Run("parses values, comments and blank lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFile)
+		content := "# wt settings\n\nworktrees_dir = trees\ndefault_hook=setup.sh\ncopy_dirs = .vscode, .idea ,\nhook_shell = bash -eu\n"
+		os.WriteFile(path, []byte(content), 0644)
+
+		cfg, problems, err := loadConfig(path)
+		if err != nil || len(problems) != 0 {
+			t.Fatalf("loadConfig() unexpected error: %v, problems: %v", err, problems)
+		}
+		if cfg.WorktreesDir != "trees" {
+			t.Errorf("WorktreesDir = %q, want %q", cfg.WorktreesDir, "trees")
+		}
+		if cfg.DefaultHook != "setup.sh" {
+			t.Errorf("DefaultHook = %q, want %q", cfg.DefaultHook, "setup.sh")
+		}
+		if strings.Join(cfg.CopyDirs, ",") != ".vscode,.idea" {
+			t.Errorf("CopyDirs = %v, want [.vscode .idea]", cfg.CopyDirs)
+		}
+		if cfg.HookShell != "bash -eu" {
+			t.Errorf("HookShell = %q, want %q", cfg.HookShell, "bash -eu")
+		}
+	})
+
+	t.Run("bad lines are skipped and reported", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFile)
+		os.WriteFile(path, []byte("# comment\nbogus = 1\nworktrees_dir = /abs/path\ndefault_hook = setup.sh\n"), 0644)
+
+		cfg, problems, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("loadConfig() unexpected error: %v", err)
+		}
+		want := []string{
+			ConfigFile + ":2: unknown config key: bogus",
+			ConfigFile + ":3: invalid worktrees_dir: /abs/path must be relative to the repository root",
+		}
+		if len(problems) != len(want) {
+			t.Fatalf("loadConfig() problems = %v, want %v", problems, want)
+		}
+		for i := range want {
+			if problems[i].Error() != want[i] {
+				t.Errorf("problem %d = %q, want %q", i, problems[i], want[i])
+			}
+		}
+		if cfg.WorktreesDir != WorktreesDir || cfg.DefaultHook != "setup.sh" {
+			t.Errorf("loadConfig() = %+v, want default worktrees_dir and the valid default_hook", cfg)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		// A directory cannot be read as a file
+		_, _, err := loadConfig(t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), "failed to read "+ConfigFile) {
+			t.Errorf("loadConfig() error = %v, want read error", err)
+		}
+	})
+}
+
+func TestConfigKeyValidation(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"worktrees_dir", "", "value must not be empty"},
+		{"worktrees_dir", "/tmp/trees", "/tmp/trees must be relative to the repository root"},
+		{"default_hook", "", "value must not be empty"},
+		{"copy_dirs", ".vscode,/etc", "/etc must be relative to the repository root"},
+		{"copy_dirs", ".", ". must name a directory inside the repository root"},
+		{"copy_dirs", "./", "./ must name a directory inside the repository root"},
+		{"copy_dirs", "../..", "../.. must not contain '..'"},
+		{"copy_dirs", "a/../../b", "a/../../b must not contain '..'"},
+		{"worktrees_dir", "../outside", "../outside must not contain '..'"},
+		{"worktrees_dir", "trees/../x", "trees/../x must not contain '..'"},
+		{"worktrees_dir", "build/trees", ""},
+		{"copy_dirs", "./.vscode", ""},
+		{"copy_dirs", "", ""},
+		{"default_hook", "hooks/setup.sh", ""},
+		{"post_remove_hook", "", "value must not be empty"},
+		{"post_remove_hook", "hooks/cleanup.sh", ""},
+		{"copy_max_depth", "8", ""},
+		{"copy_max_depth", "", ""},
+		{"copy_max_depth", "0", `"0" is not a positive number of levels`},
+		{"copy_max_depth", "deep", `"deep" is not a positive number of levels`},
+		{"copy_max_size", "500M", ""},
+		{"copy_max_size", "", ""},
+		{"copy_max_size", "huge", `"huge" is not a size (use e.g. 500M, 2G or a number of bytes)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			key, err := findConfigKey(tt.key)
+			if err != nil {
+				t.Fatalf("findConfigKey(%q) unexpected error: %v", tt.key, err)
+			}
+			err = key.set(defaultConfig(), tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("set(%q) unexpected error: %v", tt.value, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("set(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveConfigValue(t *testing.T) {
+	t.Run("creates file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFile)
+		if err := saveConfigValue(path, "default_hook", "setup.sh"); err != nil {
+			t.Fatalf("saveConfigValue() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "default_hook = setup.sh\n" {
+			t.Errorf("file content = %q, want %q", string(data), "default_hook = setup.sh\n")
+		}
+	})
+
+	t.Run("replaces existing key and keeps other lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ConfigFile)
+		os.WriteFile(path, []byte("# team settings\ndefault_hook = old.sh\ncopy_dirs = .vscode\n"), 0644)
+
+		if err := saveConfigValue(path, "default_hook", "new.sh"); err != nil {
+			t.Fatalf("saveConfigValue() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		want := "# team settings\ndefault_hook = new.sh\ncopy_dirs = .vscode\n"
+		if string(data) != want {
+			t.Errorf("file content = %q, want %q", string(data), want)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		err := saveConfigValue(t.TempDir(), "default_hook", "setup.sh")
+		if err == nil || !strings.Contains(err.Error(), "failed to read "+ConfigFile) {
+			t.Errorf("saveConfigValue() error = %v, want read error", err)
+		}
+	})
+
+	t.Run("write error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", ConfigFile)
+		err := saveConfigValue(path, "default_hook", "setup.sh")
+		if err == nil || !strings.Contains(err.Error(), "failed to write "+ConfigFile) {
+			t.Errorf("saveConfigValue() error = %v, want write error", err)
+		}
+	})
+}
+
+func TestDefaultConfigPath(t *testing.T) {
+	origGitMainRoot := gitMainRootFn
+	defer func() {
+		gitMainRootFn = origGitMainRoot
+	}()
+
+	t.Run("joins repository root", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "/test/repo", nil
+		}
+		path, err := defaultConfigPath()
+		if err != nil {
+			t.Errorf("defaultConfigPath() unexpected error: %v", err)
+		}
+		if path != filepath.Join("/test/repo", ConfigFile) {
+			t.Errorf("defaultConfigPath() = %q, want %q", path, filepath.Join("/test/repo", ConfigFile))
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		_, err := defaultConfigPath()
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("defaultConfigPath() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestConfigCmd(t *testing.T) {
+	origConfigPath := configPathFn
+	defer func() {
+		configPathFn = origConfigPath
+	}()
+
+	useConfigFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), ConfigFile)
+		if content != "" {
+			os.WriteFile(path, []byte(content), 0644)
+		}
+		configPathFn = func() (string, error) {
+			return path, nil
+		}
+		return path
+	}
+
+	t.Run("get returns default", func(t *testing.T) {
+		useConfigFile(t, "")
+
+		var buf bytes.Buffer
+		if err := configCmd([]string{"get", "worktrees_dir"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		if buf.String() != WorktreesDir+"\n" {
+			t.Errorf("configCmd() output = %q, want %q", buf.String(), WorktreesDir+"\n")
+		}
+	})
+
+	t.Run("copy limits are shown in their shortest form", func(t *testing.T) {
+		path := useConfigFile(t, "")
+
+		var buf bytes.Buffer
+		if err := configCmd([]string{"set", "copy_max_size", "524288000"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		if err := configCmd([]string{"set", "copy_max_depth", "6"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if want := "copy_max_size = 500M\ncopy_max_depth = 6\n"; string(data) != want {
+			t.Errorf("config file = %q, want %q", string(data), want)
+		}
+	})
+
+	t.Run("flag defaults are set, read and listed", func(t *testing.T) {
+		path := useConfigFile(t, "")
+
+		var buf bytes.Buffer
+		for _, args := range [][]string{{"set", "list.limit", "20"}, {"set", "create.base", "main"}} {
+			if err := configCmd(args, &buf); err != nil {
+				t.Fatalf("configCmd(%v) unexpected error: %v", args, err)
+			}
+		}
+		data, _ := os.ReadFile(path)
+		if want := "list.limit = 20\ncreate.base = main\n"; string(data) != want {
+			t.Errorf("config file = %q, want %q", string(data), want)
+		}
+
+		if err := configCmd([]string{"get", "create.base"}, &buf); err != nil || buf.String() != "main\n" {
+			t.Errorf("configCmd(get) = %q, %v; want %q", buf.String(), err, "main\n")
+		}
+
+		buf.Reset()
+		if err := configCmd([]string{"list"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		if want := "copy_max_size=\ncreate.base=main\nlist.limit=20\n"; !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("configCmd(list) output = %q, want it to end with %q", buf.String(), want)
+		}
+	})
+
+	t.Run("boolean flags cannot have a default", func(t *testing.T) {
+		useConfigFile(t, "")
+
+		err := configCmd([]string{"set", "create.push", "true"}, io.Discard)
+		if err == nil || err.Error() != "create.push cannot have a default: --push takes no value" {
+			t.Errorf("configCmd() error = %v, want boolean flag error", err)
+		}
+	})
+
+	t.Run("set persists value", func(t *testing.T) {
+		path := useConfigFile(t, "")
+
+		var buf bytes.Buffer
+		if err := configCmd([]string{"set", "copy_dirs", ".vscode, .idea"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "copy_dirs = .vscode,.idea\n" {
+			t.Errorf("config file = %q, want %q", string(data), "copy_dirs = .vscode,.idea\n")
+		}
+
+		buf.Reset()
+		if err := configCmd([]string{"get", "copy_dirs"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		if buf.String() != ".vscode,.idea\n" {
+			t.Errorf("configCmd() output = %q, want %q", buf.String(), ".vscode,.idea\n")
+		}
+	})
+
+	t.Run("list output format", func(t *testing.T) {
+		useConfigFile(t, "default_hook = setup.sh\n")
+
+		var buf bytes.Buffer
+		if err := configCmd([]string{"list"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		want := "worktrees_dir=.worktrees\ndefault_hook=setup.sh\ncopy_dirs=\nhook_shell=\nbranch_prefix=\npost_remove_hook=.worktree-post-remove\ncopy_max_depth=\ncopy_max_size=\n"
+		if buf.String() != want {
+			t.Errorf("configCmd() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	errTests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"no subcommand", []string{}, "config subcommand required (get, set, list)"},
+		{"get without key", []string{"get"}, "usage: wt config get <key>"},
+		{"get unknown key", []string{"get", "poll"}, "unknown config key: poll"},
+		{"set without value", []string{"set", "default_hook"}, "usage: wt config set <key> <value>"},
+		{"set unknown key", []string{"set", "bogus", "1"}, "unknown config key: bogus"},
+		{"set invalid value", []string{"set", "worktrees_dir", "/abs"}, "invalid worktrees_dir: /abs must be relative to the repository root"},
+		{"list with extra arg", []string{"list", "extra"}, "unexpected argument: extra"},
+		{"unknown subcommand", []string{"unset"}, "unknown config subcommand: unset (supported: get, set, list)"},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			useConfigFile(t, "")
+
+			var buf bytes.Buffer
+			err := configCmd(tt.args, &buf)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("configCmd(%v) error = %v, want %q", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("config path error", func(t *testing.T) {
+		configPathFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := configCmd([]string{"list"}, &buf)
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("configCmd() error = %v, want 'not in a git repository'", err)
+		}
+	})
+
+	t.Run("list warns about bad lines and still works", func(t *testing.T) {
+		useConfigFile(t, "bogus = 1\ndefault_hook = setup.sh\n")
+
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+
+		var buf bytes.Buffer
+		err := configCmd([]string{"list"}, &buf)
+
+		w.Close()
+		os.Stderr = oldStderr
+		var stderr bytes.Buffer
+		io.Copy(&stderr, r)
+
+		if err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		if !strings.Contains(buf.String(), "default_hook=setup.sh\n") {
+			t.Errorf("configCmd() output = %q, want valid settings listed", buf.String())
+		}
+		if stderr.String() != "warning: "+ConfigFile+":1: unknown config key: bogus (ignored)\n" {
+			t.Errorf("configCmd() stderr = %q, want warning for the bad line", stderr.String())
+		}
+	})
+
+	t.Run("set repairs a file with bad lines", func(t *testing.T) {
+		path := useConfigFile(t, "worktrees_dir = /abs\n")
+
+		var buf bytes.Buffer
+		if err := configCmd([]string{"set", "worktrees_dir", "trees"}, &buf); err != nil {
+			t.Fatalf("configCmd() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		if string(data) != "worktrees_dir = trees\n" {
+			t.Errorf("config file = %q, want repaired value", data)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		configPathFn = func() (string, error) {
+			return t.TempDir(), nil
+		}
+
+		var buf bytes.Buffer
+		err := configCmd([]string{"list"}, &buf)
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read "+ConfigFile) {
+			t.Errorf("configCmd() error = %v, want read error", err)
+		}
+	})
+}
+
+func TestGlobalConfigPath(t *testing.T) {
+	origUserConfigDir := userConfigDirFn
+	defer func() {
+		userConfigDirFn = origUserConfigDir
+	}()
+
+	t.Run("inside user config dir", func(t *testing.T) {
+		userConfigDirFn = func() (string, error) {
+			return "/home/user/.config", nil
+		}
+		path, err := globalConfigPath()
+		if err != nil || path != "/home/user/.config/wt/config" {
+			t.Errorf("globalConfigPath() = %q, %v; want %q", path, err, "/home/user/.config/wt/config")
+		}
+	})
+
+	t.Run("user config dir unknown", func(t *testing.T) {
+		userConfigDirFn = func() (string, error) {
+			return "", errors.New("neither $XDG_CONFIG_HOME nor $HOME are defined")
+		}
+		if _, err := globalConfigPath(); err == nil {
+			t.Error("globalConfigPath() expected error")
+		}
+	})
+}
+
+func TestLoadGlobalConfig(t *testing.T) {
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		userHomeDirFn = origUserHomeDir
+	}()
+	userHomeDirFn = func() (string, error) {
+		return "/home/user", nil
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "absolute template", content: "hook_template = /opt/hooks/setup.sh\n", want: "/opt/hooks/setup.sh"},
+		{name: "home relative template", content: "hook_template = ~/hooks/setup.sh\n", want: "/home/user/hooks/setup.sh"},
+		{name: "relative to config dir", content: "# team hook\nhook_template = setup.sh\n", want: "setup.sh"},
+		{name: "empty value disables", content: "hook_template = /opt/a.sh\nhook_template =\n", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			cfg, problems, err := loadGlobalConfig(path)
+			if err != nil || len(problems) != 0 {
+				t.Fatalf("loadGlobalConfig() unexpected error: %v, problems: %v", err, problems)
+			}
+			want := tt.want
+			if want != "" && !filepath.IsAbs(want) {
+				want = filepath.Join(dir, want)
+			}
+			if cfg.HookTemplate != want {
+				t.Errorf("HookTemplate = %q, want %q", cfg.HookTemplate, want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		cfg, problems, err := loadGlobalConfig(filepath.Join(t.TempDir(), "config"))
+		if err != nil || len(problems) != 0 || cfg.HookTemplate != "" {
+			t.Errorf("loadGlobalConfig() = %+v, %v, %v; want empty config", cfg, problems, err)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config")
+		os.WriteFile(path, []byte("worktrees_dir = trees\nhook_template = /opt/a.sh\n"), 0644)
+
+		cfg, problems, err := loadGlobalConfig(path)
+		if err != nil {
+			t.Fatalf("loadGlobalConfig() unexpected error: %v", err)
+		}
+		want := path + ":1: unknown config key: worktrees_dir"
+		if len(problems) != 1 || problems[0].Error() != want {
+			t.Errorf("loadGlobalConfig() problems = %v, want %q", problems, want)
+		}
+		if cfg.HookTemplate != "/opt/a.sh" {
+			t.Errorf("HookTemplate = %q, want valid lines applied", cfg.HookTemplate)
+		}
+	})
+
+	t.Run("flag defaults", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config")
+		os.WriteFile(path, []byte("create.base = develop\nlist.limit = 5\nlist.limit =\ncreate.push = true\n"), 0644)
+
+		cfg, problems, err := loadGlobalConfig(path)
+		if err != nil {
+			t.Fatalf("loadGlobalConfig() unexpected error: %v", err)
+		}
+		if want := (flagDefaults{"create.base": "develop"}); !reflect.DeepEqual(cfg.FlagDefaults, want) {
+			t.Errorf("FlagDefaults = %v, want %v", cfg.FlagDefaults, want)
+		}
+		want := path + ":4: create.push cannot have a default: --push takes no value"
+		if len(problems) != 1 || problems[0].Error() != want {
+			t.Errorf("loadGlobalConfig() problems = %v, want %q", problems, want)
+		}
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		path := t.TempDir()
+		_, _, err := loadGlobalConfig(path)
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read "+path) {
+			t.Errorf("loadGlobalConfig() error = %v, want read error", err)
+		}
 	})
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readlinkFn is replaceable for testing
+var readlinkFn = os.Readlink
+
+// copyLimits bounds how much copyDir copies; zero values mean no limit
+type copyLimits struct {
+	maxDepth int   // deepest level below src an entry may be at; direct children are at level 1
+	maxSize  int64 // total bytes of regular files that may be copied
+}
+
+// copyDir recursively copies the src directory to dst, preserving file modes and symlinks.
+// Special files such as sockets and devices are skipped, and so are entries that already
+// exist in dst, so files git checked out into a new worktree are never overwritten.
+// Paths listed in exclude are not copied; they are compared after resolving symlinks.
+// The copy fails as soon as it would go past limits, leaving what was copied so far in place.
+func copyDir(src, dst string, limits copyLimits, exclude ...string) error {
+	excluded := make(map[string]bool, len(exclude))
+	for _, path := range exclude {
+		excluded[resolvePath(path)] = true
+	}
+
+	var copied int64
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if len(excluded) > 0 && excluded[resolvePath(path)] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Walk only visits paths under src, so Rel cannot fail
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if limits.maxDepth > 0 && rel != "." && strings.Count(rel, string(filepath.Separator)) >= limits.maxDepth {
+			return fmt.Errorf("%s is more than %d levels deep (copy_max_depth)", rel, limits.maxDepth)
+		}
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm())
+		}
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := readlinkFn(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			if copied += info.Size(); limits.maxSize > 0 && copied > limits.maxSize {
+				return fmt.Errorf("more than %s to copy (copy_max_size)", formatSize(limits.maxSize))
+			}
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+// readCopyManifest returns the paths listed in the CopyManifest at root, relative to root
+// Each line is a path or a glob pattern; blank lines and lines starting with # are skipped.
+// Entries that match nothing are skipped with a warning, and a missing manifest lists nothing.
+func readCopyManifest(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, CopyManifest))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", CopyManifest, err)
+	}
+
+	var paths []string
+	seen := map[string]bool{}
+	for i, line := range strings.Split(string(data), "\n") {
+		entry := strings.TrimSpace(line)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if err := validateRelPath(entry); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", CopyManifest, i+1, err)
+		}
+		matches, err := fs.Glob(os.DirFS(root), filepath.ToSlash(filepath.Clean(entry)))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %s: %w", CopyManifest, i+1, entry, err)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s matches nothing, skipping it\n", CopyManifest, entry)
+			continue
+		}
+		for _, match := range matches {
+			if path := filepath.FromSlash(match); !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, largest first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}}
+
+// parseSize parses a positive number of bytes with an optional K, M or G suffix (powers of 1024)
+func parseSize(s string) (int64, error) {
+	n, mult := s, int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(strings.ToUpper(s), unit.suffix) {
+			n, mult = s[:len(s)-1], unit.bytes
+			break
+		}
+	}
+	size, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || size <= 0 || size > math.MaxInt64/mult {
+		return 0, fmt.Errorf("%q is not a size (use e.g. 500M, 2G or a number of bytes)", s)
+	}
+	return size * mult, nil
+}
+
+// formatSize formats bytes for parseSize, using the largest suffix that represents it exactly
+func formatSize(bytes int64) string {
+	for _, unit := range sizeUnits {
+		if bytes%unit.bytes == 0 {
+			return strconv.FormatInt(bytes/unit.bytes, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// copyFile copies a single regular file, creating dst with the given mode
+func copyFile(src, dst string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, mode)
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestCopyDir(t *testing.T) {
+	t.Run("copies files, directories and symlinks", func(t *testing.T) {
+		src := t.TempDir()
+		os.MkdirAll(filepath.Join(src, "nested", "deep"), 0755)
+		os.WriteFile(filepath.Join(src, "top.txt"), []byte("top"), 0644)
+		os.WriteFile(filepath.Join(src, "nested", "deep", "run.sh"), []byte("#!/bin/sh\n"), 0755)
+		os.Symlink("top.txt", filepath.Join(src, "link"))
+
+		dst := filepath.Join(t.TempDir(), "copy")
+		if err := copyDir(src, dst, copyLimits{}); err != nil {
+			t.Fatalf("copyDir() unexpected error: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dst, "top.txt"))
+		if err != nil || string(data) != "top" {
+			t.Errorf("top.txt = %q, %v; want %q", data, err, "top")
+		}
+		info, err := os.Stat(filepath.Join(dst, "nested", "deep", "run.sh"))
+		if err != nil {
+			t.Fatalf("failed to stat copied file: %v", err)
+		}
+		if info.Mode().Perm() != 0755 {
+			t.Errorf("run.sh mode = %v, want 0755", info.Mode().Perm())
+		}
+		target, err := os.Readlink(filepath.Join(dst, "link"))
+		if err != nil || target != "top.txt" {
+			t.Errorf("link target = %q, %v; want %q", target, err, "top.txt")
+		}
+	})
+
+	t.Run("keeps entries that already exist in the destination", func(t *testing.T) {
+		src := t.TempDir()
+		os.MkdirAll(filepath.Join(src, "nested"), 0755)
+		os.WriteFile(filepath.Join(src, "tracked.txt"), []byte("from root"), 0644)
+		os.WriteFile(filepath.Join(src, "nested", "new.txt"), []byte("new"), 0644)
+		os.Symlink("tracked.txt", filepath.Join(src, "link"))
+
+		// Simulate a directory git already checked out into the worktree
+		dst := t.TempDir()
+		os.MkdirAll(filepath.Join(dst, "nested"), 0755)
+		os.WriteFile(filepath.Join(dst, "tracked.txt"), []byte("checked out"), 0644)
+		os.Symlink("elsewhere", filepath.Join(dst, "link"))
+
+		if err := copyDir(src, dst, copyLimits{}); err != nil {
+			t.Fatalf("copyDir() unexpected error: %v", err)
+		}
+
+		data, _ := os.ReadFile(filepath.Join(dst, "tracked.txt"))
+		if string(data) != "checked out" {
+			t.Errorf("tracked.txt = %q, want the checked out content kept", data)
+		}
+		target, _ := os.Readlink(filepath.Join(dst, "link"))
+		if target != "elsewhere" {
+			t.Errorf("link target = %q, want the existing link kept", target)
+		}
+		data, _ = os.ReadFile(filepath.Join(dst, "nested", "new.txt"))
+		if string(data) != "new" {
+			t.Errorf("nested/new.txt = %q, want it copied into the existing directory", data)
+		}
+	})
+
+	t.Run("skips excluded paths", func(t *testing.T) {
+		src := t.TempDir()
+		os.MkdirAll(filepath.Join(src, "skipped", "deep"), 0755)
+		os.WriteFile(filepath.Join(src, "skipped", "deep", "file.txt"), []byte("x"), 0644)
+		os.WriteFile(filepath.Join(src, "hook.sh"), []byte("#!/bin/sh\n"), 0755)
+		os.WriteFile(filepath.Join(src, "kept.txt"), []byte("kept"), 0644)
+
+		dst := filepath.Join(t.TempDir(), "copy")
+		err := copyDir(src, dst, copyLimits{}, filepath.Join(src, "hook.sh"), filepath.Join(src, "skipped"))
+		if err != nil {
+			t.Fatalf("copyDir() unexpected error: %v", err)
+		}
+		for _, name := range []string{"hook.sh", "skipped"} {
+			if _, err := os.Lstat(filepath.Join(dst, name)); !os.IsNotExist(err) {
+				t.Errorf("expected %s to be excluded, got err = %v", name, err)
+			}
+		}
+		if data, _ := os.ReadFile(filepath.Join(dst, "kept.txt")); string(data) != "kept" {
+			t.Errorf("kept.txt = %q, want %q", data, "kept")
+		}
+	})
+
+	t.Run("skips special files", func(t *testing.T) {
+		src := t.TempDir()
+		if err := syscall.Mkfifo(filepath.Join(src, "fifo"), 0644); err != nil {
+			t.Skipf("mkfifo not supported: %v", err)
+		}
+
+		dst := filepath.Join(t.TempDir(), "copy")
+		if err := copyDir(src, dst, copyLimits{}); err != nil {
+			t.Fatalf("copyDir() unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(dst, "fifo")); !os.IsNotExist(err) {
+			t.Errorf("expected fifo to be skipped, got err = %v", err)
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		err := copyDir(filepath.Join(t.TempDir(), "missing"), t.TempDir(), copyLimits{})
+		if err == nil {
+			t.Error("copyDir() expected error for missing source")
+		}
+	})
+
+	t.Run("destination blocked", func(t *testing.T) {
+		src := t.TempDir()
+		blocker := filepath.Join(t.TempDir(), "file")
+		os.WriteFile(blocker, []byte{}, 0644)
+
+		err := copyDir(src, filepath.Join(blocker, "copy"), copyLimits{})
+		if err == nil {
+			t.Error("copyDir() expected error when destination cannot be created")
+		}
+	})
+
+	t.Run("readlink error", func(t *testing.T) {
+		origReadlink := readlinkFn
+		defer func() { readlinkFn = origReadlink }()
+		readlinkFn = func(string) (string, error) {
+			return "", errors.New("readlink failed")
+		}
+
+		src := t.TempDir()
+		os.Symlink("target", filepath.Join(src, "link"))
+
+		err := copyDir(src, filepath.Join(t.TempDir(), "copy"), copyLimits{})
+		if err == nil || err.Error() != "readlink failed" {
+			t.Errorf("copyDir() error = %v, want 'readlink failed'", err)
+		}
+	})
+}
+
+func TestCopyDirLimits(t *testing.T) {
+	// a.txt (3 bytes), one/b.txt (3 bytes) and one/two/c.txt (3 bytes)
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "one", "two"), 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("aaa"), 0644)
+	os.WriteFile(filepath.Join(src, "one", "b.txt"), []byte("bbb"), 0644)
+	os.WriteFile(filepath.Join(src, "one", "two", "c.txt"), []byte("ccc"), 0644)
+
+	tests := []struct {
+		name    string
+		limits  copyLimits
+		wantErr string
+	}{
+		{name: "no limits", limits: copyLimits{}},
+		{name: "tree within the limits", limits: copyLimits{maxDepth: 3, maxSize: 9}},
+		{name: "too deep", limits: copyLimits{maxDepth: 2}, wantErr: filepath.Join("one", "two", "c.txt") + " is more than 2 levels deep (copy_max_depth)"},
+		{name: "too large", limits: copyLimits{maxSize: 8}, wantErr: "more than 8 to copy (copy_max_size)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "copy")
+			err := copyDir(src, dst, tt.limits)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("copyDir() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("copyDir() unexpected error: %v", err)
+			}
+			if data, err := os.ReadFile(filepath.Join(dst, "one", "two", "c.txt")); err != nil || string(data) != "ccc" {
+				t.Errorf("one/two/c.txt = %q, %v; want the whole tree copied", data, err)
+			}
+		})
+	}
+
+	t.Run("files already in the destination do not count", func(t *testing.T) {
+		dst := t.TempDir()
+		os.WriteFile(filepath.Join(dst, "a.txt"), []byte("checked out"), 0644)
+		if err := copyDir(src, dst, copyLimits{maxSize: 6}); err != nil {
+			t.Errorf("copyDir() unexpected error: %v", err)
+		}
+	})
+}
+
+func TestReadCopyManifest(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".env"), []byte("TOKEN=1"), 0644)
+	os.MkdirAll(filepath.Join(root, "config", "local"), 0755)
+	os.WriteFile(filepath.Join(root, "api.local.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(root, "web.local.json"), []byte("{}"), 0644)
+
+	tests := []struct {
+		name       string
+		manifest   string // "" leaves the manifest out
+		want       []string
+		wantStderr string
+		wantErrMsg string
+	}{
+		{name: "no manifest"},
+		{
+			name:     "files, directories and globs",
+			manifest: "# local settings\n.env\n\nconfig/local/\n*.local.json\n",
+			want:     []string{".env", filepath.Join("config", "local"), "api.local.json", "web.local.json"},
+		},
+		{
+			name:       "entry that matches nothing",
+			manifest:   ".env\nsecrets.txt\n",
+			want:       []string{".env"},
+			wantStderr: "warning: " + CopyManifest + ": secrets.txt matches nothing, skipping it\n",
+		},
+		{
+			name:     "paths matched twice are listed once",
+			manifest: "api.local.json\n*.json\n",
+			want:     []string{"api.local.json", "web.local.json"},
+		},
+		{
+			name:       "path outside the repository",
+			manifest:   ".env\n../shared/.env\n",
+			wantErrMsg: CopyManifest + " line 2: ../shared/.env must not contain '..'",
+		},
+		{
+			name:       "bad pattern",
+			manifest:   "config/[local\n",
+			wantErrMsg: CopyManifest + " line 1: config/[local: syntax error in pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(filepath.Join(root, CopyManifest))
+			if tt.manifest != "" {
+				os.WriteFile(filepath.Join(root, CopyManifest), []byte(tt.manifest), 0644)
+			}
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			got, err := readCopyManifest(root)
+			w.Close()
+			os.Stderr = oldStderr
+			stderr, _ := io.ReadAll(r)
+
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("readCopyManifest() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readCopyManifest() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readCopyManifest() = %q, want %q", got, tt.want)
+			}
+			if string(stderr) != tt.wantStderr {
+				t.Errorf("readCopyManifest() stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("unreadable manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		os.MkdirAll(filepath.Join(dir, CopyManifest), 0755)
+		_, err := readCopyManifest(dir)
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read "+CopyManifest) {
+			t.Errorf("readCopyManifest() error = %v, want read error", err)
+		}
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "512", want: 512},
+		{in: "4K", want: 4 << 10},
+		{in: "500m", want: 500 << 20},
+		{in: "2G", want: 2 << 30},
+		{in: "0", wantErr: true},
+		{in: "-1M", wantErr: true},
+		{in: "G", wantErr: true},
+		{in: "1.5G", wantErr: true},
+		{in: "9999999999G", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSize(tt.in)
+			if tt.wantErr {
+				if want := `"` + tt.in + `" is not a size (use e.g. 500M, 2G or a number of bytes)`; err == nil || err.Error() != want {
+					t.Errorf("parseSize(%q) error = %v, want %q", tt.in, err, want)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:       "512",
+		1536:      "1536",
+		4 << 10:   "4K",
+		500 << 20: "500M",
+		2 << 30:   "2G",
+	}
+	for bytes, want := range tests {
+		if got := formatSize(bytes); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Run("missing source", func(t *testing.T) {
+		dir := t.TempDir()
+		err := copyFile(filepath.Join(dir, "missing"), filepath.Join(dir, "dst"), 0644)
+		if err == nil {
+			t.Error("copyFile() expected error for missing source")
+		}
+	})
+
+	t.Run("unwritable destination", func(t *testing.T) {
+		dir := t.TempDir()
+		src := filepath.Join(dir, "src")
+		os.WriteFile(src, []byte("data"), 0644)
+
+		err := copyFile(src, filepath.Join(dir, "missing", "dst"), 0644)
+		if err == nil {
+			t.Error("copyFile() expected error for unwritable destination")
+		}
+	})
+}
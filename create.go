@@ -1,57 +1,909 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 )
 
-func create(name, hookPath string) error {
+// removeAllFn is replaceable for testing
+var removeAllFn = os.RemoveAll
+
+// notifyInterruptsFn relays interrupts to c until the returned function is called; replaceable for testing
+var notifyInterruptsFn = func(c chan<- os.Signal) func() {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	return func() { signal.Stop(c) }
+}
+
+// createOptions controls how create sets up a new worktree
+type createOptions struct {
+	hookPath     string        // hook script to run; empty uses the configured default
+	noVerify     bool          // skip git hooks (such as post-checkout) while adding the worktree
+	envFile      string        // file of KEY=VALUE lines added to the hook's environment
+	quietHook    bool          // capture hook output and only print it if the hook fails
+	noCheckout   bool          // register the worktree without checking out files; skips copying and the hook
+	sparse       []string      // sparse-checkout patterns; when set only matching paths are checked out
+	dryRun       bool          // print what create would do without changing anything
+	copyDirs     []string      // directories copied in addition to the configured copy_dirs
+	base         string        // ref expression the new branch starts from; empty uses HEAD
+	slug         bool          // turn the name into a slug first, e.g. "PROJ-123 Fix login" into proj-123-fix-login
+	push         bool          // push the new branch to origin and set it as the upstream
+	openURL      bool          // print and open the page on origin comparing the branch with its base
+	submodules   bool          // initialize and update submodules, recursively, once files are checked out
+	templateDir  string        // skeleton directory whose contents are copied into the root of the worktree
+	onConflict   string        // what to do when the branch already exists; empty behaves like OnConflictError
+	replaceStale bool          // replace a stale worktree left at the target path and check out its branch again
+	fromPR       string        // pull request number whose head is fetched and checked out on a branch; names the worktree by default
+	attach       string        // existing local branch checked out as is instead of creating one; names the worktree by default
+	branchDir    string        // how an --attach worktree without a name is named after its branch; empty behaves like BranchDirSlug
+	detach       string        // commit-ish, usually a release tag, checked out on a detached HEAD instead of creating a branch
+	ifMissing    bool          // succeed without changes, printing its path, when the worktree already exists
+	fromCurrent  bool          // start the branch at the HEAD of the worktree create runs in rather than the main repository's
+	after        string        // worktree whose branch the new branch starts at and tracks, for stacked branches
+	noClaude     bool          // skip the .claude/ symlink, like WT_NO_CLAUDE_COPY=1, keeping the other copies
+	hookRetries  int           // how often a failing hook is run again before create fails
+	hookTimeout  time.Duration // how long each run of the hook may take before it is killed; 0 means defaultHookTimeout
+	runHook      bool          // run the hook for a worktree on an existing branch, which skips it by default
+	yes          bool          // answer yes to confirmation prompts, such as creating in a nested repository
+}
+
+// hookLimit returns how long each run of the hook may take
+func (o createOptions) hookLimit() time.Duration {
+	if o.hookTimeout > 0 {
+		return o.hookTimeout
+	}
+	return defaultHookTimeout
+}
+
+// skipHook reports whether the hook is skipped because the worktree checks out an existing branch,
+// with --attach, --on-conflict checkout or --checkout-existing-ok
+// Such a branch already has its own state, which a hook that scaffolds a fresh worktree could clobber
+func (o createOptions) skipHook(existing bool) bool {
+	return existing && !o.runHook
+}
+
+// Policies for --on-conflict, applied when the branch create would add already exists
+const (
+	OnConflictError    = "error"    // let git worktree add fail, as without --on-conflict
+	OnConflictSkip     = "skip"     // create nothing and print the worktree the branch is checked out in, if any
+	OnConflictCheckout = "checkout" // add the worktree on the existing branch instead of a new one
+)
+
+// Styles for --reuse-branch-dir, which name an --attach worktree after its branch
+// Either keeps slashes out of the name, so the worktree is a single directory jump can tell apart from its subdirectories
+const (
+	BranchDirSlug = "slug" // the branch as a slug, e.g. feature/Login into feature-login
+	BranchDirLast = "last" // the last segment of the branch, e.g. feature/login into login
+)
+
+// hookOptions controls how runHook executes a hook
+type hookOptions struct {
+	env     []string      // extra KEY=VALUE entries added to the inherited environment
+	quiet   bool          // buffer output instead of streaming it, printing it only on failure
+	shell   []string      // command and arguments the hook path is passed to; empty executes the hook directly
+	retries int           // extra attempts after a failure, with a backoff between them
+	timeout time.Duration // limit on each attempt after which the hook is killed; 0 means no limit
+}
+
+// hookRetryDelay is the delay before the first hook retry; it doubles after each attempt
+const hookRetryDelay = time.Second
+
+// defaultHookTimeout bounds each run of the create hook without --hook-timeout, so a hook stuck on a prompt
+// or a dead network connection cannot block create forever
+const defaultHookTimeout = 30 * time.Minute
+
+// create creates the worktree and prints its path as the only stdout line for the shell wrapper
+func create(name string, opts createOptions) error {
+	path, err := createWorktree(name, opts)
+	if err != nil || path == "" {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+// createWorktree adds the worktree and branch for name, populates it and returns its path
+// A dry run prints the plan instead and returns an empty path
+func createWorktree(name string, opts createOptions) (string, error) {
+	var pr pullRequest
+	if opts.fromPR != "" {
+		var err error
+		if pr, err = ghPullRequest(opts.fromPR); err != nil {
+			return "", err
+		}
+		// Pushing a fork's branch would start a second branch on origin instead of updating the pull request
+		if pr.fork && opts.push {
+			return "", fmt.Errorf("cannot push pull request #%s: it was opened from a fork, so its branch is not on origin", opts.fromPR)
+		}
+	}
+	// Only a name derived from a branch can collide with a directory the user did not ask for
+	var nameBranch string
+	if name == "" {
+		nameBranch = opts.attach
+		if nameBranch == "" {
+			nameBranch = pr.branch
+		}
+	}
+	if nameBranch != "" {
+		var err error
+		if name, err = branchDirName(nameBranch, opts.branchDir); err != nil {
+			return "", err
+		}
+		reportNameChange(os.Stderr, nameBranch, name, "")
+	}
+	// The branch of a pull request from the repository itself is checked out under its own name,
+	// so pushing it updates the pull request
+	prOwnBranch := pr.branch != "" && !pr.fork
+
 	wm, err := NewWorktreeManager()
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	// create acts on the config, so refuse to guess around a broken one
+	if problems := wm.ConfigProblems(); len(problems) > 0 {
+		return "", fmt.Errorf("%w (fix it with 'wt config set' or by editing %s)", problems[0], ConfigFile)
+	}
+
+	// Inside a submodule git resolves to the submodule, which is rarely where the worktree was meant to go
+	if outer := enclosingRepo(wm.Root()); outer != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s is a git repository nested inside %s\n", wm.Root(), outer)
+		if !opts.dryRun && !opts.yes && !confirm(fmt.Sprintf("Create the worktree in the nested repository %s?", wm.Root())) {
+			return "", fmt.Errorf("aborted; run wt create from %s to create the worktree there", outer)
+		}
 	}
 
 	if err := wm.ValidateWorktreesDir(); err != nil {
-		return err
+		return "", err
+	}
+
+	input := name
+	name, branch, err := worktreeNames(input, opts.slug, wm.Config().BranchPrefix)
+	if err != nil {
+		return "", err
+	}
+	// An attached or detached worktree does not get a branch named after it, and neither does a pull request's own branch
+	if opts.attach != "" || opts.detach != "" || prOwnBranch {
+		reportNameChange(os.Stderr, input, name, "")
+	} else {
+		reportNameChange(os.Stderr, input, name, branch)
+	}
+	// Like an attached branch, it keeps its name, so branch_prefix does not apply
+	if prOwnBranch {
+		branch = pr.branch
+	}
+
+	for _, pattern := range opts.sparse {
+		if strings.TrimSpace(pattern) == "" {
+			return "", fmt.Errorf("--sparse pattern must not be empty")
+		}
+	}
+
+	copyDirs, err := mergeCopyDirs(wm.Config().CopyDirs, opts.copyDirs)
+	if err != nil {
+		return "", err
+	}
+	copyPaths, err := readCopyManifest(wm.Root())
+	if err != nil {
+		return "", err
+	}
+
+	// GitHub keeps the head of every pull request under refs/pull on origin, including one opened from a fork.
+	// The repository's own branch is fetched into origin's remote-tracking branch explicitly, which a narrowed
+	// fetch refspec would skip, so the new branch can track it; a fork's head is only a starting commit
+	var prCommit string
+	if pr.branch != "" {
+		fmt.Fprintf(os.Stderr, "Fetching %s from origin for pull request #%s\n", pr.branch, opts.fromPR)
+		refspec := "refs/pull/" + opts.fromPR + "/head"
+		if prOwnBranch {
+			refspec = "+" + refspec + ":refs/remotes/origin/" + pr.branch
+		}
+		if err := gitCmd(wm.Root(), "fetch", "origin", refspec); err != nil {
+			return "", fmt.Errorf("failed to fetch %s from origin: %w", pr.branch, err)
+		}
+		if prOwnBranch {
+			opts.base = "origin/" + pr.branch
+		} else if prCommit, err = gitResolveCommit(wm.Root(), "FETCH_HEAD"); err != nil {
+			return "", fmt.Errorf("failed to fetch %s from origin: %w", pr.branch, err)
+		}
+	}
+
+	// A stacked branch starts at the other worktree's branch and tracks it, so git status counts
+	// the commits on top of it and a plain git rebase follows it
+	if opts.after != "" {
+		if opts.base, err = stackedBase(wm, opts.after); err != nil {
+			return "", err
+		}
+	}
+
+	// Resolve the base in the main repository, like git worktree add itself, so expressions such as
+	// @{upstream} are resolved once and an invalid one fails before anything is created
+	var baseCommit, from string
+	if opts.base != "" {
+		if baseCommit, err = gitResolveCommit(wm.Root(), opts.base); err != nil {
+			return "", fmt.Errorf("invalid --base: %w", err)
+		}
+		from = " from " + opts.base
+	}
+	if prCommit != "" {
+		baseCommit, from = prCommit, " from pull request #"+opts.fromPR
+	}
+
+	// git worktree add runs in the main repository, so without a base the branch starts at its HEAD,
+	// not at the HEAD of the worktree create was run from
+	if opts.fromCurrent {
+		cwd, err := getwdFn()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if baseCommit, err = gitResolveCommit(cwd, "HEAD"); err != nil {
+			return "", fmt.Errorf("--branch-from-current: %w", err)
+		}
+		current, err := gitOutput(cwd, "symbolic-ref", "--quiet", "--short", "HEAD")
+		if err != nil {
+			current = baseCommit[:min(len(baseCommit), 7)] // detached HEAD
+		}
+		from = " from the current HEAD (" + current + ")"
+	}
+
+	// A detached worktree has no branch, so resolving the commit-ish is all that can fail before adding it
+	var detachCommit string
+	if opts.detach != "" {
+		if detachCommit, err = gitResolveCommit(wm.Root(), opts.detach); err != nil {
+			return "", fmt.Errorf("invalid --detach: %w", err)
+		}
+	}
+
+	if opts.templateDir != "" {
+		if info, err := os.Stat(opts.templateDir); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("template directory %s does not exist", opts.templateDir)
+		}
+	}
+
+	hookShell, err := lookPathHookShell(wm.Config().HookShell)
+	if err != nil {
+		return "", err
+	}
+
+	// Load the env file before creating anything so a bad file leaves no worktree behind
+	var hookEnv []string
+	if opts.envFile != "" {
+		hookEnv, err = loadEnvFile(opts.envFile)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// Hook precedence: --hook, then $WT_HOOK, then git config wt.hook, then the repository's hook,
+	// then the user's global template
+	hookPath := opts.hookPath
+	if hookPath == "" {
+		hookPath = os.Getenv(HookEnv)
+	}
+	if hookPath == "" {
+		hookPath = gitConfigValue(wm.Root(), GitConfigHookKey)
+	}
+	if hookPath == "" {
+		hookPath = wm.Config().DefaultHook
+		if !wm.HookExists(hookPath) {
+			template, err := globalHookTemplate()
+			if err != nil {
+				return "", err
+			}
+			if template != "" {
+				hookPath = template
+			}
+		}
 	}
 
 	worktreePath := wm.WorktreePath(name)
 
-	// Create worktree with new branch
-	fmt.Fprintf(os.Stderr, "Creating worktree at %s/%s with branch %s\n", WorktreesDir, name, name)
-	if err := gitCmd(wm.Root(), "worktree", "add", worktreePath, "-b", name); err != nil {
-		return fmt.Errorf("failed to create worktree: %w", err)
+	// A prunable worktree is not usable, so only a live one satisfies --if-missing
+	if opts.ifMissing {
+		infos, err := listWorktreeInfos()
+		if err != nil {
+			return "", err
+		}
+		if info, ok := findWorktree(infos, worktreePath); ok && info.Prunable == "" {
+			fmt.Fprintf(os.Stderr, "Worktree %s/%s already exists; nothing to do\n", wm.WorktreesDirName(), name)
+			if opts.dryRun {
+				return "", nil
+			}
+			return worktreePath, nil
+		}
+	}
+
+	// Two branches can map to the same directory, such as feature/login and bugfix/login with last
+	if _, err := os.Lstat(worktreePath); nameBranch != "" && !opts.replaceStale && err == nil {
+		return "", fmt.Errorf("directory %s/%s for branch %s already exists; pass a name for the worktree", wm.WorktreesDirName(), name, nameBranch)
+	}
+
+	// An attached branch is used by its own name, so branch_prefix does not apply
+	existing := false
+	if opts.attach != "" {
+		if !gitRefExists(wm.Root(), "refs/heads/"+opts.attach) {
+			return "", fmt.Errorf("branch %s does not exist", opts.attach)
+		}
+		branch = opts.attach
+		existing = true
+	}
+
+	// A stale worktree's branch usually outlives it, so the replacement checks that branch out again
+	var stale string
+	if opts.replaceStale {
+		if stale, err = staleWorktreeReason(worktreePath); err != nil {
+			return "", err
+		}
+		existing = existing || stale != "" && opts.detach == "" && gitRefExists(wm.Root(), "refs/heads/"+branch)
 	}
 
-	// Create symlink to .claude/ directory if it exists
-	if wm.ClaudeDirExists() {
+	// The default policy leaves an existing branch to git, so only the others need to look it up
+	if !existing && (opts.onConflict == OnConflictSkip || opts.onConflict == OnConflictCheckout) {
+		existing = gitRefExists(wm.Root(), "refs/heads/"+branch)
+	}
+	if existing && stale == "" && opts.onConflict == OnConflictSkip {
+		path, err := skipExistingBranch(branch)
+		if err != nil || opts.dryRun {
+			return "", err
+		}
+		return path, nil
+	}
+	if existing && opts.base != "" {
+		fmt.Fprintf(os.Stderr, "warning: branch %s already exists, so --base %s is ignored\n", branch, opts.base)
+	}
+
+	var gitConfig []string
+	if opts.noVerify {
+		// git worktree add has no --no-verify, so point git at an empty hooks path instead
+		gitConfig = []string{"-c", "core.hooksPath=" + os.DevNull}
+	}
+	addArgs := append(gitConfig, "worktree", "add")
+	if opts.detach != "" {
+		addArgs = append(addArgs, "--detach")
+	}
+	addArgs = append(addArgs, worktreePath)
+	if !existing && opts.detach == "" {
+		addArgs = append(addArgs, "-b", branch)
+	}
+	if opts.noCheckout || len(opts.sparse) > 0 {
+		// A sparse worktree is checked out only after its patterns are set
+		addArgs = append(addArgs, "--no-checkout")
+	}
+	switch {
+	case opts.detach != "":
+		addArgs = append(addArgs, opts.detach)
+	case existing:
+		addArgs = append(addArgs, branch)
+	case prOwnBranch || opts.after != "":
+		// Only a branch name, not the resolved commit, lets git set it up as the upstream
+		addArgs = append(addArgs, "--track", opts.base)
+	case baseCommit != "":
+		addArgs = append(addArgs, baseCommit)
+	}
+	var pushArgs []string
+	if opts.push {
+		pushArgs = append(gitConfig, "push", "-u", "origin", branch)
+	}
+	if opts.dryRun {
+		existingBranch := ""
+		if existing {
+			existingBranch = branch
+		}
+		printCreatePlan(os.Stderr, wm, worktreePath, stale, existingBranch, hookPath, copyDirs, copyPaths, addArgs, pushArgs, opts)
+		if opts.openURL {
+			if u, err := branchCompareURL(wm.Root(), opts.base, branch); err != nil {
+				fmt.Fprintf(os.Stderr, "Would not open a compare page: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Would open %s\n", u)
+			}
+		}
+		return "", nil
+	}
+
+	// Pruning only drops registrations whose directory is missing, so remove the directory first
+	if stale != "" {
+		fmt.Fprintf(os.Stderr, "Replacing stale worktree at %s/%s (%s)\n", wm.WorktreesDirName(), name, stale)
+		if err := removeAllFn(worktreePath); err != nil {
+			return "", fmt.Errorf("failed to remove stale worktree: %w", err)
+		}
+		if err := gitCmd(wm.Root(), "worktree", "prune"); err != nil {
+			return "", fmt.Errorf("failed to prune worktrees: %w", err)
+		}
+	}
+
+	// Create worktree with new branch, on the existing one with --attach or --on-conflict checkout,
+	// or on no branch with --detach
+	switch {
+	case opts.detach != "":
+		fmt.Fprintf(os.Stderr, "Creating worktree at %s/%s detached at %s (%s)\n", wm.WorktreesDirName(), name, opts.detach, detachCommit[:min(len(detachCommit), 7)])
+	case existing:
+		fmt.Fprintf(os.Stderr, "Creating worktree at %s/%s with existing branch %s\n", wm.WorktreesDirName(), name, branch)
+	default:
+		fmt.Fprintf(os.Stderr, "Creating worktree at %s/%s with branch %s%s\n", wm.WorktreesDirName(), name, branch, from)
+	}
+	if err := gitCmdRetryLocked(wm.Root(), addArgs...); err != nil {
+		// git only reports that the path exists or is registered, so explain a stale worktree instead
+		if reason, _ := staleWorktreeReason(worktreePath); reason != "" {
+			return "", fmt.Errorf("%s/%s is a stale worktree (%s); rerun with --checkout-existing-ok to replace it", wm.WorktreesDirName(), name, reason)
+		}
+		// git names the branch but not the way to use it, so point at the modes that check it out;
+		// a branch checked out in another worktree cannot be checked out again, so git's error stands
+		if !existing && opts.detach == "" && gitRefExists(wm.Root(), "refs/heads/"+branch) && !branchCheckedOut(branch) {
+			return "", fmt.Errorf("branch %s already exists; check it out with 'wt create --attach %s%s' or rerun with --on-conflict checkout", branch, branch, attachNameArg(branch, name))
+		}
+		return "", fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	// From here on an interrupt would leave a half-made worktree behind, so it is rolled back instead
+	newBranch := ""
+	if !existing && opts.detach == "" {
+		newBranch = branch
+	}
+	defer rollbackOnInterrupt(wm.Root(), worktreePath, newBranch)()
+
+	// Like gh pr checkout, a fork's pull request becomes the new branch's upstream, so git pull follows it
+	if pr.fork && newBranch != "" {
+		err := gitCmd(wm.Root(), "config", "branch."+branch+".remote", "origin")
+		if err == nil {
+			err = gitCmd(wm.Root(), "config", "branch."+branch+".merge", "refs/pull/"+opts.fromPR+"/head")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to track pull request #%s: %w", opts.fromPR, err)
+		}
+	}
+
+	if len(opts.sparse) > 0 {
+		fmt.Fprintf(os.Stderr, "Setting up sparse checkout: %s\n", strings.Join(opts.sparse, " "))
+		if err := gitCmd(worktreePath, append([]string{"sparse-checkout", "set"}, opts.sparse...)...); err != nil {
+			return "", fmt.Errorf("failed to set up sparse checkout: %w", err)
+		}
+		if err := gitCmd(worktreePath, append(gitConfig, "checkout")...); err != nil {
+			return "", fmt.Errorf("failed to check out worktree: %w", err)
+		}
+	}
+
+	// Without a .gitmodules file there are no submodules, so there is nothing to run
+	if opts.submodules && fileExists(filepath.Join(worktreePath, ".gitmodules")) {
+		fmt.Fprintln(os.Stderr, "Updating submodules...")
+		if err := gitCmd(worktreePath, append(gitConfig, submoduleUpdateArgs...)...); err != nil {
+			return "", fmt.Errorf("failed to update submodules: %w", err)
+		}
+	}
+
+	// Create symlink to .claude/ directory if it exists, unless disabled via --no-claude or the environment
+	skipClaude := opts.noClaude || os.Getenv(NoClaudeCopyEnv) == "1"
+	if !skipClaude && wm.ClaudeDirExists() {
 		fmt.Fprintf(os.Stderr, "Creating symlink to %s/ directory...\n", ClaudeDir)
 		dstClaudeDir := filepath.Join(worktreePath, ClaudeDir)
 		if err := os.Symlink(wm.ClaudePath(), dstClaudeDir); err != nil {
-			return fmt.Errorf("failed to create %s/ symlink: %w", ClaudeDir, err)
+			return "", fmt.Errorf("failed to create %s/ symlink: %w", ClaudeDir, err)
+		}
+	}
+
+	if opts.noCheckout {
+		// There are no files to copy into or for the hook to work on until the user checks out
+		fmt.Fprintln(os.Stderr, "Skipping copied directories and hook: no files are checked out (--no-checkout)")
+	} else {
+		if opts.skipHook(existing) && wm.HookExists(hookPath) {
+			fmt.Fprintf(os.Stderr, "Skipping hook for the existing branch %s (pass --run-hook to run it)\n", branch)
+			hookPath = ""
+		}
+		if err := populateWorktree(wm, worktreePath, hookPath, opts.templateDir, copyDirs, copyPaths, hookOptions{env: hookEnv, quiet: opts.quietHook, shell: hookShell, retries: opts.hookRetries, timeout: opts.hookLimit()}); err != nil {
+			return "", err
+		}
+	}
+
+	// The worktree is usable without an upstream, so a failed push is reported but keeps it
+	if pushArgs != nil {
+		fmt.Fprintf(os.Stderr, "Pushing branch %s to origin\n", branch)
+		if err := gitCmd(worktreePath, pushArgs...); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to push branch %s: %v; push it later with 'git push -u origin %s'\n", branch, err, branch)
+		}
+	}
+
+	// Like a failed push, a compare page that cannot be opened leaves the worktree usable
+	if opts.openURL {
+		if u, err := branchCompareURL(wm.Root(), opts.base, branch); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Compare %s: %s\n", branch, u)
+			if err := openURLFn(u); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to open the browser: %v\n", err)
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Done! Worktree ready at %s/%s\n", wm.WorktreesDirName(), name)
+	return worktreePath, nil
+}
+
+// rollbackOnInterrupt watches for an interrupt until the returned function is called
+// An interrupt removes the worktree at path, and deletes branch unless it is empty, before
+// exiting with the status a shell reports for Ctrl-C
+func rollbackOnInterrupt(root, path, branch string) func() {
+	interrupts := make(chan os.Signal, 1)
+	stop := notifyInterruptsFn(interrupts)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, ok := <-interrupts; !ok {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Interrupted; removing the unfinished worktree at %s\n", path)
+		if err := gitCmd(root, "worktree", "remove", "--force", path); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to remove worktree: %v\n", err)
+		}
+		if branch != "" {
+			if err := gitCmd(root, "branch", "-D", branch); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: failed to delete branch %s: %v\n", branch, err)
+			}
+		}
+		exitFn(130)
+	}()
+
+	// Once stop returns no more interrupts arrive, so closing the channel is safe; an interrupt
+	// that did arrive is finished with first
+	return func() {
+		stop()
+		close(interrupts)
+		<-done
+	}
+}
+
+// stackedBase returns the branch checked out in the worktree named name, which create --after starts from
+func stackedBase(wm *WorktreeManager, name string) (string, error) {
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return "", err
+	}
+	info, ok := findWorktree(infos, wm.WorktreePath(name))
+	if !ok {
+		return "", fmt.Errorf("invalid --after: worktree %s does not exist", name)
+	}
+	if info.Branch == "" {
+		return "", fmt.Errorf("invalid --after: worktree %s has no branch to stack on (detached HEAD)", name)
+	}
+	return info.Branch, nil
+}
+
+// staleWorktreeReason explains why the worktree at path is stale, or returns "" if it is not
+// A worktree is stale when git considers its registration prunable, or when its directory
+// still has a worktree's .git file but git no longer tracks it
+func staleWorktreeReason(path string) (string, error) {
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return "", err
+	}
+	if info, ok := findWorktree(infos, path); ok {
+		if info.Prunable != "" {
+			return "prunable: " + info.Prunable, nil
+		}
+		return "", nil
+	}
+	if fileExists(filepath.Join(path, ".git")) {
+		return "git no longer tracks it", nil
+	}
+	return "", nil
+}
+
+// skipExistingBranch reports that create skips an existing branch and returns the path of the
+// worktree it is checked out in, or an empty path when no worktree has it
+func skipExistingBranch(branch string) (string, error) {
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return "", err
+	}
+	for _, info := range infos {
+		if info.Branch == branch {
+			fmt.Fprintf(os.Stderr, "Branch %s already exists and is checked out at %s; skipping\n", branch, info.Path)
+			return info.Path, nil
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Branch %s already exists and has no worktree; skipping\n", branch)
+	return "", nil
+}
+
+// submoduleUpdateArgs checks out the submodules of a new worktree, including nested ones
+var submoduleUpdateArgs = []string{"submodule", "update", "--init", "--recursive"}
+
+// fileExists reports whether path exists and is not a directory
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// worktreeNames turns the name given to create into the worktree's directory name and its branch,
+// slugifying it with slug and then adding the branch_prefix to the branch
+func worktreeNames(input string, slug bool, prefix string) (name, branch string, err error) {
+	name = input
+	if slug {
+		if name = slugify(input); name == "" {
+			return "", "", fmt.Errorf("--slug: %q has no letters or digits to name the worktree", input)
+		}
+	}
+	return name, prefixBranch(prefix, name), nil
+}
+
+// reportNameChange tells the user how the name they gave became the worktree's directory and branch
+// when --slug or branch_prefix changed it; branch is empty when the worktree is not named after its branch
+func reportNameChange(w io.Writer, input, name, branch string) {
+	switch {
+	case branch == "" && name != input:
+		fmt.Fprintf(w, "Using directory '%s' for %q\n", name, input)
+	case branch != "" && (name != input || branch != input):
+		fmt.Fprintf(w, "Using branch '%s' in directory '%s' for %q\n", branch, name, input)
+	}
+}
+
+// branchCheckedOut reports whether a worktree has branch checked out, or whether that is unknown
+func branchCheckedOut(branch string) bool {
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return true
+	}
+	for _, info := range infos {
+		if info.Branch == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// branchDirName returns the name of the worktree for the attached branch in the --reuse-branch-dir style
+func branchDirName(branch, style string) (string, error) {
+	if style == BranchDirLast {
+		return branch[strings.LastIndex(branch, "/")+1:], nil
+	}
+	name := slugify(branch)
+	if name == "" {
+		return "", fmt.Errorf("branch %q has no letters or digits to name the worktree (pass a name)", branch)
+	}
+	return name, nil
+}
+
+// attachNameArg returns the name argument, with its leading space, that create --attach branch needs
+// to name the worktree name; it is empty when the worktree is named after the branch anyway
+func attachNameArg(branch, name string) string {
+	if slugify(branch) == name {
+		return ""
+	}
+	return " " + name
+}
+
+// prefixBranch returns the branch name for a worktree named name, starting with prefix
+// A name that already starts with prefix is used as is, so the prefix is never applied twice
+func prefixBranch(prefix, name string) string {
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
+
+// slugify lowercases s and turns each run of characters other than ASCII letters and digits into a
+// single dash, without leading or trailing dashes
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// mergeCopyDirs returns the union of the configured copy_dirs and the --copy directories, in that order
+// Neither source overrides the other, and entries that clean to the same path are kept once
+// The --copy values are validated like copy_dirs
+func mergeCopyDirs(configured, extra []string) ([]string, error) {
+	for _, dir := range extra {
+		if err := validateRelPath(dir); err != nil {
+			return nil, fmt.Errorf("invalid --copy: %w", err)
+		}
+	}
+
+	var merged []string
+	seen := map[string]bool{}
+	for _, dir := range append(append([]string{}, configured...), extra...) {
+		if !seen[filepath.Clean(dir)] {
+			seen[filepath.Clean(dir)] = true
+			merged = append(merged, dir)
+		}
+	}
+	return merged, nil
+}
+
+// printCreatePlan describes the steps create would take for a dry run, mirroring the order they run in
+func printCreatePlan(w io.Writer, wm *WorktreeManager, worktreePath, stale, existingBranch, hookPath string, copyDirs, copyPaths, addArgs, pushArgs []string, opts createOptions) {
+	fmt.Fprintln(w, "Dry run: nothing will be created")
+	if stale != "" {
+		fmt.Fprintf(w, "Would remove the stale worktree at %s (%s) and run: git worktree prune\n", worktreePath, stale)
+	}
+	fmt.Fprintf(w, "Would run: git %s\n", strings.Join(addArgs, " "))
+	if len(opts.sparse) > 0 {
+		fmt.Fprintf(w, "Would run in %s: git sparse-checkout set %s\n", worktreePath, strings.Join(opts.sparse, " "))
+		fmt.Fprintf(w, "Would run in %s: git checkout\n", worktreePath)
+	}
+	if opts.submodules {
+		fmt.Fprintf(w, "Would run in %s if it has submodules: git %s\n", worktreePath, strings.Join(submoduleUpdateArgs, " "))
+	}
+	if !opts.noClaude && os.Getenv(NoClaudeCopyEnv) != "1" && wm.ClaudeDirExists() {
+		fmt.Fprintf(w, "Would symlink %s/ into the worktree\n", ClaudeDir)
+	}
+	if opts.noCheckout {
+		fmt.Fprintln(w, "Would skip copied directories and hook: no files are checked out (--no-checkout)")
+	} else {
+		if opts.templateDir != "" {
+			fmt.Fprintf(w, "Would copy template %s/ into the worktree\n", opts.templateDir)
+		}
+		for _, dir := range copyDirs {
+			if _, err := os.Stat(filepath.Join(wm.Root(), dir)); err == nil {
+				fmt.Fprintf(w, "Would copy %s/ into the worktree\n", dir)
+			}
+		}
+		for _, path := range copyPaths {
+			fmt.Fprintf(w, "Would copy %s into the worktree (%s)\n", path, CopyManifest)
+		}
+		switch {
+		case !wm.HookExists(hookPath):
+			fmt.Fprintf(w, "Would not run a hook: %s does not exist\n", hookPath)
+		case opts.skipHook(existingBranch != ""):
+			fmt.Fprintf(w, "Would skip hook %s for the existing branch %s (pass --run-hook to run it)\n", hookPath, existingBranch)
+		default:
+			fmt.Fprintf(w, "Would run hook: %s\n", hookPath)
+		}
+	}
+	if pushArgs != nil {
+		fmt.Fprintf(w, "Would run in %s: git %s\n", worktreePath, strings.Join(pushArgs, " "))
+	}
+}
+
+// populateWorktree copies templateDir, if set, copyDirs and the copyPaths listed in the CopyManifest
+// into a new worktree and runs its hook
+// An empty hookPath runs no hook
+func populateWorktree(wm *WorktreeManager, worktreePath, hookPath, templateDir string, copyDirs, copyPaths []string, hookOpts hookOptions) error {
+	limits := copyLimits{maxDepth: wm.Config().CopyMaxDepth, maxSize: wm.Config().CopyMaxSize}
+	if templateDir != "" {
+		fmt.Fprintf(os.Stderr, "Copying template %s/...\n", templateDir)
+		if err := copyDir(templateDir, worktreePath, limits, wm.HookPath(hookPath)); err != nil {
+			return fmt.Errorf("failed to copy template %s/: %w", templateDir, err)
+		}
+	}
+
+	// Copy the directories into the new worktree, never the hook itself so it cannot run again from there
+	for _, dir := range copyDirs {
+		srcDir := filepath.Join(wm.Root(), dir)
+		if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Copying %s/ directory...\n", dir)
+		if err := copyDir(srcDir, filepath.Join(worktreePath, dir), limits, wm.HookPath(hookPath)); err != nil {
+			return fmt.Errorf("failed to copy %s/: %w", dir, err)
+		}
+	}
+
+	// A pattern such as .* also matches the repository's .git, and one entry may contain the worktrees
+	// directory; neither is ever copied
+	gitDir := filepath.Join(wm.Root(), ".git")
+	for _, path := range copyPaths {
+		src := filepath.Join(wm.Root(), path)
+		if src == gitDir || src == wm.WorktreesPath() {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Copying %s...\n", path)
+		target := filepath.Join(worktreePath, path)
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err == nil {
+			err = copyDir(src, target, limits, wm.HookPath(hookPath), gitDir, wm.WorktreesPath())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to copy %s: %w", path, err)
 		}
 	}
 
 	// Run hook if it exists
-	if wm.HookExists(hookPath) {
+	if hookPath != "" && wm.HookExists(hookPath) {
 		fmt.Fprintf(os.Stderr, "Running hook: %s\n", hookPath)
-		if err := runHook(wm.HookPath(hookPath), worktreePath); err != nil {
+		if err := runHook(wm.HookPath(hookPath), worktreePath, hookOpts); err != nil {
 			return fmt.Errorf("hook failed: %w", err)
 		}
 	}
-
-	fmt.Fprintf(os.Stderr, "Done! Worktree ready at %s/%s\n", WorktreesDir, name)
-	// Output path to stdout for shell wrapper to cd into
-	fmt.Println(worktreePath)
 	return nil
 }
 
-func runHook(hookPath, worktreePath string) error {
-	cmd := exec.Command(hookPath)
+// globalHookTemplate returns the hook template from the per-user config, or "" if none is set
+// Bad lines in the per-user config are warned about rather than failing create
+func globalHookTemplate() (string, error) {
+	path, err := globalConfigPath()
+	if err != nil {
+		return "", nil // no user config directory, so no template
+	}
+	cfg, problems, err := loadGlobalConfig(path)
+	if err != nil {
+		return "", err
+	}
+	warnConfigProblems(os.Stderr, problems)
+	return cfg.HookTemplate, nil
+}
+
+// lookPathHookShell splits the hook_shell setting into a command and its arguments
+// It fails if the command cannot be found, so a missing shell is reported before anything is created
+func lookPathHookShell(setting string) ([]string, error) {
+	shell := strings.Fields(setting)
+	if len(shell) == 0 {
+		return nil, nil
+	}
+	if _, err := exec.LookPath(shell[0]); err != nil {
+		return nil, fmt.Errorf("invalid hook_shell: %w", err)
+	}
+	return shell, nil
+}
+
+// runHook runs the hook in the worktree, through opts.shell if it is set
+// A failing hook is run again up to opts.retries times, waiting longer before each retry
+func runHook(hookPath, worktreePath string, opts hookOptions) error {
+	delay := hookRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := runHookOnce(hookPath, worktreePath, opts)
+		if err == nil || attempt > opts.retries {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Hook failed (%v), retrying in %s (%d of %d)...\n", err, delay, attempt, opts.retries)
+		sleepFn(delay)
+		delay *= 2
+	}
+}
+
+// runHookOnce runs the hook a single time, killing it once opts.timeout has passed
+func runHookOnce(hookPath, worktreePath string, opts hookOptions) error {
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+		// The hook gets its own process group below, which a Ctrl-C in the terminal no longer reaches
+		ctx, cancel = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, hookPath)
+	if len(opts.shell) > 0 {
+		cmd = exec.CommandContext(ctx, opts.shell[0], append(opts.shell[1:], hookPath)...)
+	}
+	if opts.timeout > 0 {
+		killTreeOnCancel(cmd)
+	}
 	cmd.Dir = worktreePath
-	cmd.Stdout = os.Stderr // Redirect to stderr to keep stdout clean for worktree path
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	cmd.Env = append(os.Environ(), opts.env...)
+
+	var output bytes.Buffer
+	if opts.quiet {
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+	} else {
+		cmd.Stdout = os.Stderr // Redirect to stderr to keep stdout clean for worktree path
+		cmd.Stderr = os.Stderr
+	}
+	err := cmd.Run()
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s and was killed", opts.timeout)
+	}
+	if err != nil && opts.quiet {
+		os.Stderr.Write(output.Bytes())
+	}
+	return err
 }
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// killTreeOnCancel kills cmd when its context ends
+// Without process groups the processes the hook started are left running
+func killTreeOnCancel(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		return cmd.Process.Kill()
+	}
+}
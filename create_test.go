@@ -3,11 +3,17 @@ package main
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestCreate(t *testing.T) {
@@ -24,7 +30,7 @@ func TestCreate(t *testing.T) {
 			return "", errors.New("not in a git repository")
 		}
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 		if err == nil || err.Error() != "not in a git repository" {
 			t.Errorf("create() error = %v, want 'not in a git repository'", err)
 		}
@@ -37,12 +43,32 @@ func TestCreate(t *testing.T) {
 			return tmpDir, nil
 		}
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 		if err == nil || !strings.Contains(err.Error(), WorktreesDir+" directory does not exist") {
 			t.Errorf("create() error = %v, want error about %s not existing", err, WorktreesDir)
 		}
 	})
 
+	t.Run("broken config is refused", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("bogus = 1\n"), 0644)
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with a broken config", args)
+			return nil
+		}
+
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
+		want := ConfigFile + ":1: unknown config key: bogus (fix it with 'wt config set' or by editing " + ConfigFile + ")"
+		if err == nil || err.Error() != want {
+			t.Errorf("create() error = %v, want %q", err, want)
+		}
+	})
+
 	t.Run("git worktree add fails", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
@@ -57,7 +83,7 @@ func TestCreate(t *testing.T) {
 			return nil
 		}
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 		if err == nil || !strings.Contains(err.Error(), "failed to create worktree") {
 			t.Errorf("create() error = %v, want error about failed to create worktree", err)
 		}
@@ -79,7 +105,7 @@ func TestCreate(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -129,7 +155,7 @@ func TestCreate(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err = create("test-branch", DefaultHook)
+		err = create("test-branch", createOptions{hookPath: DefaultHook})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -171,12 +197,128 @@ func TestCreate(t *testing.T) {
 			return nil
 		}
 
-		err = create("test-branch", DefaultHook)
+		err = create("test-branch", createOptions{hookPath: DefaultHook})
 		if err == nil || !strings.Contains(err.Error(), "hook failed") {
 			t.Errorf("create() error = %v, want error about hook failed", err)
 		}
 	})
 
+	t.Run("retries worktree add while a git lock is held", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+
+		origSleep := sleepFn
+		defer func() { sleepFn = origSleep }()
+		sleepFn = func(time.Duration) {}
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		addCalls := 0
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				addCalls++
+				if addCalls <= 2 {
+					return &gitCmdError{err: errors.New("exit status 128"), stderr: "fatal: Unable to create '" + tmpDir + "/.git/index.lock': File exists."}
+				}
+			}
+			return nil
+		}
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if addCalls != 3 {
+			t.Errorf("create() ran worktree add %d times, want 3", addCalls)
+		}
+	})
+
+	t.Run("path is the only stdout line even when the hook prints", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
+		os.MkdirAll(worktreesDir, 0755)
+
+		hookPath := filepath.Join(tmpDir, DefaultHook)
+		if err := os.WriteFile(hookPath, []byte("#!/bin/sh\necho installing deps\n"), 0755); err != nil {
+			t.Fatalf("failed to create hook: %v", err)
+		}
+
+		worktreePath := filepath.Join(worktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 0 && args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+
+		oldStdout, oldStderr := os.Stdout, os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+
+		err := create("test-branch", createOptions{})
+
+		w.Close()
+		os.Stderr.Close()
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if buf.String() != worktreePath+"\n" {
+			t.Errorf("create() stdout = %q, want only %q", buf.String(), worktreePath+"\n")
+		}
+	})
+
+	t.Run("path is not printed when create fails", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
+		os.MkdirAll(worktreesDir, 0755)
+
+		hookPath := filepath.Join(tmpDir, DefaultHook)
+		if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("failed to create hook: %v", err)
+		}
+
+		worktreePath := filepath.Join(worktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 0 && args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := create("test-branch", createOptions{})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err == nil {
+			t.Fatal("create() expected hook error")
+		}
+		if buf.Len() != 0 {
+			t.Errorf("create() stdout = %q, want empty on failure", buf.String())
+		}
+	})
+
 	t.Run("custom hook path", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
@@ -206,7 +348,7 @@ func TestCreate(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err = create("test-branch", "custom-hook.sh")
+		err = create("test-branch", createOptions{hookPath: "custom-hook.sh"})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -250,7 +392,7 @@ func TestCreate(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -286,6 +428,125 @@ func TestCreate(t *testing.T) {
 		}
 	})
 
+	t.Run("WT_NO_CLAUDE_COPY skips .claude symlink", func(t *testing.T) {
+		t.Setenv(NoClaudeCopyEnv, "1")
+
+		tmpDir := t.TempDir()
+		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
+		os.MkdirAll(worktreesDir, 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ClaudeDir), 0755)
+
+		worktreePath := filepath.Join(worktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 0 && args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+
+		// Capture stdout
+		oldStdout := os.Stdout
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		if err != nil {
+			t.Errorf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(worktreePath, ClaudeDir)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s symlink with %s=1, got err = %v", ClaudeDir, NoClaudeCopyEnv, err)
+		}
+	})
+
+	t.Run("--no-claude skips .claude symlink but keeps copy_dirs", func(t *testing.T) {
+		t.Setenv(NoClaudeCopyEnv, "")
+
+		tmpDir := t.TempDir()
+		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
+		os.MkdirAll(worktreesDir, 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ClaudeDir), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ".vscode", "settings.json"), []byte("{}"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("copy_dirs = .vscode\n"), 0644)
+
+		worktreePath := filepath.Join(worktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 0 && args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+
+		oldStdout := os.Stdout
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := create("test-branch", createOptions{hookPath: DefaultHook, noClaude: true})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		if err != nil {
+			t.Errorf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(worktreePath, ClaudeDir)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s symlink with --no-claude, got err = %v", ClaudeDir, err)
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, ".vscode", "settings.json")); err != nil {
+			t.Errorf("expected copy_dirs to still be copied with --no-claude: %v", err)
+		}
+	})
+
+	t.Run("WT_NO_CLAUDE_COPY other values keep .claude symlink", func(t *testing.T) {
+		t.Setenv(NoClaudeCopyEnv, "0")
+
+		tmpDir := t.TempDir()
+		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
+		os.MkdirAll(worktreesDir, 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ClaudeDir), 0755)
+
+		worktreePath := filepath.Join(worktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 0 && args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+
+		// Capture stdout
+		oldStdout := os.Stdout
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		if err != nil {
+			t.Errorf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(worktreePath, ClaudeDir)); err != nil {
+			t.Errorf("expected %s symlink, got err = %v", ClaudeDir, err)
+		}
+	})
+
 	t.Run("symlink creation fails", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
@@ -309,13 +570,72 @@ func TestCreate(t *testing.T) {
 			return nil
 		}
 
-		err := create("test-branch", DefaultHook)
+		err := create("test-branch", createOptions{hookPath: DefaultHook})
 		if err == nil || !strings.Contains(err.Error(), "failed to create "+ClaudeDir+"/ symlink") {
 			t.Errorf("create() error = %v, want error about failed to create symlink", err)
 		}
 	})
 }
 
+func TestCreateEnvFile(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	setup := func(t *testing.T) (tmpDir, worktreePath string, addCalled *bool) {
+		tmpDir = t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "test-branch")
+		addCalled = new(bool)
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				*addCalled = true
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+		return tmpDir, worktreePath, addCalled
+	}
+
+	t.Run("hook receives variables from the env file", func(t *testing.T) {
+		tmpDir, worktreePath, _ := setup(t)
+		hook := "#!/bin/sh\nprintf '%s' \"$APP_PORT\" > port.txt\n"
+		os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte(hook), 0755)
+		envFile := filepath.Join(tmpDir, "hook.env")
+		os.WriteFile(envFile, []byte("# hook settings\nAPP_PORT=4000\n"), 0644)
+
+		if err := create("test-branch", createOptions{envFile: envFile}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		got, _ := os.ReadFile(filepath.Join(worktreePath, "port.txt"))
+		if string(got) != "4000" {
+			t.Errorf("hook saw APP_PORT = %q, want %q", got, "4000")
+		}
+	})
+
+	t.Run("malformed env file fails before creating the worktree", func(t *testing.T) {
+		tmpDir, _, addCalled := setup(t)
+		envFile := filepath.Join(tmpDir, "hook.env")
+		os.WriteFile(envFile, []byte("APP_PORT 4000\n"), 0644)
+
+		err := create("test-branch", createOptions{envFile: envFile})
+		want := envFile + `:1: expected KEY=VALUE, got "APP_PORT 4000"`
+		if err == nil || err.Error() != want {
+			t.Errorf("create() error = %v, want %q", err, want)
+		}
+		if *addCalled {
+			t.Error("create() ran git worktree add despite a bad env file")
+		}
+	})
+}
+
 func TestRunHook(t *testing.T) {
 	t.Run("successful hook", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -325,7 +645,7 @@ func TestRunHook(t *testing.T) {
 			t.Fatalf("failed to create hook: %v", err)
 		}
 
-		err = runHook(hookPath, tmpDir)
+		err = runHook(hookPath, tmpDir, hookOptions{})
 		if err != nil {
 			t.Errorf("runHook() unexpected error: %v", err)
 		}
@@ -339,17 +659,2927 @@ func TestRunHook(t *testing.T) {
 			t.Fatalf("failed to create hook: %v", err)
 		}
 
-		err = runHook(hookPath, tmpDir)
+		err = runHook(hookPath, tmpDir, hookOptions{})
 		if err == nil {
 			t.Error("runHook() expected error for failing hook")
 		}
 	})
 
+	t.Run("hook shell runs a hook that is not executable", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		// No shebang and no executable bit, and pipefail is a bash option
+		os.WriteFile(hookPath, []byte("set -o pipefail\necho \"$0 $-\" > ran\n"), 0644)
+
+		if err := runHook(hookPath, tmpDir, hookOptions{}); err == nil {
+			t.Error("runHook() expected error executing a non-executable hook directly")
+		}
+		if err := runHook(hookPath, tmpDir, hookOptions{shell: []string{"bash", "-e"}}); err != nil {
+			t.Fatalf("runHook() unexpected error: %v", err)
+		}
+		got, _ := os.ReadFile(filepath.Join(tmpDir, "ran"))
+		if !strings.HasPrefix(string(got), hookPath+" ") || !strings.Contains(string(got), "e") {
+			t.Errorf("hook output = %q, want it run by bash -e", got)
+		}
+	})
+
 	t.Run("non-existent hook", func(t *testing.T) {
 		tmpDir := t.TempDir()
-		err := runHook(filepath.Join(tmpDir, "nonexistent.sh"), tmpDir)
+		err := runHook(filepath.Join(tmpDir, "nonexistent.sh"), tmpDir, hookOptions{})
 		if err == nil {
 			t.Error("runHook() expected error for non-existent hook")
 		}
 	})
+
+	// runQuiet runs a hook script in quiet mode and returns what reached stderr and the error
+	runQuiet := func(t *testing.T, script string) (string, error) {
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		if err := os.WriteFile(hookPath, []byte(script), 0755); err != nil {
+			t.Fatalf("failed to create hook: %v", err)
+		}
+
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+
+		err := runHook(hookPath, tmpDir, hookOptions{quiet: true})
+
+		w.Close()
+		os.Stderr = oldStderr
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String(), err
+	}
+
+	t.Run("quiet hook that passes prints nothing", func(t *testing.T) {
+		stderr, err := runQuiet(t, "#!/bin/sh\necho installing\necho warning >&2\n")
+		if err != nil {
+			t.Errorf("runHook() unexpected error: %v", err)
+		}
+		if stderr != "" {
+			t.Errorf("runHook() stderr = %q, want no output", stderr)
+		}
+	})
+
+	t.Run("quiet hook that fails prints captured output", func(t *testing.T) {
+		stderr, err := runQuiet(t, "#!/bin/sh\necho installing\necho npm failed >&2\nexit 3\n")
+		if err == nil {
+			t.Error("runHook() expected error for failing hook")
+		}
+		if stderr != "installing\nnpm failed\n" {
+			t.Errorf("runHook() stderr = %q, want captured hook output", stderr)
+		}
+	})
+
+	t.Run("failing hook is retried with backoff until it passes", func(t *testing.T) {
+		origSleep := sleepFn
+		defer func() { sleepFn = origSleep }()
+		var delays []time.Duration
+		sleepFn = func(d time.Duration) {
+			delays = append(delays, d)
+		}
+
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		// Fails on the first two runs, counting them in a file, and passes on the third
+		script := "#!/bin/sh\necho run >> runs\n[ $(wc -l < runs) -ge 3 ]\n"
+		os.WriteFile(hookPath, []byte(script), 0755)
+
+		if err := runHook(hookPath, tmpDir, hookOptions{quiet: true, retries: 3}); err != nil {
+			t.Fatalf("runHook() unexpected error: %v", err)
+		}
+		if want := []time.Duration{hookRetryDelay, 2 * hookRetryDelay}; !reflect.DeepEqual(delays, want) {
+			t.Errorf("retry delays = %v, want %v", delays, want)
+		}
+	})
+
+	t.Run("hook still failing after every retry", func(t *testing.T) {
+		origSleep := sleepFn
+		defer func() { sleepFn = origSleep }()
+		sleepFn = func(time.Duration) {}
+
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		os.WriteFile(hookPath, []byte("#!/bin/sh\necho run >> runs\nexit 1\n"), 0755)
+
+		if err := runHook(hookPath, tmpDir, hookOptions{quiet: true, retries: 2}); err == nil {
+			t.Error("runHook() expected error for a hook that keeps failing")
+		}
+		if runs, _ := os.ReadFile(filepath.Join(tmpDir, "runs")); string(runs) != "run\nrun\nrun\n" {
+			t.Errorf("hook runs = %q, want 3", runs)
+		}
+	})
+
+	t.Run("hook that exceeds the timeout is killed", func(t *testing.T) {
+		for _, quiet := range []bool{false, true} {
+			tmpDir := t.TempDir()
+			hookPath := filepath.Join(tmpDir, "hook.sh")
+			// The sleep runs in a child process that must be killed with the hook
+			os.WriteFile(hookPath, []byte("#!/bin/sh\nsleep 30\ntouch finished\n"), 0755)
+
+			start := time.Now()
+			err := runHook(hookPath, tmpDir, hookOptions{quiet: quiet, timeout: 100 * time.Millisecond})
+			if err == nil || err.Error() != "timed out after 100ms and was killed" {
+				t.Errorf("runHook(quiet=%v) error = %v, want timeout error", quiet, err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("runHook(quiet=%v) took %s, want the hook killed at the timeout", quiet, elapsed)
+			}
+			if fileExists(filepath.Join(tmpDir, "finished")) {
+				t.Errorf("runHook(quiet=%v) let the hook finish", quiet)
+			}
+		}
+	})
+
+	t.Run("hook within the timeout", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		os.WriteFile(hookPath, []byte("#!/bin/sh\ntouch finished\n"), 0755)
+
+		if err := runHook(hookPath, tmpDir, hookOptions{timeout: time.Minute}); err != nil {
+			t.Errorf("runHook() unexpected error: %v", err)
+		}
+		if !fileExists(filepath.Join(tmpDir, "finished")) {
+			t.Error("runHook() did not run the hook to completion")
+		}
+	})
+
+	t.Run("interrupt kills the hook", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		hookPath := filepath.Join(tmpDir, "hook.sh")
+		os.WriteFile(hookPath, []byte("#!/bin/sh\ntouch started\nsleep 30\ntouch finished\n"), 0755)
+
+		go func() {
+			for !fileExists(filepath.Join(tmpDir, "started")) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			syscall.Kill(os.Getpid(), syscall.SIGINT)
+		}()
+		start := time.Now()
+		if err := runHook(hookPath, tmpDir, hookOptions{timeout: time.Minute}); err == nil {
+			t.Error("runHook() expected error for an interrupted hook")
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("runHook() took %s, want the hook killed on interrupt", elapsed)
+		}
+		if fileExists(filepath.Join(tmpDir, "finished")) {
+			t.Error("runHook() let the hook finish")
+		}
+	})
+}
+
+func TestCreateOptionsHookLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		opts createOptions
+		want time.Duration
+	}{
+		{name: "default", want: defaultHookTimeout},
+		{name: "--hook-timeout", opts: createOptions{hookTimeout: 5 * time.Second}, want: 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.hookLimit(); got != tt.want {
+				t.Errorf("hookLimit() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateWithConfig(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		os.Stdout = origStdout
+	}()
+
+	setup := func(t *testing.T, config string) (string, string) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte(config), 0644)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if len(args) > 1 && args[0] == "worktree" {
+				os.MkdirAll(args[2], 0755)
+			}
+			return nil
+		}
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+		t.Cleanup(func() {
+			w.Close()
+			os.Stdout = origStdout
+		})
+		return tmpDir, filepath.Join(tmpDir, "trees", "test-branch")
+	}
+
+	t.Run("uses configured worktrees dir", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(worktreePath); err != nil {
+			t.Errorf("expected worktree at %s: %v", worktreePath, err)
+		}
+	})
+
+	t.Run("runs configured default hook", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\ndefault_hook = setup.sh\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "setup.sh"), []byte("#!/bin/sh\ntouch hook-ran\n"), 0755)
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, "hook-ran")); err != nil {
+			t.Errorf("expected configured hook to run: %v", err)
+		}
+	})
+
+	t.Run("copies configured directories", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\ncopy_dirs = .vscode, missing\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ".vscode", "settings.json"), []byte("{}"), 0644)
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(worktreePath, ".vscode", "settings.json"))
+		if err != nil || string(data) != "{}" {
+			t.Errorf("copied settings.json = %q, %v; want %q", data, err, "{}")
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, "missing")); !os.IsNotExist(err) {
+			t.Errorf("missing copy dir should be skipped, got err = %v", err)
+		}
+	})
+
+	t.Run("copies the paths listed in the copy manifest", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ".git"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ".env"), []byte("TOKEN=1"), 0644)
+		os.MkdirAll(filepath.Join(tmpDir, "config", "local"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "config", "local", "db.yml"), []byte("db: dev"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "api.local.json"), []byte("{}"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, CopyManifest), []byte(".env\nconfig/local\n*.local.json\n.*\ntrees\n"), 0644)
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		for path, want := range map[string]string{".env": "TOKEN=1", "config/local/db.yml": "db: dev", "api.local.json": "{}"} {
+			if data, err := os.ReadFile(filepath.Join(worktreePath, path)); err != nil || string(data) != want {
+				t.Errorf("copied %s = %q, %v; want %q", path, data, err, want)
+			}
+		}
+		// .* also matches the repository's .git, which is never copied, and neither is the worktrees directory
+		for _, dir := range []string{".git", "trees"} {
+			if _, err := os.Stat(filepath.Join(worktreePath, dir)); !os.IsNotExist(err) {
+				t.Errorf("%s should not be copied, got err = %v", dir, err)
+			}
+		}
+	})
+
+	t.Run("invalid copy manifest", func(t *testing.T) {
+		tmpDir, _ := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, CopyManifest), []byte("/etc/passwd\n"), 0644)
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with an invalid %s", args, CopyManifest)
+			return nil
+		}
+
+		err := create("test-branch", createOptions{})
+		if want := CopyManifest + " line 1: /etc/passwd must be relative to the repository root"; err == nil || err.Error() != want {
+			t.Errorf("create() error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("copy manifest failure", func(t *testing.T) {
+		tmpDir, _ := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, "config", "local"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, CopyManifest), []byte("config/local\n"), 0644)
+		gitCmdFn = func(dir string, args ...string) error {
+			// Block the copy destination's parent with a file
+			os.MkdirAll(args[2], 0755)
+			os.WriteFile(filepath.Join(args[2], "config"), []byte{}, 0644)
+			return nil
+		}
+
+		err := create("test-branch", createOptions{})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to copy "+filepath.Join("config", "local")+": ") {
+			t.Errorf("create() error = %v, want copy failure", err)
+		}
+	})
+
+	t.Run("stops copying past copy_max_depth", func(t *testing.T) {
+		tmpDir, _ := setup(t, "worktrees_dir = trees\ncopy_dirs = node_modules\ncopy_max_depth = 2\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, "node_modules", "a", "b", "c"), 0755)
+
+		err := create("test-branch", createOptions{})
+		want := "failed to copy node_modules/: " + filepath.Join("a", "b", "c") + " is more than 2 levels deep (copy_max_depth)"
+		if err == nil || err.Error() != want {
+			t.Errorf("create() error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("does not copy the hook file", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\ndefault_hook = tools/setup.sh\ncopy_dirs = tools\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, "tools"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "tools", "setup.sh"), []byte("#!/bin/sh\ntouch hook-ran\n"), 0755)
+		os.WriteFile(filepath.Join(tmpDir, "tools", "lint.sh"), []byte("#!/bin/sh\n"), 0755)
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, "tools", "lint.sh")); err != nil {
+			t.Errorf("expected tools/lint.sh to be copied: %v", err)
+		}
+		if _, err := os.Lstat(filepath.Join(worktreePath, "tools", "setup.sh")); !os.IsNotExist(err) {
+			t.Errorf("hook file should not be copied, got err = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, "hook-ran")); err != nil {
+			t.Errorf("expected hook to run from the repository root: %v", err)
+		}
+	})
+
+	t.Run("--copy adds to configured directories", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\ncopy_dirs = .vscode\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		for _, dir := range []string{".vscode", ".idea", "fixtures"} {
+			os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+			os.WriteFile(filepath.Join(tmpDir, dir, "file"), []byte(dir), 0644)
+		}
+
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+		err := create("test-branch", createOptions{copyDirs: []string{".idea", "./.vscode"}})
+		w.Close()
+		os.Stderr = oldStderr
+		var stderr bytes.Buffer
+		io.Copy(&stderr, r)
+
+		if err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		for _, dir := range []string{".vscode", ".idea"} {
+			if data, _ := os.ReadFile(filepath.Join(worktreePath, dir, "file")); string(data) != dir {
+				t.Errorf("%s/file = %q, want it copied", dir, data)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, "fixtures")); !os.IsNotExist(err) {
+			t.Errorf("fixtures/ was not requested and should not be copied, got err = %v", err)
+		}
+		if n := strings.Count(stderr.String(), "Copying .vscode/"); n != 1 {
+			t.Errorf(".vscode/ copied %d times, want once; stderr = %q", n, stderr.String())
+		}
+	})
+
+	t.Run("--copy without configured directories", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ".idea"), 0755)
+
+		if err := create("test-branch", createOptions{copyDirs: []string{".idea"}}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(worktreePath, ".idea")); err != nil {
+			t.Errorf("expected .idea/ to be copied: %v", err)
+		}
+	})
+
+	t.Run("invalid --copy", func(t *testing.T) {
+		tmpDir, _ := setup(t, "worktrees_dir = trees\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with an invalid --copy", args)
+			return nil
+		}
+
+		err := create("test-branch", createOptions{copyDirs: []string{"../secrets"}})
+		if err == nil || err.Error() != "invalid --copy: ../secrets must not contain '..'" {
+			t.Errorf("create() error = %v, want invalid --copy error", err)
+		}
+	})
+
+	t.Run("copy failure", func(t *testing.T) {
+		tmpDir, _ := setup(t, "worktrees_dir = trees\ncopy_dirs = .vscode\n")
+		os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+		gitCmdFn = func(dir string, args ...string) error {
+			// Block the copy destination with a file
+			os.MkdirAll(args[2], 0755)
+			os.WriteFile(filepath.Join(args[2], ".vscode"), []byte{}, 0644)
+			return nil
+		}
+
+		err := create("test-branch", createOptions{})
+		if err == nil || !strings.Contains(err.Error(), "failed to copy .vscode/") {
+			t.Errorf("create() error = %v, want copy failure", err)
+		}
+	})
+}
+
+func TestCreateNoVerify(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		os.Stdout = origStdout
+	}()
+
+	tests := []struct {
+		name     string
+		noVerify bool
+		want     string
+	}{
+		{"default runs git hooks", false, "worktree add"},
+		{"--no-verify disables git hooks", true, "-c core.hooksPath=" + os.DevNull + " worktree add"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			var gitArgs string
+			gitCmdFn = func(dir string, args ...string) error {
+				gitArgs = strings.Join(args, " ")
+				return nil
+			}
+
+			_, w, _ := os.Pipe()
+			os.Stdout = w
+			err := create("test-branch", createOptions{noVerify: tt.noVerify})
+			w.Close()
+			os.Stdout = origStdout
+
+			if err != nil {
+				t.Fatalf("create() unexpected error: %v", err)
+			}
+			if !strings.HasPrefix(gitArgs, tt.want) {
+				t.Errorf("git args = %q, want prefix %q", gitArgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateNoCheckout(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		os.Stdout = origStdout
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "trees"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".vscode", "settings.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("worktrees_dir = trees\ncopy_dirs = .vscode\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("#!/bin/sh\ntouch hook-ran\n"), 0755)
+	worktreePath := filepath.Join(tmpDir, "trees", "test-branch")
+
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	var gitArgs []string
+	gitCmdFn = func(dir string, args ...string) error {
+		gitArgs = args
+		os.MkdirAll(worktreePath, 0755)
+		return nil
+	}
+
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	err := create("test-branch", createOptions{noCheckout: true})
+	w.Close()
+	os.Stdout = origStdout
+
+	if err != nil {
+		t.Fatalf("create() unexpected error: %v", err)
+	}
+	want := "worktree add " + worktreePath + " -b test-branch --no-checkout"
+	if strings.Join(gitArgs, " ") != want {
+		t.Errorf("git args = %q, want %q", strings.Join(gitArgs, " "), want)
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, ".vscode")); !os.IsNotExist(err) {
+		t.Errorf("copy_dirs should be skipped with --no-checkout, got err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, "hook-ran")); !os.IsNotExist(err) {
+		t.Errorf("hook should be skipped with --no-checkout, got err = %v", err)
+	}
+}
+
+func TestCreateSparse(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		os.Stdout = origStdout
+	}()
+
+	// setup records each git call as "dir: args" and fails the call whose args start with failOn
+	setup := func(t *testing.T, failOn string) (worktreePath string, calls *[]string) {
+		tmpDir := t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "test-branch")
+		calls = &[]string{}
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			call := strings.Join(args, " ")
+			*calls = append(*calls, dir+": "+call)
+			if failOn != "" && strings.HasPrefix(call, failOn) {
+				return errors.New("exit status 1")
+			}
+			return nil
+		}
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+		t.Cleanup(func() {
+			w.Close()
+			os.Stdout = origStdout
+		})
+		return worktreePath, calls
+	}
+
+	t.Run("sets patterns before checking out", func(t *testing.T) {
+		worktreePath, calls := setup(t, "")
+
+		if err := create("test-branch", createOptions{sparse: []string{"web", "docs"}}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		root := filepath.Dir(filepath.Dir(worktreePath))
+		want := []string{
+			root + ": worktree add " + worktreePath + " -b test-branch --no-checkout",
+			worktreePath + ": sparse-checkout set web docs",
+			worktreePath + ": checkout",
+		}
+		if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+			t.Errorf("git calls = %q, want %q", *calls, want)
+		}
+	})
+
+	t.Run("without --sparse uses a normal checkout", func(t *testing.T) {
+		worktreePath, calls := setup(t, "")
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		root := filepath.Dir(filepath.Dir(worktreePath))
+		want := []string{root + ": worktree add " + worktreePath + " -b test-branch"}
+		if strings.Join(*calls, "\n") != strings.Join(want, "\n") {
+			t.Errorf("git calls = %q, want %q", *calls, want)
+		}
+	})
+
+	t.Run("--no-verify also applies to the checkout", func(t *testing.T) {
+		worktreePath, calls := setup(t, "")
+
+		if err := create("test-branch", createOptions{sparse: []string{"web"}, noVerify: true}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		want := worktreePath + ": -c core.hooksPath=" + os.DevNull + " checkout"
+		if last := (*calls)[len(*calls)-1]; last != want {
+			t.Errorf("last git call = %q, want %q", last, want)
+		}
+	})
+
+	t.Run("empty pattern is rejected before creating anything", func(t *testing.T) {
+		_, calls := setup(t, "")
+
+		err := create("test-branch", createOptions{sparse: []string{"web", " "}})
+		if err == nil || err.Error() != "--sparse pattern must not be empty" {
+			t.Errorf("create() error = %v, want empty pattern error", err)
+		}
+		if len(*calls) != 0 {
+			t.Errorf("git calls = %q, want none", *calls)
+		}
+	})
+
+	t.Run("sparse-checkout set fails", func(t *testing.T) {
+		setup(t, "sparse-checkout")
+
+		err := create("test-branch", createOptions{sparse: []string{"web"}})
+		if err == nil || err.Error() != "failed to set up sparse checkout: exit status 1" {
+			t.Errorf("create() error = %v, want sparse checkout error", err)
+		}
+	})
+
+	t.Run("checkout fails", func(t *testing.T) {
+		setup(t, "checkout")
+
+		err := create("test-branch", createOptions{sparse: []string{"web"}})
+		if err == nil || err.Error() != "failed to check out worktree: exit status 1" {
+			t.Errorf("create() error = %v, want checkout error", err)
+		}
+	})
+}
+
+func TestCreateBase(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// Ref expressions rev-parse resolves, with the ^{commit} peel create appends
+	commits := map[string]string{
+		"@{upstream}^{commit}": "1111111111111111111111111111111111111111",
+		"HEAD~3^{commit}":      "3333333333333333333333333333333333333333",
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if dir != tmpDir || !reflect.DeepEqual(args[:3], []string{"rev-parse", "--verify", "--quiet"}) {
+			t.Errorf("unexpected git %v in %s", args, dir)
+		}
+		if sha, ok := commits[args[3]]; ok {
+			return sha, nil
+		}
+		return "", errors.New("exit status 1")
+	}
+
+	for _, base := range []string{"@{upstream}", "HEAD~3"} {
+		t.Run(base, func(t *testing.T) {
+			var addArgs []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" {
+					addArgs = args
+				}
+				return nil
+			}
+
+			if _, err := createWorktree("feat-"+base[:4], createOptions{base: base, noCheckout: true}); err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			want := []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "feat-"+base[:4]), "-b", "feat-" + base[:4], "--no-checkout", commits[base+"^{commit}"]}
+			if !reflect.DeepEqual(addArgs, want) {
+				t.Errorf("git worktree add args = %q, want %q", addArgs, want)
+			}
+		})
+	}
+
+	t.Run("invalid expression", func(t *testing.T) {
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with an invalid base", args)
+			return nil
+		}
+
+		_, err := createWorktree("feat", createOptions{base: "origin/nope~1"})
+		if err == nil || err.Error() != "invalid --base: origin/nope~1 does not resolve to a commit" {
+			t.Errorf("createWorktree() error = %v, want invalid --base error", err)
+		}
+	})
+}
+
+func TestCreateBranchFromCurrent(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origGetwd := getwdFn
+	origStderr := os.Stderr
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		getwdFn = origGetwd
+		os.Stderr = origStderr
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	current := filepath.Join(tmpDir, WorktreesDir, "other")
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return current, nil
+	}
+
+	tests := []struct {
+		name     string
+		branch   string // branch checked out in the current worktree; empty for a detached HEAD
+		wantFrom string
+	}{
+		{name: "on a branch", branch: "other", wantFrom: "from the current HEAD (other)"},
+		{name: "detached HEAD", wantFrom: "from the current HEAD (4444444)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Only the current worktree is asked about, never the main repository
+			gitOutputFn = func(dir string, args ...string) (string, error) {
+				if dir != current {
+					t.Errorf("git %v ran in %s, want %s", args, dir, current)
+				}
+				switch {
+				case args[0] == "rev-parse" && args[3] == "HEAD^{commit}":
+					return "4444444444444444444444444444444444444444", nil
+				case args[0] == "symbolic-ref" && tt.branch != "":
+					return tt.branch, nil
+				}
+				return "", errors.New("exit status 1")
+			}
+			var addArgs []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" {
+					addArgs = args
+				}
+				return nil
+			}
+
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			_, err := createWorktree("feat", createOptions{fromCurrent: true, noCheckout: true})
+			w.Close()
+			os.Stderr = origStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			want := []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "feat"), "-b", "feat", "--no-checkout", "4444444444444444444444444444444444444444"}
+			if !reflect.DeepEqual(addArgs, want) {
+				t.Errorf("git worktree add args = %q, want %q", addArgs, want)
+			}
+			if !strings.Contains(stderr.String(), "with branch feat "+tt.wantFrom+"\n") {
+				t.Errorf("stderr = %q, want it to mention %q", stderr.String(), tt.wantFrom)
+			}
+		})
+	}
+
+	t.Run("unresolvable HEAD", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 128")
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run without a base", args)
+			return nil
+		}
+
+		_, err := createWorktree("feat", createOptions{fromCurrent: true})
+		if err == nil || err.Error() != "--branch-from-current: HEAD does not resolve to a commit" {
+			t.Errorf("createWorktree() error = %v, want unresolvable HEAD error", err)
+		}
+	})
+
+	t.Run("current directory unknown", func(t *testing.T) {
+		getwdFn = func() (string, error) {
+			return "", errors.New("getwd failed")
+		}
+
+		_, err := createWorktree("feat", createOptions{fromCurrent: true})
+		if err == nil || err.Error() != "failed to get current directory: getwd failed" {
+			t.Errorf("createWorktree() error = %v, want getwd error", err)
+		}
+	})
+}
+
+func TestCreateOnConflict(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origListInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origListInfos
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// Only the feat branch exists, and the main repository has it checked out
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		switch args[3] {
+		case "refs/heads/feat^{commit}":
+			return "1111111111111111111111111111111111111111", nil
+		case "HEAD~1^{commit}":
+			return "2222222222222222222222222222222222222222", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Name: "main", Path: tmpDir, Branch: "feat"}}, nil
+	}
+
+	tests := []struct {
+		name     string
+		branch   string
+		opts     createOptions
+		wantPath string
+		wantAdd  []string // nil when git worktree add must not run
+	}{
+		{
+			name:    "default leaves the existing branch to git",
+			branch:  "feat",
+			wantAdd: []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "feat"), "-b", "feat", "--no-checkout"},
+		},
+		{
+			name:    "error leaves the existing branch to git",
+			branch:  "feat",
+			opts:    createOptions{onConflict: OnConflictError},
+			wantAdd: []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "feat"), "-b", "feat", "--no-checkout"},
+		},
+		{
+			name:     "skip prints the worktree the branch is checked out in",
+			branch:   "feat",
+			opts:     createOptions{onConflict: OnConflictSkip},
+			wantPath: tmpDir,
+		},
+		{
+			name:   "skip in a dry run prints no path",
+			branch: "feat",
+			opts:   createOptions{onConflict: OnConflictSkip, dryRun: true},
+		},
+		{
+			name:    "skip creates a new branch as usual",
+			branch:  "new",
+			opts:    createOptions{onConflict: OnConflictSkip},
+			wantAdd: []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "new"), "-b", "new", "--no-checkout"},
+		},
+		{
+			name:    "checkout attaches the existing branch and ignores --base",
+			branch:  "feat",
+			opts:    createOptions{onConflict: OnConflictCheckout, base: "HEAD~1"},
+			wantAdd: []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "feat"), "--no-checkout", "feat"},
+		},
+		{
+			name:    "checkout creates a new branch as usual",
+			branch:  "new",
+			opts:    createOptions{onConflict: OnConflictCheckout, base: "HEAD~1"},
+			wantAdd: []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, "new"), "-b", "new", "--no-checkout", "2222222222222222222222222222222222222222"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var addArgs []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" {
+					addArgs = args
+				}
+				return nil
+			}
+
+			tt.opts.noCheckout = true
+			path, err := createWorktree(tt.branch, tt.opts)
+			if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			if tt.wantAdd != nil {
+				tt.wantPath = filepath.Join(tmpDir, WorktreesDir, tt.branch)
+			}
+			if path != tt.wantPath {
+				t.Errorf("createWorktree() path = %q, want %q", path, tt.wantPath)
+			}
+			if !reflect.DeepEqual(addArgs, tt.wantAdd) {
+				t.Errorf("git worktree add args = %q, want %q", addArgs, tt.wantAdd)
+			}
+		})
+	}
+
+	t.Run("skip without a worktree creates nothing", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return []Worktree{{Name: "main", Path: tmpDir, Branch: "main"}}, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run when skipping", args)
+			return nil
+		}
+		if path, err := createWorktree("feat", createOptions{onConflict: OnConflictSkip}); err != nil || path != "" {
+			t.Errorf("createWorktree() = %q, %v; want no path and no error", path, err)
+		}
+	})
+
+	t.Run("skip fails when worktrees cannot be listed", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("git failed")
+		}
+		if _, err := createWorktree("feat", createOptions{onConflict: OnConflictSkip}); err == nil || err.Error() != "git failed" {
+			t.Errorf("createWorktree() error = %v, want 'git failed'", err)
+		}
+	})
+}
+
+func TestCreateReplaceStale(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origListInfos := listWorktreeInfosFn
+	origRemoveAll := removeAllFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origListInfos
+		removeAllFn = origRemoveAll
+	}()
+
+	// Only the feat branch exists
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "refs/heads/feat^{commit}" {
+			return "1111111111111111111111111111111111111111", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+	prunable := "gitdir file points to non-existent location"
+
+	tests := []struct {
+		name      string
+		worktree  string
+		opts      createOptions
+		prunable  bool  // git still registers the worktree, as prunable
+		live      bool  // git tracks the worktree and it is not prunable
+		leftover  bool  // the directory is left behind with its .git file
+		infosErr  error // error listing worktrees
+		removeErr error // error removing the stale directory
+		pruneErr  error // error from git worktree prune
+		addErr    error // error from git worktree add
+		wantCalls []string
+		wantErr   string
+	}{
+		{
+			name:      "prunable registration is pruned and its branch checked out",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true},
+			prunable:  true,
+			wantCalls: []string{"worktree prune", "worktree add {path} --no-checkout feat"},
+		},
+		{
+			name:      "leftover directory is removed and recreated",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true},
+			leftover:  true,
+			wantCalls: []string{"worktree prune", "worktree add {path} --no-checkout feat"},
+		},
+		{
+			name:      "stale worktree whose branch is gone gets a new branch",
+			worktree:  "gone",
+			opts:      createOptions{replaceStale: true},
+			leftover:  true,
+			wantCalls: []string{"worktree prune", "worktree add {path} -b gone --no-checkout"},
+		},
+		{
+			name:      "replaces a stale worktree before skipping existing branches",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true, onConflict: OnConflictSkip},
+			prunable:  true,
+			wantCalls: []string{"worktree prune", "worktree add {path} --no-checkout feat"},
+		},
+		{
+			name:      "nothing stale creates as usual",
+			worktree:  "new",
+			opts:      createOptions{replaceStale: true},
+			wantCalls: []string{"worktree add {path} -b new --no-checkout"},
+		},
+		{
+			name:     "dry run changes nothing",
+			worktree: "feat",
+			opts:     createOptions{replaceStale: true, dryRun: true},
+			leftover: true,
+		},
+		{
+			name:      "without the flag a stale worktree is explained",
+			worktree:  "feat",
+			prunable:  true,
+			addErr:    errors.New("exit status 128"),
+			wantCalls: []string{"worktree add {path} -b feat --no-checkout"},
+			wantErr:   ".worktrees/feat is a stale worktree (prunable: " + prunable + "); rerun with --checkout-existing-ok to replace it",
+		},
+		{
+			name:      "a live worktree is left to git",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true},
+			live:      true,
+			addErr:    errors.New("exit status 128"),
+			wantCalls: []string{"worktree add {path} -b feat --no-checkout"},
+			wantErr:   "failed to create worktree: exit status 128",
+		},
+		{
+			name:      "without the flag other failures are reported as is",
+			worktree:  "new",
+			addErr:    errors.New("exit status 128"),
+			wantCalls: []string{"worktree add {path} -b new --no-checkout"},
+			wantErr:   "failed to create worktree: exit status 128",
+		},
+		{
+			name:      "an existing branch no worktree has suggests --attach",
+			worktree:  "feat",
+			addErr:    errors.New("exit status 128"),
+			wantCalls: []string{"worktree add {path} -b feat --no-checkout"},
+			wantErr:   "branch feat already exists; check it out with 'wt create --attach feat' or rerun with --on-conflict checkout",
+		},
+		{
+			name:     "listing worktrees fails",
+			worktree: "feat",
+			opts:     createOptions{replaceStale: true},
+			infosErr: errors.New("git failed"),
+			wantErr:  "git failed",
+		},
+		{
+			name:      "stale directory cannot be removed",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true},
+			leftover:  true,
+			removeErr: errors.New("permission denied"),
+			wantErr:   "failed to remove stale worktree: permission denied",
+		},
+		{
+			name:      "prune fails",
+			worktree:  "feat",
+			opts:      createOptions{replaceStale: true},
+			prunable:  true,
+			pruneErr:  errors.New("exit status 1"),
+			wantCalls: []string{"worktree prune"},
+			wantErr:   "failed to prune worktrees: exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			worktreePath := filepath.Join(tmpDir, WorktreesDir, tt.worktree)
+			if tt.leftover {
+				os.MkdirAll(worktreePath, 0755)
+				os.WriteFile(filepath.Join(worktreePath, ".git"), []byte("gitdir: /gone\n"), 0644)
+			}
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				infos := []Worktree{{Path: tmpDir, Branch: "main"}}
+				if tt.prunable || tt.live {
+					info := Worktree{Path: worktreePath, Branch: tt.worktree}
+					if tt.prunable {
+						info.Prunable = prunable
+					}
+					infos = append(infos, info)
+				}
+				return infos, tt.infosErr
+			}
+			removeAllFn = func(path string) error {
+				if tt.removeErr != nil {
+					return tt.removeErr
+				}
+				return os.RemoveAll(path)
+			}
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				call := strings.Join(args, " ")
+				calls = append(calls, strings.ReplaceAll(call, worktreePath, "{path}"))
+				if args[1] == "prune" {
+					return tt.pruneErr
+				}
+				if tt.leftover && fileExists(filepath.Join(worktreePath, ".git")) {
+					t.Errorf("git %s ran before the stale directory was removed", call)
+				}
+				return tt.addErr
+			}
+
+			tt.opts.noCheckout = true
+			path, err := createWorktree(tt.worktree, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("createWorktree() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			} else if wantPath := worktreePath; !tt.opts.dryRun && path != wantPath {
+				t.Errorf("createWorktree() path = %q, want %q", path, wantPath)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if tt.opts.dryRun && !fileExists(filepath.Join(worktreePath, ".git")) {
+				t.Error("dry run removed the stale directory")
+			}
+		})
+	}
+}
+
+func TestCreateHookOnExistingBranch(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origListInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origListInfos
+	}()
+
+	// Only the feat branch exists
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "refs/heads/feat^{commit}" {
+			return "1111111111111111111111111111111111111111", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+	gitCmdFn = func(dir string, args ...string) error {
+		if args[1] == "add" {
+			os.MkdirAll(args[2], 0755)
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		worktree string
+		opts     createOptions
+		stale    bool // git still registers a prunable worktree at the target
+		wantRan  bool
+	}{
+		{name: "checkout on conflict skips the hook", worktree: "feat", opts: createOptions{onConflict: OnConflictCheckout}},
+		{name: "checkout on conflict with --run-hook", worktree: "feat", opts: createOptions{onConflict: OnConflictCheckout, runHook: true}, wantRan: true},
+		{name: "checkout on conflict of a new branch runs the hook", worktree: "new", opts: createOptions{onConflict: OnConflictCheckout}, wantRan: true},
+		{name: "replacing a stale worktree skips the hook", worktree: "feat", opts: createOptions{replaceStale: true}, stale: true},
+		{name: "replacing a stale worktree with --run-hook", worktree: "feat", opts: createOptions{replaceStale: true, runHook: true}, stale: true, wantRan: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+			os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("#!/bin/sh\ntouch hook-ran\n"), 0755)
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				infos := []Worktree{{Path: tmpDir, Branch: "main"}}
+				if tt.stale {
+					infos = append(infos, Worktree{Path: filepath.Join(tmpDir, WorktreesDir, tt.worktree), Branch: tt.worktree, Prunable: "gitdir file points to non-existent location"})
+				}
+				return infos, nil
+			}
+
+			oldStderr := os.Stderr
+			os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			path, err := createWorktree(tt.worktree, tt.opts)
+			os.Stderr.Close()
+			os.Stderr = oldStderr
+			if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			if ran := fileExists(filepath.Join(path, "hook-ran")); ran != tt.wantRan {
+				t.Errorf("createWorktree() ran the hook = %v, want %v", ran, tt.wantRan)
+			}
+		})
+	}
+}
+
+func TestCreateIfMissing(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origListInfos := listWorktreeInfosFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		listWorktreeInfosFn = origListInfos
+		os.Stdout = origStdout
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	existing := filepath.Join(tmpDir, WorktreesDir, "feat")
+	pruned := filepath.Join(tmpDir, WorktreesDir, "gone")
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{
+			{Name: "main", Path: tmpDir, Branch: "main"},
+			{Name: "feat", Path: existing, Branch: "feat"},
+			{Name: "gone", Path: pruned, Branch: "gone", Prunable: "gitdir file points to non-existent location"},
+		}, nil
+	}
+
+	tests := []struct {
+		name    string
+		wtName  string
+		dryRun  bool
+		wantOut string
+		wantAdd bool
+	}{
+		{name: "existing worktree is left alone and printed", wtName: "feat", wantOut: existing + "\n"},
+		{name: "existing worktree in a dry run prints nothing", wtName: "feat", dryRun: true},
+		{name: "new worktree is created", wtName: "new", wantOut: filepath.Join(tmpDir, WorktreesDir, "new") + "\n", wantAdd: true},
+		{name: "prunable worktree does not count", wtName: "gone", wantOut: pruned + "\n", wantAdd: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added := false
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" && args[1] == "add" {
+					added = true
+				}
+				return nil
+			}
+
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			err := create(tt.wtName, createOptions{ifMissing: true, noCheckout: true, dryRun: tt.dryRun})
+			w.Close()
+			os.Stdout = origStdout
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+
+			if err != nil {
+				t.Fatalf("create() unexpected error: %v", err)
+			}
+			if buf.String() != tt.wantOut {
+				t.Errorf("create() stdout = %q, want %q", buf.String(), tt.wantOut)
+			}
+			if added != tt.wantAdd {
+				t.Errorf("git worktree add ran = %v, want %v", added, tt.wantAdd)
+			}
+		})
+	}
+
+	t.Run("worktree list error", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("git worktree list failed")
+		}
+
+		_, err := createWorktree("feat", createOptions{ifMissing: true})
+		if err == nil || err.Error() != "git worktree list failed" {
+			t.Errorf("createWorktree() error = %v, want list error", err)
+		}
+	})
+}
+
+func TestCreateAttach(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// Only the fix-ci and feature/Login branches exist
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "refs/heads/fix-ci^{commit}" || args[3] == "refs/heads/feature/Login^{commit}" {
+			return "1111111111111111111111111111111111111111", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+
+	tests := []struct {
+		name      string
+		wtName    string
+		attach    string
+		branchDir string
+		wantDir   string
+	}{
+		{name: "named after the branch", wtName: "", attach: "fix-ci", wantDir: "fix-ci"},
+		{name: "with a name", wtName: "ci", attach: "fix-ci", wantDir: "ci"},
+		{name: "slashed branch as a slug", attach: "feature/Login", wantDir: "feature-login"},
+		{name: "slashed branch by its last segment", attach: "feature/Login", branchDir: BranchDirLast, wantDir: "Login"},
+		{name: "name wins over the style", wtName: "review", attach: "feature/Login", branchDir: BranchDirLast, wantDir: "review"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var addArgs []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" {
+					addArgs = args
+				}
+				return nil
+			}
+
+			path, err := createWorktree(tt.wtName, createOptions{attach: tt.attach, branchDir: tt.branchDir, noCheckout: true})
+			if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			wantPath := filepath.Join(tmpDir, WorktreesDir, tt.wantDir)
+			if path != wantPath {
+				t.Errorf("createWorktree() path = %q, want %q", path, wantPath)
+			}
+			wantAdd := []string{"worktree", "add", wantPath, "--no-checkout", tt.attach}
+			if !reflect.DeepEqual(addArgs, wantAdd) {
+				t.Errorf("git worktree add args = %q, want %q", addArgs, wantAdd)
+			}
+		})
+	}
+
+	t.Run("hook runs only with --run-hook", func(t *testing.T) {
+		os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("#!/bin/sh\ntouch hook-ran\n"), 0755)
+		defer os.Remove(filepath.Join(tmpDir, DefaultHook))
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				os.MkdirAll(args[2], 0755)
+			}
+			return nil
+		}
+
+		for _, runHook := range []bool{false, true} {
+			wtName := fmt.Sprintf("run-hook-%v", runHook)
+			path, err := createWorktree(wtName, createOptions{attach: "fix-ci", runHook: runHook})
+			if err != nil {
+				t.Fatalf("createWorktree(runHook=%v) unexpected error: %v", runHook, err)
+			}
+			if ran := fileExists(filepath.Join(path, "hook-ran")); ran != runHook {
+				t.Errorf("createWorktree(runHook=%v) ran the hook = %v, want %v", runHook, ran, runHook)
+			}
+		}
+	})
+
+	t.Run("derived directory already exists", func(t *testing.T) {
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, "Login"), 0755)
+		defer os.RemoveAll(filepath.Join(tmpDir, WorktreesDir, "Login"))
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run when the directory is taken", args)
+			return nil
+		}
+
+		_, err := createWorktree("", createOptions{attach: "feature/Login", branchDir: BranchDirLast})
+		want := "directory " + WorktreesDir + "/Login for branch feature/Login already exists; pass a name for the worktree"
+		if err == nil || err.Error() != want {
+			t.Errorf("createWorktree() error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("branch without letters or digits", func(t *testing.T) {
+		_, err := createWorktree("", createOptions{attach: "__"})
+		want := `branch "__" has no letters or digits to name the worktree (pass a name)`
+		if err == nil || err.Error() != want {
+			t.Errorf("createWorktree() error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("missing branch", func(t *testing.T) {
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run for a missing branch", args)
+			return nil
+		}
+
+		_, err := createWorktree("", createOptions{attach: "gone"})
+		if err == nil || err.Error() != "branch gone does not exist" {
+			t.Errorf("createWorktree() error = %v, want missing branch error", err)
+		}
+	})
+}
+
+func TestCreateDetach(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("branch_prefix = alice/\n"), 0644)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// Only the v2.0.0 tag exists
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "v2.0.0^{commit}" {
+			return "2222222222222222222222222222222222222222", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+
+	t.Run("checks out the tag without a branch", func(t *testing.T) {
+		var addArgs []string
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				addArgs = args
+			}
+			return nil
+		}
+
+		path, err := createWorktree("release-review", createOptions{detach: "v2.0.0"})
+		if err != nil {
+			t.Fatalf("createWorktree() unexpected error: %v", err)
+		}
+		wantPath := filepath.Join(tmpDir, WorktreesDir, "release-review")
+		if path != wantPath {
+			t.Errorf("createWorktree() path = %q, want %q", path, wantPath)
+		}
+		wantAdd := []string{"worktree", "add", "--detach", wantPath, "v2.0.0"}
+		if !reflect.DeepEqual(addArgs, wantAdd) {
+			t.Errorf("git worktree add args = %q, want %q", addArgs, wantAdd)
+		}
+	})
+
+	t.Run("with no checkout", func(t *testing.T) {
+		var addArgs []string
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				addArgs = args
+			}
+			return nil
+		}
+
+		if _, err := createWorktree("release", createOptions{detach: "v2.0.0", noCheckout: true}); err != nil {
+			t.Fatalf("createWorktree() unexpected error: %v", err)
+		}
+		wantAdd := []string{"worktree", "add", "--detach", filepath.Join(tmpDir, WorktreesDir, "release"), "--no-checkout", "v2.0.0"}
+		if !reflect.DeepEqual(addArgs, wantAdd) {
+			t.Errorf("git worktree add args = %q, want %q", addArgs, wantAdd)
+		}
+	})
+
+	t.Run("ref that does not resolve", func(t *testing.T) {
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run for an invalid ref", args)
+			return nil
+		}
+
+		_, err := createWorktree("release", createOptions{detach: "v9.9.9"})
+		if err == nil || err.Error() != "invalid --detach: v9.9.9 does not resolve to a commit" {
+			t.Errorf("createWorktree() error = %v, want invalid ref error", err)
+		}
+	})
+}
+
+func TestCreateFromPR(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origGhOutput := ghOutputFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		ghOutputFn = origGhOutput
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, "taken"), 0755)
+	// branch_prefix names local branches only, so it must not rename the pull request's own branch
+	os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("branch_prefix = alice/\n"), 0644)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	fetchHead := "2222222222222222222222222222222222222222"
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		switch args[3] {
+		case "origin/feature/login^{commit}", "origin/taken^{commit}":
+			return "1111111111111111111111111111111111111111", nil
+		case "FETCH_HEAD^{commit}":
+			if fetchHead != "" {
+				return fetchHead, nil
+			}
+		}
+		return "", errors.New("exit status 1")
+	}
+	ghOutputFn = func(args ...string) (string, error) {
+		switch args[2] {
+		case "123":
+			return "feature/login false", nil
+		case "77":
+			return "main true", nil
+		case "5":
+			return "taken false", nil
+		}
+		return "", errors.New("exit status 1: no pull requests found")
+	}
+	fetch := "fetch origin +refs/pull/123/head:refs/remotes/origin/feature/login"
+	forkFetch := "fetch origin refs/pull/77/head"
+
+	tests := []struct {
+		name      string
+		worktree  string
+		pr        string
+		push      bool
+		fetchErr  error
+		configErr error
+		noFetch   bool
+		wantPath  string
+		wantCalls []string
+		wantErr   string
+	}{
+		{
+			name:     "named after the pull request branch",
+			pr:       "123",
+			wantPath: filepath.Join(tmpDir, WorktreesDir, "feature-login"),
+			wantCalls: []string{
+				fetch,
+				"worktree add " + filepath.Join(tmpDir, WorktreesDir, "feature-login") + " -b feature/login --no-checkout --track origin/feature/login",
+			},
+		},
+		{
+			name:     "explicit name keeps the pull request branch",
+			worktree: "review",
+			pr:       "123",
+			wantPath: filepath.Join(tmpDir, WorktreesDir, "review"),
+			wantCalls: []string{
+				fetch,
+				"worktree add " + filepath.Join(tmpDir, WorktreesDir, "review") + " -b feature/login --no-checkout --track origin/feature/login",
+			},
+		},
+		{
+			name:     "push updates the pull request branch",
+			pr:       "123",
+			push:     true,
+			wantPath: filepath.Join(tmpDir, WorktreesDir, "feature-login"),
+			wantCalls: []string{
+				fetch,
+				"worktree add " + filepath.Join(tmpDir, WorktreesDir, "feature-login") + " -b feature/login --no-checkout --track origin/feature/login",
+				"push -u origin feature/login",
+			},
+		},
+		{
+			name:     "from a fork",
+			pr:       "77",
+			wantPath: filepath.Join(tmpDir, WorktreesDir, "main"),
+			wantCalls: []string{
+				forkFetch,
+				"worktree add " + filepath.Join(tmpDir, WorktreesDir, "main") + " -b alice/main --no-checkout " + fetchHead,
+				"config branch.alice/main.remote origin",
+				"config branch.alice/main.merge refs/pull/77/head",
+			},
+		},
+		{
+			name:    "push from a fork",
+			pr:      "77",
+			push:    true,
+			wantErr: "cannot push pull request #77: it was opened from a fork, so its branch is not on origin",
+		},
+		{
+			name:      "fork upstream cannot be set",
+			pr:        "77",
+			configErr: errors.New("exit status 255"),
+			wantCalls: []string{
+				forkFetch,
+				"worktree add " + filepath.Join(tmpDir, WorktreesDir, "main") + " -b alice/main --no-checkout " + fetchHead,
+				"config branch.alice/main.remote origin",
+			},
+			wantErr: "failed to track pull request #77: exit status 255",
+		},
+		{
+			name:      "fork fetch leaves no head",
+			pr:        "77",
+			noFetch:   true,
+			wantCalls: []string{forkFetch},
+			wantErr:   "failed to fetch main from origin: FETCH_HEAD does not resolve to a commit",
+		},
+		{
+			name:      "derived directory already exists",
+			pr:        "5",
+			wantCalls: []string{"fetch origin +refs/pull/5/head:refs/remotes/origin/taken"},
+			wantErr:   "directory " + WorktreesDir + "/taken for branch taken already exists; pass a name for the worktree",
+		},
+		{
+			name:    "pull request does not exist",
+			pr:      "9",
+			wantErr: "failed to look up pull request #9: exit status 1: no pull requests found",
+		},
+		{
+			name:      "fetch fails",
+			pr:        "123",
+			fetchErr:  errors.New("exit status 128"),
+			wantCalls: []string{fetch},
+			wantErr:   "failed to fetch feature/login from origin: exit status 128",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				calls = append(calls, strings.Join(args, " "))
+				switch args[0] {
+				case "fetch":
+					return tt.fetchErr
+				case "config":
+					return tt.configErr
+				}
+				return nil
+			}
+			fetchHead = "2222222222222222222222222222222222222222"
+			if tt.noFetch {
+				fetchHead = ""
+			}
+
+			path, err := createWorktree(tt.worktree, createOptions{fromPR: tt.pr, push: tt.push, noCheckout: true})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("createWorktree() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil || path != tt.wantPath {
+				t.Errorf("createWorktree() = %q, %v; want %q", path, err, tt.wantPath)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCreateAfter(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origListWorktreeInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origListWorktreeInfos
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "feature/base^{commit}" {
+			return "1111111111111111111111111111111111111111", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+	infos := []Worktree{
+		{Path: tmpDir, Branch: "main"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "base-feature"), Branch: "feature/base"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "review"), Detached: true},
+	}
+
+	tests := []struct {
+		name      string
+		after     string
+		listErr   error
+		wantCalls []string
+		wantErr   string
+	}{
+		{
+			name:      "starts at and tracks the other worktree's branch",
+			after:     "base-feature",
+			wantCalls: []string{"worktree add " + filepath.Join(tmpDir, WorktreesDir, "next") + " -b next --no-checkout --track feature/base"},
+		},
+		{
+			name:    "worktree does not exist",
+			after:   "missing",
+			wantErr: "invalid --after: worktree missing does not exist",
+		},
+		{
+			name:    "worktree on a detached HEAD",
+			after:   "review",
+			wantErr: "invalid --after: worktree review has no branch to stack on (detached HEAD)",
+		},
+		{
+			name:    "git worktree list fails",
+			after:   "base-feature",
+			listErr: errors.New("mock error"),
+			wantErr: "mock error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				return infos, tt.listErr
+			}
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				calls = append(calls, strings.Join(args, " "))
+				return nil
+			}
+
+			path, err := createWorktree("next", createOptions{after: tt.after, noCheckout: true})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("createWorktree() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if want := filepath.Join(tmpDir, WorktreesDir, "next"); err != nil || path != want {
+				t.Errorf("createWorktree() = %q, %v; want %q", path, err, want)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCreateNestedRepo(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origEnclosing := enclosingFn
+	origInput := promptInput
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		enclosingFn = origEnclosing
+		promptInput = origInput
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	tests := []struct {
+		name       string
+		outer      string
+		input      string
+		dryRun     bool
+		yes        bool
+		wantStderr string
+		wantAdd    bool
+		wantErr    string
+	}{
+		{
+			name:    "repository that is not nested",
+			wantAdd: true,
+		},
+		{
+			name:       "nested repository confirmed",
+			outer:      "/src/app",
+			input:      "y\n",
+			wantStderr: "warning: " + tmpDir + " is a git repository nested inside /src/app\nCreate the worktree in the nested repository " + tmpDir + "? [y/N] ",
+			wantAdd:    true,
+		},
+		{
+			name:       "nested repository declined",
+			outer:      "/src/app",
+			input:      "n\n",
+			wantStderr: "warning: " + tmpDir + " is a git repository nested inside /src/app\nCreate the worktree in the nested repository " + tmpDir + "? [y/N] ",
+			wantErr:    "aborted; run wt create from /src/app to create the worktree there",
+		},
+		{
+			name:       "closed stdin aborts",
+			outer:      "/src/app",
+			wantStderr: "warning: " + tmpDir + " is a git repository nested inside /src/app\nCreate the worktree in the nested repository " + tmpDir + "? [y/N] ",
+			wantErr:    "aborted; run wt create from /src/app to create the worktree there",
+		},
+		{
+			name:       "yes creates it without asking",
+			outer:      "/src/app",
+			yes:        true,
+			wantStderr: "warning: " + tmpDir + " is a git repository nested inside /src/app\n",
+			wantAdd:    true,
+		},
+		{
+			name:       "dry run only warns",
+			outer:      "/src/app",
+			dryRun:     true,
+			wantStderr: "warning: " + tmpDir + " is a git repository nested inside /src/app\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enclosingFn = func(root string) string {
+				if root != tmpDir {
+					t.Errorf("enclosingRepo(%q), want %q", root, tmpDir)
+				}
+				return tt.outer
+			}
+			promptInput = strings.NewReader(tt.input)
+			added := false
+			gitCmdFn = func(dir string, args ...string) error {
+				added = added || args[0] == "worktree"
+				return nil
+			}
+
+			oldStdout, oldStderr := os.Stdout, os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			_, err := createWorktree("feat", createOptions{dryRun: tt.dryRun, yes: tt.yes, noCheckout: true})
+			w.Close()
+			os.Stdout.Close()
+			os.Stdout, os.Stderr = oldStdout, oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("createWorktree() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			if added != tt.wantAdd {
+				t.Errorf("worktree added = %v, want %v", added, tt.wantAdd)
+			}
+			if got := stderr.String(); !strings.HasPrefix(got, tt.wantStderr) || (tt.wantStderr == "" && strings.Contains(got, "warning")) {
+				t.Errorf("stderr = %q, want it to start with %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCreateDryRun(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("copy_dirs = .vscode, missing\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("#!/bin/sh\n"), 0755)
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "test-branch")
+
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitCmdFn = func(dir string, args ...string) error {
+		t.Errorf("git %v should not run in a dry run", args)
+		return nil
+	}
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	outR, outW, _ := os.Pipe()
+	errR, errW, _ := os.Pipe()
+	os.Stdout, os.Stderr = outW, errW
+
+	err := create("test-branch", createOptions{dryRun: true})
+
+	outW.Close()
+	errW.Close()
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	var stdout, stderr bytes.Buffer
+	io.Copy(&stdout, outR)
+	io.Copy(&stderr, errR)
+
+	if err != nil {
+		t.Fatalf("create() unexpected error: %v", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("create() stdout = %q, want empty so the shell wrapper does not cd", stdout.String())
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("dry run should not create %s, got err = %v", worktreePath, err)
+	}
+	want := "Dry run: nothing will be created\n" +
+		"Would run: git worktree add " + worktreePath + " -b test-branch\n" +
+		"Would copy .vscode/ into the worktree\n" +
+		"Would run hook: " + DefaultHook + "\n"
+	if stderr.String() != want {
+		t.Errorf("create() plan = %q, want %q", stderr.String(), want)
+	}
+}
+
+func TestPrintCreatePlan(t *testing.T) {
+	t.Setenv(NoClaudeCopyEnv, "")
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, ClaudeDir), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".vscode"), 0755)
+	wm := &WorktreeManager{root: tmpDir, config: &Config{WorktreesDir: WorktreesDir, DefaultHook: DefaultHook, CopyDirs: []string{".vscode"}}}
+	worktreePath := wm.WorktreePath("feat")
+	addArgs := []string{"worktree", "add", worktreePath, "-b", "feat", "--no-checkout"}
+
+	t.Run("sparse checkout with submodules and without a hook", func(t *testing.T) {
+		var buf bytes.Buffer
+		printCreatePlan(&buf, wm, worktreePath, "", "", DefaultHook, wm.Config().CopyDirs, []string{".env"}, addArgs, nil, createOptions{sparse: []string{"web", "docs"}, submodules: true, templateDir: "/skel"})
+		want := "Dry run: nothing will be created\n" +
+			"Would run: git worktree add " + worktreePath + " -b feat --no-checkout\n" +
+			"Would run in " + worktreePath + ": git sparse-checkout set web docs\n" +
+			"Would run in " + worktreePath + ": git checkout\n" +
+			"Would run in " + worktreePath + " if it has submodules: git submodule update --init --recursive\n" +
+			"Would symlink " + ClaudeDir + "/ into the worktree\n" +
+			"Would copy template /skel/ into the worktree\n" +
+			"Would copy .vscode/ into the worktree\n" +
+			"Would copy .env into the worktree (" + CopyManifest + ")\n" +
+			"Would not run a hook: " + DefaultHook + " does not exist\n"
+		if buf.String() != want {
+			t.Errorf("printCreatePlan() = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("hook skipped for an attached branch", func(t *testing.T) {
+		os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("#!/bin/sh\n"), 0755)
+		defer os.Remove(filepath.Join(tmpDir, DefaultHook))
+		attachArgs := []string{"worktree", "add", worktreePath, "fix-ci"}
+
+		var buf bytes.Buffer
+		printCreatePlan(&buf, wm, worktreePath, "", "fix-ci", DefaultHook, nil, nil, attachArgs, nil, createOptions{attach: "fix-ci", noClaude: true})
+		want := "Dry run: nothing will be created\n" +
+			"Would run: git worktree add " + worktreePath + " fix-ci\n" +
+			"Would skip hook " + DefaultHook + " for the existing branch fix-ci (pass --run-hook to run it)\n"
+		if buf.String() != want {
+			t.Errorf("printCreatePlan() = %q, want %q", buf.String(), want)
+		}
+
+		buf.Reset()
+		printCreatePlan(&buf, wm, worktreePath, "", "fix-ci", DefaultHook, nil, nil, attachArgs, nil, createOptions{attach: "fix-ci", noClaude: true, runHook: true})
+		if want := "Would run hook: " + DefaultHook + "\n"; !strings.HasSuffix(buf.String(), want) {
+			t.Errorf("printCreatePlan() = %q, want it to end with %q", buf.String(), want)
+		}
+	})
+
+	t.Run("no claude keeps copy_dirs", func(t *testing.T) {
+		var buf bytes.Buffer
+		printCreatePlan(&buf, wm, worktreePath, "", "", DefaultHook, wm.Config().CopyDirs, nil, addArgs, nil, createOptions{noClaude: true})
+		want := "Dry run: nothing will be created\n" +
+			"Would run: git worktree add " + worktreePath + " -b feat --no-checkout\n" +
+			"Would copy .vscode/ into the worktree\n" +
+			"Would not run a hook: " + DefaultHook + " does not exist\n"
+		if buf.String() != want {
+			t.Errorf("printCreatePlan() = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("stale worktree, no checkout skips copy and hook, then pushes", func(t *testing.T) {
+		t.Setenv(NoClaudeCopyEnv, "1")
+
+		var buf bytes.Buffer
+		printCreatePlan(&buf, wm, worktreePath, "git no longer tracks it", "", DefaultHook, wm.Config().CopyDirs, nil, addArgs, []string{"push", "-u", "origin", "feat"}, createOptions{noCheckout: true})
+		want := "Dry run: nothing will be created\n" +
+			"Would remove the stale worktree at " + worktreePath + " (git no longer tracks it) and run: git worktree prune\n" +
+			"Would run: git worktree add " + worktreePath + " -b feat --no-checkout\n" +
+			"Would skip copied directories and hook: no files are checked out (--no-checkout)\n" +
+			"Would run in " + worktreePath + ": git push -u origin feat\n"
+		if buf.String() != want {
+			t.Errorf("printCreatePlan() = %q, want %q", buf.String(), want)
+		}
+	})
+}
+
+func TestCreatePush(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	tests := []struct {
+		name       string
+		opts       createOptions
+		pushErr    error
+		wantPush   []string
+		wantStderr string
+	}{
+		{name: "no push by default"},
+		{
+			name:       "push sets the upstream",
+			opts:       createOptions{push: true},
+			wantPush:   []string{"push", "-u", "origin", "feat"},
+			wantStderr: "Pushing branch feat to origin\n",
+		},
+		{
+			name:     "push skips git hooks with --no-verify",
+			opts:     createOptions{push: true, noVerify: true},
+			wantPush: []string{"-c", "core.hooksPath=" + os.DevNull, "push", "-u", "origin", "feat"},
+		},
+		{
+			name:       "push failure keeps the worktree",
+			opts:       createOptions{push: true},
+			pushErr:    errors.New("exit status 128"),
+			wantPush:   []string{"push", "-u", "origin", "feat"},
+			wantStderr: "warning: failed to push branch feat: exit status 128; push it later with 'git push -u origin feat'\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			var pushed []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if slices.Contains(args, "push") {
+					if dir != worktreePath {
+						t.Errorf("git push ran in %s, want %s", dir, worktreePath)
+					}
+					pushed = args
+					return tt.pushErr
+				}
+				os.MkdirAll(worktreePath, 0755)
+				return nil
+			}
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			tt.opts.hookPath = "no-such-hook"
+			path, err := createWorktree("feat", tt.opts)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if err != nil || path != worktreePath {
+				t.Fatalf("createWorktree() = %q, %v; want %q", path, err, worktreePath)
+			}
+			if !reflect.DeepEqual(pushed, tt.wantPush) {
+				t.Errorf("git push args = %q, want %q", pushed, tt.wantPush)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCreateOpenURL(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origOpenURL := openURLFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		openURLFn = origOpenURL
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitCmdFn = func(dir string, args ...string) error {
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		remote     string
+		dryRun     bool
+		openErr    error
+		wantOpened string
+		wantStderr string
+	}{
+		{
+			name:       "opens the compare page",
+			remote:     "git@github.com:acme/app.git",
+			wantOpened: "https://github.com/acme/app/compare/main...feat",
+			wantStderr: "Compare feat: https://github.com/acme/app/compare/main...feat\n",
+		},
+		{
+			name:       "browser fails",
+			remote:     "https://github.com/acme/app.git",
+			openErr:    errors.New("exec: \"xdg-open\": executable file not found in $PATH"),
+			wantOpened: "https://github.com/acme/app/compare/main...feat",
+			wantStderr: "warning: failed to open the browser: exec: \"xdg-open\": executable file not found in $PATH\n",
+		},
+		{
+			name:       "no origin remote",
+			wantStderr: "warning: no origin remote to compare feat on\n",
+		},
+		{
+			name:       "dry run",
+			remote:     "https://github.com/acme/app.git",
+			dryRun:     true,
+			wantStderr: "Would open https://github.com/acme/app/compare/main...feat\n",
+		},
+		{
+			name:       "dry run without origin",
+			dryRun:     true,
+			wantStderr: "Would not open a compare page: no origin remote to compare feat on\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitOutputFn = func(dir string, args ...string) (string, error) {
+				switch strings.Join(args, " ") {
+				case "remote get-url origin":
+					if tt.remote != "" {
+						return tt.remote, nil
+					}
+				case "symbolic-ref --quiet --short refs/remotes/origin/HEAD":
+					return "origin/main", nil
+				}
+				return "", errors.New("exit status 1")
+			}
+			var opened string
+			openURLFn = func(u string) error {
+				opened = u
+				return tt.openErr
+			}
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			path, err := createWorktree("feat", createOptions{openURL: true, dryRun: tt.dryRun, noCheckout: true})
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			wantPath := worktreePath
+			if tt.dryRun {
+				wantPath = ""
+			}
+			if err != nil || path != wantPath {
+				t.Fatalf("createWorktree() = %q, %v; want %q", path, err, wantPath)
+			}
+			if opened != tt.wantOpened {
+				t.Errorf("opened %q, want %q", opened, tt.wantOpened)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCreateInterrupted(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origNotify := notifyInterruptsFn
+	origExit := exitFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		notifyInterruptsFn = origNotify
+		exitFn = origExit
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	var interrupts chan<- os.Signal
+	notifyInterruptsFn = func(c chan<- os.Signal) func() {
+		interrupts = c
+		return func() {}
+	}
+	exitCode := -1
+	exitFn = func(code int) {
+		exitCode = code
+	}
+	var mu sync.Mutex
+	var calls []string
+	gitCmdFn = func(dir string, args ...string) error {
+		mu.Lock()
+		calls = append(calls, strings.Join(args, " "))
+		mu.Unlock()
+		switch args[0] {
+		case "worktree":
+			os.MkdirAll(worktreePath, 0755)
+			os.WriteFile(filepath.Join(worktreePath, ".gitmodules"), []byte("[submodule \"lib\"]\n"), 0644)
+		case "submodule":
+			// Ctrl-C while the submodules are updated, after the worktree was added
+			interrupts <- os.Interrupt
+		}
+		return nil
+	}
+
+	createWorktree("feat", createOptions{submodules: true, noClaude: true})
+	want := []string{
+		"worktree add " + worktreePath + " -b feat",
+		strings.Join(submoduleUpdateArgs, " "),
+		"worktree remove --force " + worktreePath,
+		"branch -D feat",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("git calls = %q, want %q", calls, want)
+	}
+	if exitCode != 130 {
+		t.Errorf("exit code = %d, want 130", exitCode)
+	}
+}
+
+func TestRollbackOnInterrupt(t *testing.T) {
+	origGitCmd := gitCmdFn
+	origNotify := notifyInterruptsFn
+	origExit := exitFn
+	defer func() {
+		gitCmdFn = origGitCmd
+		notifyInterruptsFn = origNotify
+		exitFn = origExit
+	}()
+
+	tests := []struct {
+		name       string
+		interrupt  bool
+		branch     string
+		gitErr     error
+		wantCalls  []string
+		wantExit   int
+		wantStderr string
+	}{
+		{name: "not interrupted", wantExit: -1},
+		{
+			name:       "interrupted without a new branch",
+			interrupt:  true,
+			wantCalls:  []string{"worktree remove --force /repo/.worktrees/feat"},
+			wantExit:   130,
+			wantStderr: "Interrupted; removing the unfinished worktree at /repo/.worktrees/feat\n",
+		},
+		{
+			name:      "rollback fails",
+			interrupt: true,
+			branch:    "feat",
+			gitErr:    errors.New("exit status 128"),
+			wantCalls: []string{"worktree remove --force /repo/.worktrees/feat", "branch -D feat"},
+			wantExit:  130,
+			wantStderr: "Interrupted; removing the unfinished worktree at /repo/.worktrees/feat\n" +
+				"warning: failed to remove worktree: exit status 128\n" +
+				"warning: failed to delete branch feat: exit status 128\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stopped := false
+			notifyInterruptsFn = func(c chan<- os.Signal) func() {
+				if tt.interrupt {
+					c <- os.Interrupt
+				}
+				return func() { stopped = true }
+			}
+			exitCode := -1
+			exitFn = func(code int) {
+				exitCode = code
+			}
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if dir != "/repo" {
+					t.Errorf("git %v ran in %s, want the repository root", args, dir)
+				}
+				calls = append(calls, strings.Join(args, " "))
+				return tt.gitErr
+			}
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			rollbackOnInterrupt("/repo", "/repo/.worktrees/feat", tt.branch)()
+			w.Close()
+			os.Stderr = oldStderr
+			stderr, _ := io.ReadAll(r)
+
+			if !stopped {
+				t.Error("rollbackOnInterrupt() did not stop watching for interrupts")
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if exitCode != tt.wantExit {
+				t.Errorf("exit code = %d, want %d", exitCode, tt.wantExit)
+			}
+			if string(stderr) != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestCreateRecurseSubmodules(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	tests := []struct {
+		name       string
+		submodules bool
+		gitmodules bool // whether the checked out worktree has a .gitmodules file
+		updateErr  error
+		wantUpdate bool
+		wantErr    string
+	}{
+		{name: "updates submodules with the flag", submodules: true, gitmodules: true, wantUpdate: true},
+		{name: "skipped without the flag", gitmodules: true},
+		{name: "no-op without submodules", submodules: true},
+		{
+			name:       "update failure",
+			submodules: true,
+			gitmodules: true,
+			updateErr:  errors.New("exit status 1"),
+			wantUpdate: true,
+			wantErr:    "failed to update submodules: exit status 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			updated := false
+			gitCmdFn = func(dir string, args ...string) error {
+				switch args[0] {
+				case "worktree":
+					os.MkdirAll(worktreePath, 0755)
+					if tt.gitmodules {
+						os.WriteFile(filepath.Join(worktreePath, ".gitmodules"), []byte("[submodule \"lib\"]\n"), 0644)
+					}
+				case "submodule":
+					if dir != worktreePath || !reflect.DeepEqual(args, submoduleUpdateArgs) {
+						t.Errorf("git %v in %s, want submodule update in the worktree", args, dir)
+					}
+					updated = true
+					return tt.updateErr
+				}
+				return nil
+			}
+
+			_, err := createWorktree("feat", createOptions{submodules: tt.submodules, hookPath: "no-such-hook"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("createWorktree() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			if updated != tt.wantUpdate {
+				t.Errorf("submodules updated = %v, want %v", updated, tt.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateDir(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	// setup returns a repository whose git worktree add checks out README.md, and a skeleton directory
+	setup := func(t *testing.T) (worktreePath, skeleton string) {
+		tmpDir := t.TempDir()
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "feat")
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			os.MkdirAll(worktreePath, 0755)
+			os.WriteFile(filepath.Join(worktreePath, "README.md"), []byte("tracked"), 0644)
+			return nil
+		}
+
+		skeleton = filepath.Join(t.TempDir(), "skel")
+		os.MkdirAll(filepath.Join(skeleton, ".tools"), 0755)
+		os.WriteFile(filepath.Join(skeleton, ".tools", "lint.toml"), []byte("strict = true"), 0644)
+		os.WriteFile(filepath.Join(skeleton, "README.md"), []byte("skeleton"), 0644)
+		return worktreePath, skeleton
+	}
+
+	t.Run("skeleton files appear in the worktree", func(t *testing.T) {
+		worktreePath, skeleton := setup(t)
+
+		if _, err := createWorktree("feat", createOptions{templateDir: skeleton, hookPath: "no-such-hook"}); err != nil {
+			t.Fatalf("createWorktree() unexpected error: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(worktreePath, ".tools", "lint.toml")); string(data) != "strict = true" {
+			t.Errorf(".tools/lint.toml = %q, want it copied from the skeleton", data)
+		}
+		if data, _ := os.ReadFile(filepath.Join(worktreePath, "README.md")); string(data) != "tracked" {
+			t.Errorf("README.md = %q, want the checked out file kept", data)
+		}
+	})
+
+	t.Run("missing template dir", func(t *testing.T) {
+		setup(t)
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with a missing template dir", args)
+			return nil
+		}
+		missing := filepath.Join(t.TempDir(), "missing")
+
+		_, err := createWorktree("feat", createOptions{templateDir: missing})
+		if err == nil || err.Error() != "template directory "+missing+" does not exist" {
+			t.Errorf("createWorktree() error = %v, want missing template error", err)
+		}
+	})
+
+	t.Run("template dir is a file", func(t *testing.T) {
+		_, skeleton := setup(t)
+		file := filepath.Join(skeleton, "README.md")
+
+		_, err := createWorktree("feat", createOptions{templateDir: file})
+		if err == nil || err.Error() != "template directory "+file+" does not exist" {
+			t.Errorf("createWorktree() error = %v, want missing template error", err)
+		}
+	})
+
+	t.Run("copy failure", func(t *testing.T) {
+		worktreePath, skeleton := setup(t)
+		gitCmdFn = func(dir string, args ...string) error {
+			// Block the skeleton's .tools/ directory with a file
+			os.MkdirAll(worktreePath, 0755)
+			os.WriteFile(filepath.Join(worktreePath, ".tools"), []byte{}, 0644)
+			return nil
+		}
+
+		_, err := createWorktree("feat", createOptions{templateDir: skeleton})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to copy template "+skeleton+"/") {
+			t.Errorf("createWorktree() error = %v, want template copy failure", err)
+		}
+	})
+}
+
+func TestFileExists(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	os.WriteFile(file, nil, 0644)
+
+	if !fileExists(file) || fileExists(dir) || fileExists(filepath.Join(dir, "missing")) {
+		t.Errorf("fileExists() = %v, %v, %v; want true for the file only", fileExists(file), fileExists(dir), fileExists(filepath.Join(dir, "missing")))
+	}
+}
+
+func TestLookPathHookShell(t *testing.T) {
+	if shell, err := lookPathHookShell(""); err != nil || shell != nil {
+		t.Errorf("lookPathHookShell(\"\") = %q, %v; want direct execution", shell, err)
+	}
+	shell, err := lookPathHookShell("  bash -euo  pipefail ")
+	if want := []string{"bash", "-euo", "pipefail"}; err != nil || !reflect.DeepEqual(shell, want) {
+		t.Errorf("lookPathHookShell() = %q, %v; want %q", shell, err, want)
+	}
+	_, err = lookPathHookShell("wt-no-such-shell -e")
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid hook_shell: exec: "wt-no-such-shell": executable file not found`) {
+		t.Errorf("lookPathHookShell() error = %v, want not found", err)
+	}
+}
+
+func TestCreateHookShell(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	setup := func(t *testing.T, config string) (tmpDir, worktreePath string) {
+		tmpDir = t.TempDir()
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "feat")
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte(config), 0644)
+		os.WriteFile(filepath.Join(tmpDir, DefaultHook), []byte("echo \"$0\" > ran\n"), 0644)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				os.MkdirAll(worktreePath, 0755)
+			}
+			return nil
+		}
+		return tmpDir, worktreePath
+	}
+
+	t.Run("hook runs through hook_shell", func(t *testing.T) {
+		tmpDir, worktreePath := setup(t, "hook_shell = bash\n")
+		t.Setenv(HookEnv, "")
+
+		if _, err := createWorktree("feat", createOptions{}); err != nil {
+			t.Fatalf("createWorktree() unexpected error: %v", err)
+		}
+		got, _ := os.ReadFile(filepath.Join(worktreePath, "ran"))
+		if string(got) != filepath.Join(tmpDir, DefaultHook)+"\n" {
+			t.Errorf("hook output = %q, want it run by bash", got)
+		}
+	})
+
+	t.Run("missing shell fails before creating the worktree", func(t *testing.T) {
+		setup(t, "hook_shell = wt-no-such-shell\n")
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run with a missing hook_shell", args)
+			return nil
+		}
+
+		_, err := createWorktree("feat", createOptions{})
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid hook_shell") {
+			t.Errorf("createWorktree() error = %v, want invalid hook_shell", err)
+		}
+	})
+}
+
+func TestPrefixBranch(t *testing.T) {
+	tests := []struct {
+		prefix, name, want string
+	}{
+		{prefix: "", name: "feat", want: "feat"},
+		{prefix: "alice/", name: "feat", want: "alice/feat"},
+		{prefix: "alice/", name: "alice/feat", want: "alice/feat"},
+		{prefix: "team-", name: "ui/feat", want: "team-ui/feat"},
+	}
+	for _, tt := range tests {
+		if got := prefixBranch(tt.prefix, tt.name); got != tt.want {
+			t.Errorf("prefixBranch(%q, %q) = %q, want %q", tt.prefix, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateBranchPrefix(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	tests := []struct {
+		name       string
+		config     string
+		opts       createOptions
+		wantDir    string
+		wantBranch string
+	}{
+		{name: "feat", config: "branch_prefix = alice/\n", wantDir: "feat", wantBranch: "alice/feat"},
+		{name: "feat", config: "branch_prefix =\n", wantDir: "feat", wantBranch: "feat"},
+		{name: "alice/feat", config: "branch_prefix = alice/\n", wantDir: "alice/feat", wantBranch: "alice/feat"},
+		{name: "PROJ-1 Fix it", config: "branch_prefix = alice/\n", opts: createOptions{slug: true}, wantDir: "proj-1-fix-it", wantBranch: "alice/proj-1-fix-it"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" "+tt.config, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+			os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte(tt.config), 0644)
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			var addArgs []string
+			gitCmdFn = func(dir string, args ...string) error {
+				if args[0] == "worktree" {
+					addArgs = args
+				}
+				return nil
+			}
+
+			tt.opts.noCheckout = true
+			if _, err := createWorktree(tt.name, tt.opts); err != nil {
+				t.Fatalf("createWorktree() unexpected error: %v", err)
+			}
+			want := []string{"worktree", "add", filepath.Join(tmpDir, WorktreesDir, tt.wantDir), "-b", tt.wantBranch, "--no-checkout"}
+			if !reflect.DeepEqual(addArgs, want) {
+				t.Errorf("git worktree add args = %q, want %q", addArgs, want)
+			}
+		})
+	}
+}
+
+func TestBranchCheckedOut(t *testing.T) {
+	origListInfos := listWorktreeInfosFn
+	defer func() { listWorktreeInfosFn = origListInfos }()
+
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Path: "/repo", Branch: "main"}, {Path: "/repo/.worktrees/spike", Detached: true}}, nil
+	}
+	if !branchCheckedOut("main") {
+		t.Error("branchCheckedOut(main) = false, want true")
+	}
+	if branchCheckedOut("feat") {
+		t.Error("branchCheckedOut(feat) = true, want false")
+	}
+
+	// Without the list, the branch may be checked out, so no suggestion is made
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return nil, errors.New("git failed")
+	}
+	if !branchCheckedOut("feat") {
+		t.Error("branchCheckedOut() = false when listing fails, want true")
+	}
+}
+
+func TestAttachNameArg(t *testing.T) {
+	if got := attachNameArg("feat", "feat"); got != "" {
+		t.Errorf("attachNameArg(feat, feat) = %q, want empty", got)
+	}
+	if got := attachNameArg("Fix/CI", "fix-ci"); got != "" {
+		t.Errorf("attachNameArg(Fix/CI, fix-ci) = %q, want empty", got)
+	}
+	if got := attachNameArg("alice/feat", "feat"); got != " feat" {
+		t.Errorf("attachNameArg(alice/feat, feat) = %q, want %q", got, " feat")
+	}
+}
+
+func TestWorktreeNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		slug       bool
+		prefix     string
+		wantName   string
+		wantBranch string
+		wantErrMsg string
+	}{
+		{name: "unchanged", input: "fix-login", wantName: "fix-login", wantBranch: "fix-login"},
+		{name: "branch prefix", input: "fix-login", prefix: "alice/", wantName: "fix-login", wantBranch: "alice/fix-login"},
+		{name: "slug", input: "PROJ-123 Fix login", slug: true, wantName: "proj-123-fix-login", wantBranch: "proj-123-fix-login"},
+		{name: "slug then prefix", input: "Fix Login", slug: true, prefix: "alice/", wantName: "fix-login", wantBranch: "alice/fix-login"},
+		{name: "nothing to slug", input: "!!!", slug: true, wantErrMsg: `--slug: "!!!" has no letters or digits to name the worktree`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, branch, err := worktreeNames(tt.input, tt.slug, tt.prefix)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("worktreeNames() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || name != tt.wantName || branch != tt.wantBranch {
+				t.Errorf("worktreeNames() = %q, %q, %v; want %q, %q", name, branch, err, tt.wantName, tt.wantBranch)
+			}
+		})
+	}
+}
+
+func TestReportNameChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		dir    string
+		branch string
+		want   string
+	}{
+		{name: "unchanged", input: "feat", dir: "feat", branch: "feat", want: ""},
+		{name: "branch prefix", input: "fix-login", dir: "fix-login", branch: "alice/fix-login", want: "Using branch 'alice/fix-login' in directory 'fix-login' for \"fix-login\"\n"},
+		{name: "slug", input: "Fix Login", dir: "fix-login", branch: "fix-login", want: "Using branch 'fix-login' in directory 'fix-login' for \"Fix Login\"\n"},
+		{name: "without a branch", input: "Release 2.0", dir: "release-2-0", want: "Using directory 'release-2-0' for \"Release 2.0\"\n"},
+		{name: "without a branch, unchanged", input: "release", dir: "release", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			reportNameChange(&buf, tt.input, tt.dir, tt.branch)
+			if buf.String() != tt.want {
+				t.Errorf("reportNameChange() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "PROJ-123 Fix login", want: "proj-123-fix-login"},
+		{in: "Fix   Login Page", want: "fix-login-page"},
+		{in: "  --[BUG] cannot save: \"draft\" (v2)!!  ", want: "bug-cannot-save-draft-v2"},
+		{in: "feature/new_ui.v2", want: "feature-new-ui-v2"},
+		{in: "Ünïcode café", want: "n-code-caf"},
+		{in: "proj-123-fix-login", want: "proj-123-fix-login"},
+		{in: "!!!", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := slugify(tt.in)
+			if got != tt.want {
+				t.Errorf("slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if again := slugify(got); again != got {
+				t.Errorf("slugify(%q) = %q, want slugs unchanged", got, again)
+			}
+		})
+	}
+}
+
+func TestCreateSlug(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+	}()
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	t.Run("names the worktree and branch after the slug", func(t *testing.T) {
+		var addArgs []string
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				addArgs = args
+			}
+			return nil
+		}
+
+		path, err := createWorktree("PROJ-123 Fix login", createOptions{slug: true, noCheckout: true})
+		if err != nil {
+			t.Fatalf("createWorktree() unexpected error: %v", err)
+		}
+		wantPath := filepath.Join(tmpDir, WorktreesDir, "proj-123-fix-login")
+		if path != wantPath {
+			t.Errorf("createWorktree() = %q, want %q", path, wantPath)
+		}
+		if want := []string{"worktree", "add", wantPath, "-b", "proj-123-fix-login", "--no-checkout"}; !reflect.DeepEqual(addArgs, want) {
+			t.Errorf("git worktree add args = %q, want %q", addArgs, want)
+		}
+	})
+
+	t.Run("nothing left to slug", func(t *testing.T) {
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run without a name", args)
+			return nil
+		}
+
+		_, err := createWorktree("???", createOptions{slug: true})
+		if err == nil || err.Error() != `--slug: "???" has no letters or digits to name the worktree` {
+			t.Errorf("createWorktree() error = %v, want empty slug error", err)
+		}
+	})
+}
+
+func TestMergeCopyDirs(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured []string
+		extra      []string
+		want       []string
+	}{
+		{name: "config only", configured: []string{".vscode"}, want: []string{".vscode"}},
+		{name: "cli only", extra: []string{".idea"}, want: []string{".idea"}},
+		{name: "union keeps config first", configured: []string{".vscode", "tools"}, extra: []string{".idea"}, want: []string{".vscode", "tools", ".idea"}},
+		{name: "duplicates kept once", configured: []string{".vscode", ".vscode/"}, extra: []string{"./.vscode", ".idea", ".idea"}, want: []string{".vscode", ".idea"}},
+		{name: "neither", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeCopyDirs(tt.configured, tt.extra)
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeCopyDirs() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateHookPrecedence(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origUserConfigDir := userConfigDirFn
+	origGitConfig := gitConfigFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		userConfigDirFn = origUserConfigDir
+		gitConfigFn = origGitConfig
+		os.Stdout = origStdout
+	}()
+
+	// setup creates a repository whose hooks each touch a marker file named after the hook
+	setup := func(t *testing.T, repoHook bool, globalConfig string) (worktreePath, configDir string) {
+		tmpDir := t.TempDir()
+		configDir = t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir), 0755)
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "test-branch")
+
+		writeHook := func(path, marker string) {
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte("#!/bin/sh\ntouch "+marker+"\n"), 0755)
+		}
+		writeHook(filepath.Join(tmpDir, "flag-hook.sh"), "flag-ran")
+		writeHook(filepath.Join(tmpDir, "env-hook.sh"), "env-ran")
+		writeHook(filepath.Join(tmpDir, "git-hook.sh"), "git-config-ran")
+		writeHook(filepath.Join(configDir, "wt", "template.sh"), "template-ran")
+		if repoHook {
+			writeHook(filepath.Join(tmpDir, DefaultHook), "repo-ran")
+		}
+		if globalConfig != "" {
+			os.WriteFile(filepath.Join(configDir, GlobalConfigFile), []byte(globalConfig), 0644)
+		}
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			os.MkdirAll(worktreePath, 0755)
+			return nil
+		}
+		userConfigDirFn = func() (string, error) {
+			return configDir, nil
+		}
+		_, w, _ := os.Pipe()
+		os.Stdout = w
+		t.Cleanup(func() {
+			w.Close()
+			os.Stdout = origStdout
+		})
+		return worktreePath, configDir
+	}
+
+	ran := func(worktreePath string) []string {
+		var markers []string
+		for _, marker := range []string{"flag-ran", "env-ran", "git-config-ran", "repo-ran", "template-ran"} {
+			if _, err := os.Stat(filepath.Join(worktreePath, marker)); err == nil {
+				markers = append(markers, marker)
+			}
+		}
+		return markers
+	}
+
+	tests := []struct {
+		name      string
+		hookFlag  string
+		hookEnv   string
+		gitConfig string // value of git config wt.hook
+		repoHook  bool
+		global    string
+		want      string
+	}{
+		{name: "--hook beats repo and global hooks", hookFlag: "flag-hook.sh", repoHook: true, global: "hook_template = template.sh\n", want: "flag-ran"},
+		{name: "--hook beats WT_HOOK", hookFlag: "flag-hook.sh", hookEnv: "env-hook.sh", want: "flag-ran"},
+		{name: "--hook beats git config", hookFlag: "flag-hook.sh", gitConfig: "git-hook.sh", want: "flag-ran"},
+		{name: "WT_HOOK beats repo and global hooks", hookEnv: "env-hook.sh", repoHook: true, global: "hook_template = template.sh\n", want: "env-ran"},
+		{name: "WT_HOOK beats git config", hookEnv: "env-hook.sh", gitConfig: "git-hook.sh", want: "env-ran"},
+		{name: "git config beats repo and global hooks", gitConfig: "git-hook.sh", repoHook: true, global: "hook_template = template.sh\n", want: "git-config-ran"},
+		{name: "missing WT_HOOK runs no hook", hookEnv: "missing.sh", repoHook: true},
+		{name: "repo hook beats global template", repoHook: true, global: "hook_template = template.sh\n", want: "repo-ran"},
+		{name: "global template without repo hook", global: "hook_template = template.sh\n", want: "template-ran"},
+		{name: "missing global template is a no-op", global: "hook_template = missing.sh\n"},
+		{name: "no global config", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worktreePath, _ := setup(t, tt.repoHook, tt.global)
+			t.Setenv(HookEnv, tt.hookEnv)
+			gitConfigFn = func(dir, key string) string {
+				if key != GitConfigHookKey {
+					t.Errorf("git config read %s, want %s", key, GitConfigHookKey)
+				}
+				return tt.gitConfig
+			}
+
+			if err := create("test-branch", createOptions{hookPath: tt.hookFlag}); err != nil {
+				t.Fatalf("create() unexpected error: %v", err)
+			}
+			got := strings.Join(ran(worktreePath), ",")
+			if got != tt.want {
+				t.Errorf("hooks run = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("user config dir unknown", func(t *testing.T) {
+		worktreePath, _ := setup(t, false, "")
+		userConfigDirFn = func() (string, error) {
+			return "", errors.New("no config dir")
+		}
+
+		if err := create("test-branch", createOptions{}); err != nil {
+			t.Fatalf("create() unexpected error: %v", err)
+		}
+		if got := ran(worktreePath); len(got) != 0 {
+			t.Errorf("hooks run = %v, want none", got)
+		}
+	})
+
+	t.Run("unreadable global config fails before creating the worktree", func(t *testing.T) {
+		_, configDir := setup(t, false, "")
+		os.MkdirAll(filepath.Join(configDir, GlobalConfigFile), 0755)
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Error("worktree should not be created when the global config cannot be read")
+			return nil
+		}
+
+		err := create("test-branch", createOptions{})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read "+filepath.Join(configDir, GlobalConfigFile)) {
+			t.Errorf("create() error = %v, want global config read error", err)
+		}
+	})
 }
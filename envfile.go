@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envKeyPattern matches valid environment variable names
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadEnvFile reads KEY=VALUE lines from path, ignoring blank lines and # comments
+// The result is in os/exec's "KEY=VALUE" form
+func loadEnvFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	var env []string
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE, got %q", path, i+1, line)
+		}
+		env = append(env, key+"="+strings.TrimSpace(value))
+	}
+	return env, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "key value pairs",
+			content: "PORT=3000\nDB_NAME = dev\n",
+			want:    []string{"PORT=3000", "DB_NAME=dev"},
+		},
+		{
+			name:    "comments and blank lines ignored",
+			content: "# settings\n\nPORT=3000\n   \n# done\n",
+			want:    []string{"PORT=3000"},
+		},
+		{
+			name:    "empty value and value with equals",
+			content: "EMPTY=\nURL=postgres://u@h/db?sslmode=disable\n",
+			want:    []string{"EMPTY=", "URL=postgres://u@h/db?sslmode=disable"},
+		},
+		{
+			name:    "missing equals",
+			content: "PORT=3000\nnot a pair\n",
+			wantErr: `:2: expected KEY=VALUE, got "not a pair"`,
+		},
+		{
+			name:    "invalid key",
+			content: "1BAD=x\n",
+			wantErr: `:1: expected KEY=VALUE, got "1BAD=x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			os.WriteFile(path, []byte(tt.content), 0644)
+
+			got, err := loadEnvFile(path)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != path+tt.wantErr {
+					t.Errorf("loadEnvFile() error = %v, want %q", err, path+tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadEnvFile() unexpected error: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("loadEnvFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := loadEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read env file:") {
+			t.Errorf("loadEnvFile() error = %v, want read error", err)
+		}
+	})
+}
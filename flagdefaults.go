@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// flagDefaults maps command.flag config keys, such as create.base, to the value used when the flag is not given
+type flagDefaults map[string]string
+
+// set records value as the default for name; an empty value removes the default
+func (d *flagDefaults) set(name, value string) {
+	if value == "" {
+		delete(*d, name)
+		return
+	}
+	if *d == nil {
+		*d = flagDefaults{}
+	}
+	(*d)[name] = value
+}
+
+// names returns the keys of the defaults that are set, sorted
+func (d flagDefaults) names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// flagDefaultKey returns the config key for the default of a command's flag, named command.flag as in create.base
+// Only flags that take a value can have a default, so a flag given on the command line can always override it
+func flagDefaultKey(name string) (configKey, error) {
+	cmd, flag, _ := strings.Cut(name, ".")
+	for _, spec := range commandFlags[cmd] {
+		if spec.name != "--"+flag {
+			continue
+		}
+		if spec.arg == "" {
+			return configKey{}, fmt.Errorf("%s cannot have a default: %s takes no value", name, spec.name)
+		}
+		return configKey{
+			name: name,
+			get:  func(c *Config) string { return c.FlagDefaults[name] },
+			set: func(c *Config, value string) error {
+				c.FlagDefaults.set(name, value)
+				return nil
+			},
+		}, nil
+	}
+	return configKey{}, fmt.Errorf("unknown config key: %s", name)
+}
+
+// withFlagDefaults adds the configured default of each flag missing from the command line and parses it again
+// Defaults in the repository's .wtconfig take precedence over the per-user config's
+func withFlagDefaults(args []string, a *cliArgs) (*cliArgs, error) {
+	var missing []flagSpec
+	for _, spec := range commandFlags[a.cmd] {
+		if spec.arg != "" && !a.has(spec.name) {
+			missing = append(missing, spec)
+		}
+	}
+	if len(missing) == 0 {
+		return a, nil
+	}
+
+	// Config errors are left to the commands, which report them where they matter
+	defaults := flagDefaults{}
+	if path, err := globalConfigPath(); err == nil {
+		if cfg, _, err := loadGlobalConfig(path); err == nil {
+			for name, value := range cfg.FlagDefaults {
+				defaults[name] = value
+			}
+		}
+	}
+	if wm, err := NewWorktreeManager(); err == nil {
+		for name, value := range wm.Config().FlagDefaults {
+			defaults[name] = value
+		}
+	}
+
+	// A default gives way to whatever it conflicts with on the command line, such as create.base to --attach
+	// or remove.older-than to a worktree name, so the defaults are added one at a time
+	merged, extra := a, []string{}
+	for _, spec := range missing {
+		value, ok := defaults[a.cmd+"."+strings.TrimPrefix(spec.name, "--")]
+		if !ok {
+			continue
+		}
+		try := append(append([]string{}, extra...), spec.name, value)
+		parsed, err := parseArgs(append(append([]string{a.cmd}, try...), args[1:]...))
+		if err == nil {
+			merged, extra = parsed, try
+			continue
+		}
+		if !validFlagDefault(a.cmd, spec.name, value) {
+			return nil, fmt.Errorf("%w (with %s %s from config)", err, spec.name, value)
+		}
+	}
+	return merged, nil
+}
+
+// validFlagDefault reports whether value is accepted for the command's flag on its own, with or without
+// a name, so a default that only fails next to other arguments can be told apart from a bad value
+func validFlagDefault(cmd, flag, value string) bool {
+	for _, args := range [][]string{{cmd, flag, value}, {cmd, flag, value, "name"}} {
+		if _, err := parseArgs(args); err == nil {
+			return true
+		}
+	}
+	return false
+}
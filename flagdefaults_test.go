@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFlagDefaultKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		wantErrMsg string
+	}{
+		{name: "create.base"},
+		{name: "list.limit"},
+		{name: "create.push", wantErrMsg: "create.push cannot have a default: --push takes no value"},
+		{name: "create.colour", wantErrMsg: "unknown config key: create.colour"},
+		{name: "gha.interval", wantErrMsg: "unknown config key: gha.interval"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := flagDefaultKey(tt.name)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("flagDefaultKey() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("flagDefaultKey() unexpected error: %v", err)
+			}
+
+			cfg := defaultConfig()
+			key.set(cfg, "main")
+			if got := key.get(cfg); got != "main" {
+				t.Errorf("get() after set = %q, want %q", got, "main")
+			}
+			key.set(cfg, "")
+			if _, ok := cfg.FlagDefaults[tt.name]; ok {
+				t.Errorf("set() with an empty value kept the default: %v", cfg.FlagDefaults)
+			}
+		})
+	}
+}
+
+func TestFlagDefaultsNames(t *testing.T) {
+	d := flagDefaults{"list.limit": "5", "create.base": "main", "jump.relative-to": "~"}
+	want := []string{"create.base", "jump.relative-to", "list.limit"}
+	if got := d.names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("names() = %v, want %v", got, want)
+	}
+}
+
+func TestWithFlagDefaults(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origUserConfigDir := userConfigDirFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		userConfigDirFn = origUserConfigDir
+	}()
+
+	root := t.TempDir()
+	userDir := t.TempDir()
+	os.MkdirAll(filepath.Join(userDir, "wt"), 0755)
+	gitMainRootFn = func() (string, error) {
+		return root, nil
+	}
+	userConfigDirFn = func() (string, error) {
+		return userDir, nil
+	}
+
+	tests := []struct {
+		name       string
+		repo       string // .wtconfig contents
+		global     string // per-user config contents
+		args       []string
+		wantFlags  map[string][]string
+		wantErrMsg string
+	}{
+		{
+			name:      "repo default is used when the flag is absent",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "feat"},
+			wantFlags: map[string][]string{"--base": {"main"}},
+		},
+		{
+			name:      "command line overrides the default",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--base", "origin/dev", "feat"},
+			wantFlags: map[string][]string{"--base": {"origin/dev"}},
+		},
+		{
+			name:      "repo default overrides the global default",
+			repo:      "create.base = main\n",
+			global:    "create.base = develop\ncreate.on-conflict = checkout\n",
+			args:      []string{"create", "feat"},
+			wantFlags: map[string][]string{"--base": {"main"}, "--on-conflict": {"checkout"}},
+		},
+		{
+			name:      "defaults of other commands are not used",
+			repo:      "create.base = main\n",
+			args:      []string{"list", "--check"},
+			wantFlags: map[string][]string{"--check": {""}},
+		},
+		{
+			name:      "boolean flags are left alone",
+			args:      []string{"list", "--check"},
+			wantFlags: map[string][]string{"--check": {""}},
+		},
+		{
+			name:      "command without value flags",
+			repo:      "create.base = main\n",
+			args:      []string{"version"},
+			wantFlags: map[string][]string{},
+		},
+		{
+			name:      "base default gives way to --attach",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--attach", "fix-ci", "feat"},
+			wantFlags: map[string][]string{"--attach": {"fix-ci"}},
+		},
+		{
+			name:      "base default gives way to --from-pr",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--from-pr", "12", "feat"},
+			wantFlags: map[string][]string{"--from-pr": {"12"}},
+		},
+		{
+			name:      "base default gives way to --detach",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--detach", "v1.0", "feat"},
+			wantFlags: map[string][]string{"--detach": {"v1.0"}},
+		},
+		{
+			name:      "base default gives way to --after",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--after", "base-feature", "feat"},
+			wantFlags: map[string][]string{"--after": {"base-feature"}},
+		},
+		{
+			name:      "base default gives way to --branch-from-current",
+			repo:      "create.base = main\n",
+			args:      []string{"create", "--branch-from-current", "feat"},
+			wantFlags: map[string][]string{"--branch-from-current": {""}},
+		},
+		{
+			name:      "other defaults still apply next to a conflicting one",
+			repo:      "create.base = main\ncreate.on-conflict = checkout\n",
+			args:      []string{"create", "--branch-from-current", "feat"},
+			wantFlags: map[string][]string{"--branch-from-current": {""}, "--on-conflict": {"checkout"}},
+		},
+		{
+			name:      "older-than default gives way to a worktree name",
+			repo:      "remove.older-than = 30d\n",
+			args:      []string{"remove", "feat"},
+			wantFlags: map[string][]string{},
+		},
+		{
+			name:      "older-than default applies without a name",
+			repo:      "remove.older-than = 30d\n",
+			args:      []string{"remove"},
+			wantFlags: map[string][]string{"--older-than": {"30d"}},
+		},
+		{
+			name:      "output default gives way to --null",
+			repo:      "list.output = json\n",
+			args:      []string{"list", "--null"},
+			wantFlags: map[string][]string{"--null": {""}},
+		},
+		{
+			name:       "invalid default value",
+			repo:       "list.output = yaml\n",
+			args:       []string{"list"},
+			wantErrMsg: `invalid --output "yaml" (use text, json or null) (with --output yaml from config)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(filepath.Join(root, ConfigFile), []byte(tt.repo), 0644)
+			os.WriteFile(filepath.Join(userDir, GlobalConfigFile), []byte(tt.global), 0644)
+
+			a, err := parseArgs(tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs() unexpected error: %v", err)
+			}
+			got, err := withFlagDefaults(tt.args, a)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("withFlagDefaults() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("withFlagDefaults() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got.flags, tt.wantFlags) {
+				t.Errorf("withFlagDefaults() flags = %v, want %v", got.flags, tt.wantFlags)
+			}
+			if !reflect.DeepEqual(got.args, a.args) {
+				t.Errorf("withFlagDefaults() args = %v, want %v", got.args, a.args)
+			}
+		})
+	}
+
+	t.Run("outside a repository and without a user config directory", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		userConfigDirFn = func() (string, error) {
+			return "", errors.New("no home")
+		}
+
+		args := []string{"list", "--limit", "3"}
+		a, _ := parseArgs(args)
+		got, err := withFlagDefaults(args, a)
+		if err != nil || got != a {
+			t.Errorf("withFlagDefaults() = %v, %v; want the parsed args unchanged", got, err)
+		}
+	})
+
+	t.Run("unreadable user config", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		userConfigDirFn = func() (string, error) {
+			return userDir, nil
+		}
+		// A directory in place of the file cannot be read
+		os.Remove(filepath.Join(userDir, GlobalConfigFile))
+		os.MkdirAll(filepath.Join(userDir, GlobalConfigFile), 0755)
+
+		args := []string{"list"}
+		a, _ := parseArgs(args)
+		got, err := withFlagDefaults(args, a)
+		if err != nil || got != a {
+			t.Errorf("withFlagDefaults() = %v, %v; want the parsed args unchanged", got, err)
+		}
+	})
+}
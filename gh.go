@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ghOutputFn is replaceable for testing
+var ghOutputFn = defaultGhOutput
+
+// defaultGhOutput runs the GitHub CLI and returns its trimmed stdout, adding its stderr to errors
+func defaultGhOutput(args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("gh", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// pullRequest is the part of a GitHub pull request that create needs to check it out
+type pullRequest struct {
+	branch string // name of the branch the pull request was opened from
+	fork   bool   // the branch is in a fork, so origin does not have it
+}
+
+// ghPullRequest looks up the head branch of pull request number and whether it comes from a fork
+func ghPullRequest(number string) (pullRequest, error) {
+	out, err := ghOutputFn("pr", "view", number, "--json", "headRefName,isCrossRepository", "--jq", `.headRefName + " " + (.isCrossRepository | tostring)`)
+	if err != nil {
+		return pullRequest{}, fmt.Errorf("failed to look up pull request #%s: %w", number, err)
+	}
+	branch, fork, _ := strings.Cut(out, " ")
+	if branch == "" {
+		return pullRequest{}, fmt.Errorf("pull request #%s has no head branch", number)
+	}
+	return pullRequest{branch: branch, fork: fork == "true"}, nil
+}
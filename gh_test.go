@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultGhOutput(t *testing.T) {
+	// A stand-in gh that echoes its arguments, or fails for "fail" with or without stderr
+	bin := t.TempDir()
+	script := "#!/bin/sh\ncase \"$1\" in\nfail) echo 'not found' >&2; exit 1;;\nquiet) exit 1;;\nesac\necho \"  $*  \"\n"
+	os.WriteFile(filepath.Join(bin, "gh"), []byte(script), 0755)
+	t.Setenv("PATH", bin)
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "trims output", args: []string{"pr", "view"}, want: "pr view"},
+		{name: "failure includes stderr", args: []string{"fail"}, wantErr: "exit status 1: not found"},
+		{name: "failure without stderr", args: []string{"quiet"}, wantErr: "exit status 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defaultGhOutput(tt.args...)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("defaultGhOutput() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("defaultGhOutput() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGhPullRequest(t *testing.T) {
+	origGhOutput := ghOutputFn
+	defer func() {
+		ghOutputFn = origGhOutput
+	}()
+
+	tests := []struct {
+		name    string
+		out     string
+		err     error
+		want    pullRequest
+		wantErr string
+	}{
+		{name: "head branch", out: "feature/login false", want: pullRequest{branch: "feature/login"}},
+		{name: "from a fork", out: "main true", want: pullRequest{branch: "main", fork: true}},
+		{name: "no such pull request", err: errors.New("exit status 1: no pull requests found"), wantErr: "failed to look up pull request #42: exit status 1: no pull requests found"},
+		{name: "empty head branch", out: " false", wantErr: "pull request #42 has no head branch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			ghOutputFn = func(args ...string) (string, error) {
+				gotArgs = args
+				return tt.out, tt.err
+			}
+
+			got, err := ghPullRequest("42")
+			if want := `pr view 42 --json headRefName,isCrossRepository --jq .headRefName + " " + (.isCrossRepository | tostring)`; strings.Join(gotArgs, " ") != want {
+				t.Errorf("gh args = %q, want %q", strings.Join(gotArgs, " "), want)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ghPullRequest() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ghPullRequest() = %+v, %v; want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
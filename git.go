@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // Function variables for testing
@@ -13,9 +17,30 @@ var (
 	gitRootFn     = defaultGitRoot
 	gitMainRootFn = defaultGitMainRoot
 	gitCmdFn      = defaultGitCmd
+	gitOutputFn   = defaultGitOutput
+	gitConfigFn   = defaultGitConfig
+	enclosingFn   = defaultEnclosingRepo
 	filepathAbsFn = filepath.Abs
+	sleepFn       = time.Sleep
 )
 
+// gitLockAttempts bounds how often gitCmdRetryLocked runs a command that fails on a held lock
+const gitLockAttempts = 4
+
+// gitLockRetryDelay is the delay before the first retry; it doubles after each attempt
+const gitLockRetryDelay = 100 * time.Millisecond
+
+// gitCmdError is returned by defaultGitCmd and keeps the command's stderr for inspection
+// Error() omits stderr because it has already been streamed to the user
+type gitCmdError struct {
+	err    error
+	stderr string
+}
+
+func (e *gitCmdError) Error() string { return e.err.Error() }
+
+func (e *gitCmdError) Unwrap() error { return e.err }
+
 func gitRoot() (string, error) {
 	return gitRootFn()
 }
@@ -28,6 +53,97 @@ func gitCmd(dir string, args ...string) error {
 	return gitCmdFn(dir, args...)
 }
 
+// gitCmdRetryLocked runs gitCmd, retrying with backoff while another git process holds a lock
+func gitCmdRetryLocked(dir string, args ...string) error {
+	delay := gitLockRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := gitCmd(dir, args...)
+		if err == nil || attempt == gitLockAttempts || !isGitLockError(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "git lock is held by another process, retrying in %s...\n", delay)
+		sleepFn(delay)
+		delay *= 2
+	}
+}
+
+// isGitLockError reports whether err is a git failure caused by a lock file another process holds
+func isGitLockError(err error) bool {
+	var cmdErr *gitCmdError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return strings.Contains(cmdErr.stderr, ".lock': File exists") ||
+		strings.Contains(cmdErr.stderr, "could not lock")
+}
+
+// gitOutput runs a git command in dir and returns its trimmed stdout
+func gitOutput(dir string, args ...string) (string, error) {
+	return gitOutputFn(dir, args...)
+}
+
+// gitConfigValue returns the value of key in the git config seen from dir, or "" when it is unset
+func gitConfigValue(dir, key string) string {
+	return gitConfigFn(dir, key)
+}
+
+// defaultGitConfig reads key with git config --get, which fails when the key is unset
+func defaultGitConfig(dir, key string) string {
+	value, _ := defaultGitOutput(dir, "config", "--get", key)
+	return value
+}
+
+// gitRefExists reports whether ref resolves to a commit in the repository at dir
+func gitRefExists(dir, ref string) bool {
+	_, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// gitResolveCommit resolves a ref expression such as HEAD~3, origin/main~1 or @{upstream} to a commit SHA
+func gitResolveCommit(dir, ref string) (string, error) {
+	sha, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%s does not resolve to a commit", ref)
+	}
+	return sha, nil
+}
+
+// gitDefaultBranch returns the repository's default branch
+// It prefers the branch origin/HEAD points at, falling back to a local main or master branch
+func gitDefaultBranch(dir string) (string, error) {
+	if ref, err := gitOutput(dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, "origin/"), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if gitRefExists(dir, "refs/heads/"+branch) {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the default branch")
+}
+
+// enclosingRepo returns the root of the repository that records the one at root as a submodule or
+// embedded repository, or "" when root is not nested in another repository
+func enclosingRepo(root string) string {
+	return enclosingFn(root)
+}
+
+// defaultEnclosingRepo asks the repository containing root's parent directory whether root is a gitlink in its index
+// A repository that merely sits in another's work tree, like any project below a dotfiles repository in $HOME, is not nested
+func defaultEnclosingRepo(root string) string {
+	outer, err := defaultGitOutput(filepath.Dir(root), "rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	// Both paths are absolute, so Rel cannot fail
+	rel, _ := filepath.Rel(outer, root)
+	entry, err := defaultGitOutput(outer, "ls-files", "--stage", "--", rel)
+	if err != nil || !strings.HasPrefix(entry, "160000 ") {
+		return ""
+	}
+	return outer
+}
+
 func defaultGitRoot() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	out, err := cmd.Output()
@@ -37,25 +153,68 @@ func defaultGitRoot() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// defaultGitMainRoot returns the root of the main worktree, normally the parent of the common .git directory
+// A git directory moved elsewhere (GIT_DIR, --separate-git-dir) says nothing about the work tree, so git
+// is asked for it: from the main worktree git always knows it, from the git directory only through
+// core.worktree. If git cannot tell, as in a bare repository, the parent directory is used after all
 func defaultGitMainRoot() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--git-common-dir")
-	out, err := cmd.Output()
-	if err != nil {
+	out, err := gitOutput("", "rev-parse", "--git-common-dir", "--git-dir")
+	dirs := strings.Split(out, "\n")
+	if err != nil || len(dirs) != 2 {
 		return "", fmt.Errorf("not in a git repository")
 	}
-	gitDir := strings.TrimSpace(string(out))
-	// gitDir is the .git directory (or path to it), parent is repo root
-	absGitDir, err := filepathAbsFn(gitDir)
+	commonDir, err := filepathAbsFn(dirs[0])
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve git directory path")
 	}
-	return filepath.Dir(absGitDir), nil
+	if filepath.Base(commonDir) == ".git" {
+		return filepath.Dir(commonDir), nil
+	}
+
+	// Outside the main worktree the git directory is a per-worktree one below the common directory
+	// Resolving the common directory succeeded, so resolving the git directory cannot fail
+	dir := ""
+	if gitDir, _ := filepathAbsFn(dirs[1]); gitDir != commonDir {
+		dir = commonDir
+	}
+	if root, err := gitOutput(dir, "rev-parse", "--show-toplevel"); err == nil {
+		return root, nil
+	}
+	return filepath.Dir(commonDir), nil
 }
 
 func defaultGitCmd(dir string, args ...string) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = dir
-	cmd.Stdout = os.Stderr // Redirect to stderr to keep stdout clean for directory path
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	// Forward to stderr to keep stdout clean for directory path
+	if stderr, err := streamCmd(cmd, os.Stderr); err != nil {
+		return &gitCmdError{err: err, stderr: stderr}
+	}
+	return nil
+}
+
+// streamCmd runs cmd, forwarding its stdout and stderr to out as they are written rather than
+// when the command exits, and returns the command's stderr for inspecting failures
+// Unless out is an *os.File, it must be safe for concurrent writes from both streams
+func streamCmd(cmd *exec.Cmd, out io.Writer) (string, error) {
+	var stderr bytes.Buffer
+	cmd.Stdout = out
+	cmd.Stderr = io.MultiWriter(out, &stderr)
+	err := cmd.Run()
+	return stderr.String(), err
+}
+
+func defaultGitOutput(dir string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
 }
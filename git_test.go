@@ -1,9 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGitRoot(t *testing.T) {
@@ -163,6 +171,83 @@ func TestDefaultGitMainRoot(t *testing.T) {
 	})
 }
 
+func TestDefaultGitMainRootRelocated(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitOutputFn = origGitOutput
+	}()
+
+	tests := []struct {
+		name      string
+		dirs      string            // output of rev-parse --git-common-dir --git-dir
+		toplevels map[string]string // rev-parse --show-toplevel output by directory; missing ones fail
+		want      string
+	}{
+		{
+			name: "standard repository",
+			dirs: ".git\n.git",
+			want: "{cwd}",
+		},
+		{
+			name: "linked worktree",
+			dirs: "/repo/.git\n/repo/.git/worktrees/feat",
+			want: "/repo",
+		},
+		{
+			name:      "relocated git directory from the main worktree",
+			dirs:      "/store/repo.git\n/store/repo.git",
+			toplevels: map[string]string{"": "/work/repo"},
+			want:      "/work/repo",
+		},
+		{
+			name:      "relocated git directory with core.worktree from a linked worktree",
+			dirs:      "/store/repo.git\n/store/repo.git/worktrees/feat",
+			toplevels: map[string]string{"/store/repo.git": "/work/repo"},
+			want:      "/work/repo",
+		},
+		{
+			name: "relocated git directory git cannot place",
+			dirs: "/store/repo.git\n/store/repo.git/worktrees/feat",
+			want: "/store",
+		},
+		{
+			name: "bare repository",
+			dirs: ".\n.",
+			want: "{parent}",
+		},
+	}
+	cwd, _ := os.Getwd()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitOutputFn = func(dir string, args ...string) (string, error) {
+				switch strings.Join(args, " ") {
+				case "rev-parse --git-common-dir --git-dir":
+					return tt.dirs, nil
+				case "rev-parse --show-toplevel":
+					if root, ok := tt.toplevels[dir]; ok {
+						return root, nil
+					}
+				}
+				return "", errors.New("exit status 128")
+			}
+
+			want := strings.NewReplacer("{cwd}", cwd, "{parent}", filepath.Dir(cwd)).Replace(tt.want)
+			if root, err := defaultGitMainRoot(); err != nil || root != want {
+				t.Errorf("defaultGitMainRoot() = %q, %v; want %q", root, err, want)
+			}
+		})
+	}
+
+	t.Run("unexpected output", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return ".git", nil
+		}
+		if _, err := defaultGitMainRoot(); err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("defaultGitMainRoot() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
 func TestDefaultGitCmd(t *testing.T) {
 	t.Run("successful command", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -189,5 +274,349 @@ func TestDefaultGitCmd(t *testing.T) {
 		if err == nil {
 			t.Error("defaultGitCmd() expected error for invalid command")
 		}
+		var cmdErr *gitCmdError
+		if !errors.As(err, &cmdErr) || !strings.Contains(cmdErr.stderr, "invalid-command-xyz") {
+			t.Errorf("defaultGitCmd() error = %#v, want gitCmdError with captured stderr", err)
+		}
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("defaultGitCmd() error = %v, want it to wrap *exec.ExitError", err)
+		}
+		if strings.Contains(err.Error(), "invalid-command-xyz") {
+			t.Errorf("defaultGitCmd() error message %q should not repeat stderr", err.Error())
+		}
+	})
+}
+
+func TestGitCmdRetryLocked(t *testing.T) {
+	origGitCmd := gitCmdFn
+	origSleep := sleepFn
+	defer func() {
+		gitCmdFn = origGitCmd
+		sleepFn = origSleep
+	}()
+
+	lockErr := &gitCmdError{
+		err:    errors.New("exit status 128"),
+		stderr: "fatal: Unable to create '/repo/.git/index.lock': File exists.\n",
+	}
+
+	tests := []struct {
+		name       string
+		failures   int   // number of leading failures
+		failErr    error // error returned for each failure
+		wantCalls  int
+		wantSleeps []time.Duration
+		wantErr    bool
+	}{
+		{"succeeds first time", 0, nil, 1, nil, false},
+		{"lock error twice then succeeds", 2, lockErr, 3, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, false},
+		{"non-retryable error fails immediately", 1, &gitCmdError{err: errors.New("exit status 128"), stderr: "fatal: '.worktrees/x' already exists\n"}, 1, nil, true},
+		{"plain error fails immediately", 1, errors.New("boom"), 1, nil, true},
+		{"gives up after max attempts", 10, lockErr, gitLockAttempts, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			gitCmdFn = func(dir string, args ...string) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failErr
+				}
+				return nil
+			}
+			var sleeps []time.Duration
+			sleepFn = func(d time.Duration) {
+				sleeps = append(sleeps, d)
+			}
+
+			err := gitCmdRetryLocked("/repo", "worktree", "add")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("gitCmdRetryLocked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("gitCmdRetryLocked() ran git %d times, want %d", calls, tt.wantCalls)
+			}
+			if len(sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("gitCmdRetryLocked() slept %v, want %v", sleeps, tt.wantSleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.wantSleeps[i] {
+					t.Errorf("sleep %d = %v, want %v", i, sleeps[i], tt.wantSleeps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIsGitLockError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"index lock", &gitCmdError{err: errors.New("exit status 128"), stderr: "fatal: Unable to create '/r/.git/index.lock': File exists."}, true},
+		{"config lock", &gitCmdError{err: errors.New("exit status 255"), stderr: "error: could not lock config file .git/config: File exists"}, true},
+		{"existing worktree", &gitCmdError{err: errors.New("exit status 128"), stderr: "fatal: '/r/.worktrees/x' already exists"}, false},
+		{"wrapped lock error", fmt.Errorf("wrapped: %w", &gitCmdError{err: errors.New("exit status 128"), stderr: "could not lock ref"}), true},
+		{"other error type", errors.New("could not lock"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isGitLockError(tt.err); got != tt.want {
+				t.Errorf("isGitLockError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitOutput(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitOutputFn = origGitOutput
+	}()
+
+	t.Run("delegates to gitOutputFn", func(t *testing.T) {
+		var capturedDir string
+		var capturedArgs []string
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			capturedDir = dir
+			capturedArgs = args
+			return "output", nil
+		}
+
+		out, err := gitOutput("/test/dir", "rev-parse", "HEAD")
+		if err != nil || out != "output" {
+			t.Errorf("gitOutput() = %q, %v; want %q, nil", out, err, "output")
+		}
+		if capturedDir != "/test/dir" || len(capturedArgs) != 2 || capturedArgs[0] != "rev-parse" {
+			t.Errorf("gitOutput() called with dir=%q args=%v", capturedDir, capturedArgs)
+		}
+	})
+}
+
+func TestGitDefaultBranch(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitOutputFn = origGitOutput
+	}()
+
+	tests := []struct {
+		name     string
+		remote   string // origin/HEAD target, empty if unset
+		branches []string
+		want     string
+		wantErr  bool
+	}{
+		{"origin HEAD", "origin/trunk", []string{"main"}, "trunk", false},
+		{"local main", "", []string{"master", "main"}, "main", false},
+		{"local master", "", []string{"master"}, "master", false},
+		{"unknown", "", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitOutputFn = func(dir string, args ...string) (string, error) {
+				if args[0] == "symbolic-ref" && tt.remote != "" {
+					return tt.remote, nil
+				}
+				if args[0] == "rev-parse" {
+					for _, b := range tt.branches {
+						if args[3] == "refs/heads/"+b+"^{commit}" {
+							return "abc123", nil
+						}
+					}
+				}
+				return "", errors.New("exit status 1")
+			}
+
+			got, err := gitDefaultBranch("/repo")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("gitDefaultBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("gitDefaultBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitResolveCommit(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitOutputFn = origGitOutput
+	}()
+
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[3] == "HEAD~3^{commit}" {
+			return "abc123", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+
+	if got, err := gitResolveCommit("/repo", "HEAD~3"); err != nil || got != "abc123" {
+		t.Errorf("gitResolveCommit() = %q, %v; want %q", got, err, "abc123")
+	}
+	if _, err := gitResolveCommit("/repo", "HEAD~999"); err == nil || err.Error() != "HEAD~999 does not resolve to a commit" {
+		t.Errorf("gitResolveCommit() error = %v, want does not resolve error", err)
+	}
+}
+
+func TestDefaultGitOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	initCmd := exec.Command("git", "init", "-q")
+	initCmd.Dir = tmpDir
+	if err := initCmd.Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+
+	t.Run("returns trimmed stdout", func(t *testing.T) {
+		out, err := defaultGitOutput(tmpDir, "rev-parse", "--is-inside-work-tree")
+		if err != nil || out != "true" {
+			t.Errorf("defaultGitOutput() = %q, %v; want %q, nil", out, err, "true")
+		}
+	})
+
+	t.Run("includes stderr in error", func(t *testing.T) {
+		_, err := defaultGitOutput(tmpDir, "invalid-command-xyz")
+		if err == nil || !strings.Contains(err.Error(), "invalid-command-xyz") {
+			t.Errorf("defaultGitOutput() error = %v, want git's message", err)
+		}
+	})
+
+	t.Run("error without stderr", func(t *testing.T) {
+		_, err := defaultGitOutput(tmpDir, "rev-parse", "--verify", "--quiet", "refs/heads/nope")
+		if err == nil || err.Error() != "exit status 1" {
+			t.Errorf("defaultGitOutput() error = %v, want 'exit status 1'", err)
+		}
+	})
+}
+
+func TestDefaultGitConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"config", "wt.hook", "scripts/setup.sh"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if err := cmd.Run(); err != nil {
+			t.Skipf("git %v failed: %v", args, err)
+		}
+	}
+
+	if got := gitConfigValue(tmpDir, "wt.hook"); got != "scripts/setup.sh" {
+		t.Errorf("gitConfigValue() = %q, want %q", got, "scripts/setup.sh")
+	}
+	if got := gitConfigValue(tmpDir, "wt.unset"); got != "" {
+		t.Errorf("gitConfigValue() for an unset key = %q, want empty", got)
+	}
+}
+
+func TestDefaultEnclosingRepo(t *testing.T) {
+	// git reports paths with symlinks resolved
+	outer, _ := filepath.EvalSymlinks(t.TempDir())
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=wt", "-c", "user.email=wt@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	git(outer, "init", "-q")
+	for _, name := range []string{"embedded", "standalone"} {
+		dir := filepath.Join(outer, name)
+		os.MkdirAll(dir, 0755)
+		git(dir, "init", "-q")
+		git(dir, "commit", "-q", "--allow-empty", "-m", "initial")
+	}
+	// Adding a repository records it as a gitlink, as git submodule add does
+	git(outer, "add", "embedded")
+
+	tests := []struct {
+		name string
+		root string
+		want string
+	}{
+		{name: "embedded repository", root: filepath.Join(outer, "embedded"), want: outer},
+		{name: "repository only inside the work tree", root: filepath.Join(outer, "standalone")},
+		{name: "top-level repository", root: outer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultEnclosingRepo(tt.root); got != tt.want {
+				t.Errorf("defaultEnclosingRepo(%q) = %q, want %q", tt.root, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamCmd(t *testing.T) {
+	t.Run("forwards output before the command exits", func(t *testing.T) {
+		// The command prints, then blocks until the test has seen that output
+		stdinR, stdinW := io.Pipe()
+		outR, outW := io.Pipe()
+		cmd := exec.Command("sh", "-c", "echo cloning >&2; read x; echo done")
+		cmd.Stdin = stdinR
+
+		type result struct {
+			stderr string
+			err    error
+		}
+		done := make(chan result, 1)
+		go func() {
+			stderr, err := streamCmd(cmd, outW)
+			outW.Close()
+			done <- result{stderr, err}
+		}()
+
+		reader := bufio.NewReader(outR)
+		first, err := reader.ReadString('\n')
+		if err != nil || first != "cloning\n" {
+			t.Fatalf("first forwarded line = %q, %v; want %q", first, err, "cloning\n")
+		}
+		select {
+		case <-done:
+			t.Fatal("streamCmd() returned before the command was unblocked")
+		default:
+		}
+
+		stdinW.Write([]byte("go\n"))
+		stdinW.Close()
+		rest, _ := io.ReadAll(reader)
+		res := <-done
+		if res.err != nil {
+			t.Fatalf("streamCmd() unexpected error: %v", res.err)
+		}
+		if string(rest) != "done\n" {
+			t.Errorf("remaining output = %q, want %q", rest, "done\n")
+		}
+		if res.stderr != "cloning\n" {
+			t.Errorf("streamCmd() stderr = %q, want %q", res.stderr, "cloning\n")
+		}
+	})
+
+	t.Run("returns stderr on failure", func(t *testing.T) {
+		// An io.Pipe is safe for the concurrent writes of the stdout and stderr copiers
+		outR, outW := io.Pipe()
+		var out bytes.Buffer
+		copied := make(chan struct{})
+		go func() {
+			io.Copy(&out, outR)
+			close(copied)
+		}()
+
+		stderr, err := streamCmd(exec.Command("sh", "-c", "echo out; echo boom >&2; exit 2"), outW)
+		outW.Close()
+		<-copied
+		if err == nil {
+			t.Fatal("streamCmd() expected error")
+		}
+		if stderr != "boom\n" {
+			t.Errorf("streamCmd() stderr = %q, want %q", stderr, "boom\n")
+		}
+		if !strings.Contains(out.String(), "out\n") || !strings.Contains(out.String(), "boom\n") {
+			t.Errorf("forwarded output = %q, want both streams", out.String())
+		}
 	})
 }
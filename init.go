@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreFile is the ignore file init updates at the repository root
+const GitignoreFile = ".gitignore"
+
+// initOptions controls how init prepares the repository
+type initOptions struct {
+	noGitignoreCheck bool // leave .gitignore untouched
+}
+
+// initCmd creates the worktrees directory and makes sure git ignores it
+func initCmd(opts initOptions) error {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(wm.WorktreesPath()); err == nil {
+		fmt.Fprintf(os.Stderr, "%s/ directory already exists\n", wm.WorktreesDirName())
+	} else {
+		fmt.Fprintf(os.Stderr, "Creating %s/ directory\n", wm.WorktreesDirName())
+		if err := os.MkdirAll(wm.WorktreesPath(), 0755); err != nil {
+			return fmt.Errorf("failed to create %s/: %w", wm.WorktreesDirName(), err)
+		}
+	}
+
+	if opts.noGitignoreCheck {
+		return nil
+	}
+	added, err := ensureGitignored(wm.Root(), wm.WorktreesDirName())
+	if err != nil {
+		return err
+	}
+	if added {
+		fmt.Fprintf(os.Stderr, "Added %s/ to %s\n", wm.WorktreesDirName(), GitignoreFile)
+	}
+	return nil
+}
+
+// ensureGitignored appends dir/ to the root .gitignore unless git already ignores it
+// It reports whether the file was changed
+func ensureGitignored(root, dir string) (bool, error) {
+	path := filepath.Join(root, GitignoreFile)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to read %s: %w", GitignoreFile, err)
+	}
+
+	entry := dir + "/"
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.TrimSuffix(strings.TrimPrefix(line, "/"), "/") == dir {
+			return false, nil
+		}
+	}
+	// Also respect patterns elsewhere, such as a parent .gitignore or core.excludesFile
+	if _, err := gitOutput(root, "check-ignore", "--quiet", entry); err == nil {
+		return false, nil
+	}
+
+	content := string(data)
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += entry + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", GitignoreFile, err)
+	}
+	return true, nil
+}
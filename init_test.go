@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitCmd(t *testing.T) {
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	// Nothing is ignored outside the repo's own .gitignore
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		return "", errors.New("exit status 1")
+	}
+
+	setup := func(t *testing.T) string {
+		tmpDir := t.TempDir()
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		return tmpDir
+	}
+
+	t.Run("creates worktrees dir and adds it to gitignore once", func(t *testing.T) {
+		tmpDir := setup(t)
+		os.WriteFile(filepath.Join(tmpDir, GitignoreFile), []byte("node_modules/"), 0644)
+
+		for i := 0; i < 2; i++ {
+			if err := initCmd(initOptions{}); err != nil {
+				t.Fatalf("initCmd() run %d unexpected error: %v", i+1, err)
+			}
+		}
+
+		if info, err := os.Stat(filepath.Join(tmpDir, WorktreesDir)); err != nil || !info.IsDir() {
+			t.Errorf("initCmd() did not create %s/: %v", WorktreesDir, err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, GitignoreFile))
+		if want := "node_modules/\n.worktrees/\n"; string(data) != want {
+			t.Errorf(".gitignore = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("creates gitignore when missing", func(t *testing.T) {
+		tmpDir := setup(t)
+
+		if err := initCmd(initOptions{}); err != nil {
+			t.Fatalf("initCmd() unexpected error: %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, GitignoreFile))
+		if string(data) != ".worktrees/\n" {
+			t.Errorf(".gitignore = %q, want %q", data, ".worktrees/\n")
+		}
+	})
+
+	t.Run("opt-out leaves gitignore untouched", func(t *testing.T) {
+		tmpDir := setup(t)
+
+		if err := initCmd(initOptions{noGitignoreCheck: true}); err != nil {
+			t.Fatalf("initCmd() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, WorktreesDir)); err != nil {
+			t.Errorf("initCmd() did not create %s/: %v", WorktreesDir, err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, GitignoreFile)); !os.IsNotExist(err) {
+			t.Errorf("initCmd() created %s despite --no-gitignore-check", GitignoreFile)
+		}
+	})
+
+	t.Run("uses configured worktrees dir", func(t *testing.T) {
+		tmpDir := setup(t)
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("worktrees_dir = trees\n"), 0644)
+
+		if err := initCmd(initOptions{}); err != nil {
+			t.Fatalf("initCmd() unexpected error: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(tmpDir, "trees")); err != nil {
+			t.Errorf("initCmd() did not create trees/: %v", err)
+		}
+		data, _ := os.ReadFile(filepath.Join(tmpDir, GitignoreFile))
+		if string(data) != "trees/\n" {
+			t.Errorf(".gitignore = %q, want %q", data, "trees/\n")
+		}
+	})
+
+	t.Run("cannot create worktrees dir", func(t *testing.T) {
+		tmpDir := setup(t)
+		// A file in the way makes MkdirAll fail
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("worktrees_dir = blocker/trees\n"), 0644)
+		os.WriteFile(filepath.Join(tmpDir, "blocker"), nil, 0644)
+
+		err := initCmd(initOptions{})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to create blocker/trees/:") {
+			t.Errorf("initCmd() error = %v, want create error", err)
+		}
+	})
+
+	t.Run("gitignore read error", func(t *testing.T) {
+		tmpDir := setup(t)
+		os.Mkdir(filepath.Join(tmpDir, GitignoreFile), 0755)
+
+		err := initCmd(initOptions{})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to read .gitignore:") {
+			t.Errorf("initCmd() error = %v, want read error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		err := initCmd(initOptions{})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("initCmd() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestEnsureGitignored(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() { gitOutputFn = origGitOutput }()
+
+	notIgnored := func(dir string, args ...string) (string, error) {
+		return "", errors.New("exit status 1")
+	}
+
+	tests := []struct {
+		name      string
+		existing  string
+		ignored   bool // git check-ignore reports the dir as ignored
+		want      string
+		wantAdded bool
+	}{
+		{"already listed", "dist/\n.worktrees/\n", false, "dist/\n.worktrees/\n", false},
+		{"listed without slash", ".worktrees\n", false, ".worktrees\n", false},
+		{"listed anchored", "/.worktrees/\n", false, "/.worktrees/\n", false},
+		{"ignored elsewhere", "dist/\n", true, "dist/\n", false},
+		{"appended", "dist/\n", false, "dist/\n.worktrees/\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, GitignoreFile)
+			os.WriteFile(path, []byte(tt.existing), 0644)
+			gitOutputFn = notIgnored
+			if tt.ignored {
+				gitOutputFn = func(dir string, args ...string) (string, error) {
+					return "", nil
+				}
+			}
+
+			added, err := ensureGitignored(root, WorktreesDir)
+			if err != nil {
+				t.Fatalf("ensureGitignored() unexpected error: %v", err)
+			}
+			if added != tt.wantAdded {
+				t.Errorf("ensureGitignored() added = %v, want %v", added, tt.wantAdded)
+			}
+			data, _ := os.ReadFile(path)
+			if string(data) != tt.want {
+				t.Errorf(".gitignore = %q, want %q", data, tt.want)
+			}
+		})
+	}
+
+	t.Run("write error", func(t *testing.T) {
+		gitOutputFn = notIgnored
+		_, err := ensureGitignored(filepath.Join(t.TempDir(), "missing"), WorktreesDir)
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to write .gitignore:") {
+			t.Errorf("ensureGitignored() error = %v, want write error", err)
+		}
+	})
+}
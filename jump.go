@@ -2,13 +2,36 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
+// RootJumpName is the name wt jump accepts for the repository root
+// git refuses "@" as a branch name, so it cannot clash with a worktree
+const RootJumpName = "@"
+
+// jumpOptions controls how jump prints the target path
+type jumpOptions struct {
+	relative   bool   // print the path relative to the current directory
+	relativeTo string // print the path relative to this directory instead; empty prints it absolute
+	create     bool   // create the worktree with the default settings when it does not exist
+	yes        bool   // answer yes to the prompts of --create
+}
+
 // jump outputs a worktree path for the shell wrapper to cd into.
 // If name is empty, it navigates to the repository root (when inside a worktree).
-// If name is provided, it navigates to that specific worktree.
-func jump(name string) error {
+// If name is RootJumpName, it navigates to the repository root from anywhere.
+// If name is provided, it navigates to that specific worktree, creating it first with opts.create.
+// A name such as my-feature/frontend navigates to the frontend directory inside the my-feature worktree.
+func jump(name string, opts jumpOptions) error {
+	relativeTo, err := relativeToDir(opts.relativeTo)
+	if err != nil {
+		return err
+	}
+	opts.relativeTo = relativeTo
+
 	wm, err := NewWorktreeManager()
 	if err != nil {
 		return err
@@ -18,16 +41,112 @@ func jump(name string) error {
 	if name == "" {
 		currentName, _ := wm.CurrentWorktreeName()
 		if currentName != "" {
-			fmt.Println(wm.Root())
+			printJumpPath(wm.Root(), opts)
 		}
 		return nil
 	}
+	if name == RootJumpName {
+		printJumpPath(wm.Root(), opts)
+		return nil
+	}
+
+	name, subdir := splitJumpName(wm, name)
+	if subdir != "" {
+		return jumpToSubdir(wm, name, subdir, opts)
+	}
 
 	// Jump to specific worktree
 	worktreePath := wm.WorktreePath(name)
 	if _, err := os.Stat(worktreePath); os.IsNotExist(err) {
-		return fmt.Errorf("worktree %q does not exist", name)
+		if !opts.create {
+			return fmt.Errorf("worktree %q does not exist", name)
+		}
+		if worktreePath, err = createWorktree(name, createOptions{yes: opts.yes}); err != nil {
+			return err
+		}
+	}
+	printJumpPath(worktreePath, opts)
+	return nil
+}
+
+// splitJumpName splits name into a worktree and a subdirectory inside it, as in my-feature/frontend
+// Worktree names can contain slashes, so the longest leading part naming a worktree (a directory with a
+// .git file) is used; when no part does, all of name is the worktree
+func splitJumpName(wm *WorktreeManager, name string) (string, string) {
+	parts := strings.Split(name, "/")
+	for i := len(parts) - 1; i > 0; i-- {
+		worktree := strings.Join(parts[:i], "/")
+		// A worktree name is clean, so one that only resolves to a worktree through .. is not one
+		if filepath.IsLocal(worktree) && filepath.Clean(worktree) == worktree && fileExists(filepath.Join(wm.WorktreePath(worktree), ".git")) {
+			return worktree, strings.Join(parts[i:], "/")
+		}
+	}
+	return name, ""
+}
+
+// jumpToSubdir prints the path of the directory subdir inside the worktree name
+// subdir must stay inside the worktree, so a path such as ../other is rejected
+func jumpToSubdir(wm *WorktreeManager, name, subdir string, opts jumpOptions) error {
+	if !filepath.IsLocal(subdir) {
+		return fmt.Errorf("%s is outside worktree %q", subdir, name)
+	}
+	path := filepath.Join(wm.WorktreePath(name), subdir)
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("worktree %q has no directory %s", name, subdir)
+	}
+	printJumpPath(path, opts)
+	return nil
+}
+
+// printJumpPath prints path for the shell wrapper, relative to the current directory or --relative-to if requested
+// It falls back to the absolute path when the current directory is unknown
+func printJumpPath(path string, opts jumpOptions) {
+	base := opts.relativeTo
+	if opts.relative {
+		if cwd, err := getwdFn(); err == nil {
+			base = cwd
+		}
+	}
+	fmt.Println(relativePath(base, path))
+}
+
+// relativeToDir resolves the --relative-to directory against the current directory and checks that it exists
+// An empty dir means no --relative-to and is returned as is
+func relativeToDir(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	abs, err := absPath(dir)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("--relative-to: %s is not a directory", dir)
+	}
+	return abs, nil
+}
+
+// relativePath returns path relative to base, or path unchanged when base is empty
+func relativePath(base, path string) string {
+	if base == "" {
+		return path
+	}
+	// Both paths are absolute, so Rel cannot fail
+	rel, _ := filepath.Rel(base, path)
+	return rel
+}
+
+// repoRoot prints the main repository root, whether run from the root or from a worktree
+// A non-empty relativeTo prints it relative to that directory instead
+func repoRoot(w io.Writer, relativeTo string) error {
+	base, err := relativeToDir(relativeTo)
+	if err != nil {
+		return err
+	}
+	root, err := gitMainRoot()
+	if err != nil {
+		return err
 	}
-	fmt.Println(worktreePath)
+	fmt.Fprintln(w, relativePath(base, root))
 	return nil
 }
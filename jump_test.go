@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -24,7 +25,7 @@ func TestJump(t *testing.T) {
 			return "", errors.New("not in a git repository")
 		}
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 		if err == nil || err.Error() != "not in a git repository" {
 			t.Errorf("jump() error = %v, want 'not in a git repository'", err)
 		}
@@ -46,7 +47,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -79,7 +80,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -96,6 +97,36 @@ func TestJump(t *testing.T) {
 		}
 	})
 
+	t.Run("@ outputs root path from anywhere", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		getwdFn = func() (string, error) {
+			return "/some/other/dir", nil
+		}
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := jump(RootJumpName, jumpOptions{})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err != nil {
+			t.Errorf("jump() unexpected error: %v", err)
+		}
+		if output := strings.TrimSpace(buf.String()); output != tmpDir {
+			t.Errorf("jump() stdout = %q, want %q", output, tmpDir)
+		}
+	})
+
 	t.Run("no name not inside worktree outputs nothing", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -111,7 +142,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -143,7 +174,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -175,7 +206,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("")
+		err := jump("", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -208,7 +239,7 @@ func TestJump(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := jump("my-feature")
+		err := jump("my-feature", jumpOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -232,7 +263,7 @@ func TestJump(t *testing.T) {
 			return tmpDir, nil
 		}
 
-		err := jump("non-existent")
+		err := jump("non-existent", jumpOptions{})
 		if err == nil {
 			t.Error("jump() expected error for non-existent worktree")
 		}
@@ -241,3 +272,369 @@ func TestJump(t *testing.T) {
 		}
 	})
 }
+
+func TestJumpCreate(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+	}()
+
+	// setup returns a repository with an existing worktree and records git worktree add calls
+	setup := func(t *testing.T) (tmpDir string, added *[]string) {
+		tmpDir = t.TempDir()
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, "existing"), 0755)
+		added = &[]string{}
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "worktree" {
+				*added = append(*added, args[2])
+				os.MkdirAll(args[2], 0755)
+			}
+			return nil
+		}
+		getwdFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		return tmpDir, added
+	}
+
+	captureStdout := func(t *testing.T, fn func() error) (string, error) {
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+		err := fn()
+		w.Close()
+		os.Stdout = oldStdout
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		return buf.String(), err
+	}
+
+	t.Run("existing worktree is not created again", func(t *testing.T) {
+		tmpDir, added := setup(t)
+
+		out, err := captureStdout(t, func() error { return jump("existing", jumpOptions{create: true}) })
+		if err != nil {
+			t.Fatalf("jump() unexpected error: %v", err)
+		}
+		if want := filepath.Join(tmpDir, WorktreesDir, "existing") + "\n"; out != want {
+			t.Errorf("jump() stdout = %q, want %q", out, want)
+		}
+		if len(*added) != 0 {
+			t.Errorf("jump() created worktrees %v, want none", *added)
+		}
+	})
+
+	t.Run("missing worktree is created then printed", func(t *testing.T) {
+		tmpDir, added := setup(t)
+		worktreePath := filepath.Join(tmpDir, WorktreesDir, "new-feature")
+
+		out, err := captureStdout(t, func() error { return jump("new-feature", jumpOptions{create: true}) })
+		if err != nil {
+			t.Fatalf("jump() unexpected error: %v", err)
+		}
+		if out != worktreePath+"\n" {
+			t.Errorf("jump() stdout = %q, want only the new path %q", out, worktreePath+"\n")
+		}
+		if len(*added) != 1 || (*added)[0] != worktreePath {
+			t.Errorf("jump() created worktrees %v, want [%s]", *added, worktreePath)
+		}
+	})
+
+	t.Run("created worktree honors --relative", func(t *testing.T) {
+		setup(t)
+
+		out, err := captureStdout(t, func() error { return jump("new-feature", jumpOptions{create: true, relative: true}) })
+		if err != nil {
+			t.Fatalf("jump() unexpected error: %v", err)
+		}
+		if want := filepath.Join(WorktreesDir, "new-feature") + "\n"; out != want {
+			t.Errorf("jump() stdout = %q, want %q", out, want)
+		}
+	})
+
+	t.Run("missing worktree without --create is not created", func(t *testing.T) {
+		_, added := setup(t)
+
+		err := jump("new-feature", jumpOptions{})
+		if err == nil || err.Error() != `worktree "new-feature" does not exist` {
+			t.Errorf("jump() error = %v, want does not exist error", err)
+		}
+		if len(*added) != 0 {
+			t.Errorf("jump() created worktrees %v, want none", *added)
+		}
+	})
+
+	t.Run("nested repository without a terminal needs --yes", func(t *testing.T) {
+		tmpDir, added := setup(t)
+		origEnclosing, origInput := enclosingFn, promptInput
+		defer func() {
+			enclosingFn, promptInput = origEnclosing, origInput
+		}()
+		enclosingFn = func(root string) string {
+			return "/src/app"
+		}
+		promptInput = strings.NewReader("")
+		oldStderr := os.Stderr
+		os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		defer func() {
+			os.Stderr.Close()
+			os.Stderr = oldStderr
+		}()
+
+		err := jump("new-feature", jumpOptions{create: true})
+		if err == nil || err.Error() != "aborted; run wt create from /src/app to create the worktree there" {
+			t.Errorf("jump() error = %v, want aborted", err)
+		}
+		out, err := captureStdout(t, func() error { return jump("new-feature", jumpOptions{create: true, yes: true}) })
+		if worktreePath := filepath.Join(tmpDir, WorktreesDir, "new-feature"); err != nil || out != worktreePath+"\n" {
+			t.Errorf("jump() = %q, %v; want %q", out, err, worktreePath+"\n")
+		}
+		if len(*added) != 1 {
+			t.Errorf("jump() created worktrees %v, want one", *added)
+		}
+	})
+
+	t.Run("create failure", func(t *testing.T) {
+		setup(t)
+		gitCmdFn = func(dir string, args ...string) error {
+			return errors.New("exit status 128")
+		}
+
+		err := jump("new-feature", jumpOptions{create: true})
+		if err == nil || err.Error() != "failed to create worktree: exit status 128" {
+			t.Errorf("jump() error = %v, want create failure", err)
+		}
+	})
+}
+
+func TestJumpRelative(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGetwd := getwdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		getwdFn = origGetwd
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "my-feature")
+	otherPath := filepath.Join(tmpDir, WorktreesDir, "other", "src")
+	os.MkdirAll(worktreePath, 0755)
+	os.MkdirAll(otherPath, 0755)
+
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		cwd    string
+		cwdErr error
+		opts   jumpOptions
+		want   string
+	}{
+		{"absolute by default", "my-feature", tmpDir, nil, jumpOptions{}, worktreePath},
+		{"relative from root", "my-feature", tmpDir, nil, jumpOptions{relative: true}, filepath.Join(WorktreesDir, "my-feature")},
+		{"relative from another worktree", "my-feature", otherPath, nil, jumpOptions{relative: true}, filepath.Join("..", "..", "my-feature")},
+		{"relative to root from worktree", "", worktreePath, nil, jumpOptions{relative: true}, filepath.Join("..", "..")},
+		{"absolute when cwd unknown", "my-feature", "", errors.New("getwd failed"), jumpOptions{relative: true}, worktreePath},
+		{"relative to the worktrees directory", "my-feature", tmpDir, nil, jumpOptions{relativeTo: filepath.Join(tmpDir, WorktreesDir)}, "my-feature"},
+		{"relative to a directory below the target", "my-feature", tmpDir, nil, jumpOptions{relativeTo: otherPath}, filepath.Join("..", "..", "my-feature")},
+		{"root relative to a worktree", RootJumpName, tmpDir, nil, jumpOptions{relativeTo: worktreePath}, filepath.Join("..", "..")},
+		{"relative to a directory given relative to cwd", "my-feature", worktreePath, nil, jumpOptions{relativeTo: filepath.Join("..", "other")}, filepath.Join("..", "my-feature")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getwdFn = func() (string, error) {
+				return tt.cwd, tt.cwdErr
+			}
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := jump(tt.target, tt.opts)
+
+			w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+
+			if err != nil {
+				t.Fatalf("jump() unexpected error: %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("jump() stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("relative to a missing directory", func(t *testing.T) {
+		getwdFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		os.WriteFile(filepath.Join(tmpDir, "file"), []byte{}, 0644)
+
+		for _, dir := range []string{"missing", "file"} {
+			err := jump("my-feature", jumpOptions{relativeTo: dir})
+			if want := "--relative-to: " + dir + " is not a directory"; err == nil || err.Error() != want {
+				t.Errorf("jump() error = %v, want %q", err, want)
+			}
+		}
+	})
+
+	t.Run("relative to when cwd unknown", func(t *testing.T) {
+		getwdFn = func() (string, error) {
+			return "", errors.New("getwd failed")
+		}
+
+		err := jump("my-feature", jumpOptions{relativeTo: "other"})
+		if err == nil || err.Error() != "failed to get current directory: getwd failed" {
+			t.Errorf("jump() error = %v, want getwd error", err)
+		}
+	})
+}
+
+func TestJumpSubdir(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		os.Stdout = origStdout
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// my-feature is a worktree with a frontend directory; feature/login is one whose name has a slash
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "my-feature")
+	os.MkdirAll(filepath.Join(worktreePath, "frontend", "src"), 0755)
+	os.WriteFile(filepath.Join(worktreePath, ".git"), []byte("gitdir: /repo/.git/worktrees/my-feature\n"), 0644)
+	os.WriteFile(filepath.Join(worktreePath, "README.md"), nil, 0644)
+	loginPath := filepath.Join(tmpDir, WorktreesDir, "feature", "login")
+	os.MkdirAll(filepath.Join(loginPath, "docs"), 0755)
+	os.WriteFile(filepath.Join(loginPath, ".git"), []byte("gitdir: /repo/.git/worktrees/login\n"), 0644)
+
+	tests := []struct {
+		name       string
+		arg        string
+		want       string
+		wantErrMsg string
+	}{
+		{name: "subdirectory", arg: "my-feature/frontend", want: filepath.Join(worktreePath, "frontend")},
+		{name: "nested subdirectory", arg: "my-feature/frontend/src", want: filepath.Join(worktreePath, "frontend", "src")},
+		{name: "worktree with a slash in its name", arg: "feature/login", want: loginPath},
+		{name: "subdirectory of a worktree with a slash in its name", arg: "feature/login/docs", want: filepath.Join(loginPath, "docs")},
+		{name: "missing subdirectory", arg: "my-feature/backend", wantErrMsg: `worktree "my-feature" has no directory backend`},
+		{name: "file instead of a directory", arg: "my-feature/README.md", wantErrMsg: `worktree "my-feature" has no directory README.md`},
+		{name: "traversal out of the worktree", arg: "my-feature/../feature", wantErrMsg: `../feature is outside worktree "my-feature"`},
+		{name: "traversal that comes back", arg: "my-feature/frontend/../../my-feature", wantErrMsg: `frontend/../../my-feature is outside worktree "my-feature"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := jump(tt.arg, jumpOptions{})
+
+			w.Close()
+			os.Stdout = origStdout
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("jump() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("jump() unexpected error: %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != tt.want {
+				t.Errorf("jump() stdout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepoRoot(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	defer func() { gitMainRootFn = origGitRoot }()
+
+	// Use a real repository so the root is resolved the same way from the root and from a worktree
+	gitMainRootFn = defaultGitMainRoot
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	worktreePath := filepath.Join(root, WorktreesDir, "my-feature")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.email=a@b", "-c", "user.name=a", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"worktree", "add", "-q", worktreePath, "-b", "my-feature"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	for _, dir := range []string{root, worktreePath} {
+		t.Run("from "+filepath.Base(dir), func(t *testing.T) {
+			t.Chdir(dir)
+
+			var buf bytes.Buffer
+			if err := repoRoot(&buf, ""); err != nil {
+				t.Fatalf("repoRoot() unexpected error: %v", err)
+			}
+			if got := strings.TrimSpace(buf.String()); got != root {
+				t.Errorf("repoRoot() = %q, want %q", got, root)
+			}
+		})
+	}
+
+	t.Run("relative to a worktree", func(t *testing.T) {
+		t.Chdir(root)
+
+		var buf bytes.Buffer
+		if err := repoRoot(&buf, worktreePath); err != nil {
+			t.Fatalf("repoRoot() unexpected error: %v", err)
+		}
+		if got, want := strings.TrimSpace(buf.String()), filepath.Join("..", ".."); got != want {
+			t.Errorf("repoRoot() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("relative to a missing directory", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := repoRoot(&buf, filepath.Join(root, "missing"))
+		if err == nil || !strings.HasSuffix(err.Error(), "missing is not a directory") {
+			t.Errorf("repoRoot() error = %v, want not a directory error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := repoRoot(&buf, "")
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("repoRoot() error = %v, want 'not in a git repository'", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("repoRoot() wrote %q on error", buf.String())
+		}
+	})
+}
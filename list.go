@@ -1,18 +1,408 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
-// list outputs all worktree names, one per line.
-func list(w io.Writer) error {
-	worktrees, err := listWorktrees()
+// listOptions controls which worktrees list shows
+type listOptions struct {
+	merged   bool   // only worktrees whose branch is merged into base
+	unmerged bool   // only worktrees whose branch is not merged into base
+	base     string // branch to compare against; defaults to the repository's default branch
+	attached bool   // only worktrees with a branch checked out
+	detached bool   // only worktrees on a detached HEAD
+	limit    int    // maximum number of worktrees to show; 0 means no limit
+	offset   int    // number of worktrees to skip
+	check    bool   // mark worktrees git no longer tracks as stale
+	prunable bool   // only worktrees git reports as prunable, with the reason
+	branches bool   // show the branch checked out in each worktree instead of its directory name
+	active   bool   // list the worktree containing the current directory first
+	paths    bool   // show the absolute path of each worktree instead of its directory name
+	abbrev   bool   // with paths, show paths under the home directory as ~/...
+	head     bool   // append the short SHA of each worktree's HEAD
+	columns  bool   // show each worktree's checked out branch in a second column after its name
+	orphans  bool   // list local branches that no worktree has checked out instead of worktrees
+	output   string // how the entries are printed: OutputText (the default), OutputJSON objects or OutputNull
+	exec     string // shell command run in each listed worktree instead of printing its name
+}
+
+// list outputs all worktree names in opts.output's format, one per line by default.
+func list(w io.Writer, opts listOptions) error {
+	var worktrees []string
+	var err error
+	switch {
+	case opts.prunable:
+		worktrees, err = prunableWorktrees()
+	case opts.orphans:
+		worktrees, err = orphanBranches()
+	default:
+		worktrees, err = listWorktrees()
+	}
 	if err != nil {
 		return err
 	}
-	for _, wt := range worktrees {
-		fmt.Fprintln(w, wt)
+	if opts.merged || opts.unmerged {
+		worktrees, err = filterMerged(worktrees, opts)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.attached || opts.detached {
+		if worktrees, err = filterDetached(worktrees, opts.detached); err != nil {
+			return err
+		}
+	}
+	sort.Strings(worktrees)
+	if opts.active {
+		if worktrees, err = currentWorktreeFirst(worktrees); err != nil {
+			return err
+		}
+	}
+	if opts.limit > 0 || opts.offset > 0 {
+		worktrees = paginate(worktrees, opts.offset, opts.limit)
 	}
+	if opts.exec != "" {
+		return execInWorktrees(w, worktrees, opts.exec)
+	}
+	if opts.output == OutputJSON {
+		records, err := worktreeRecords(worktrees)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, records)
+	}
+	if opts.check || opts.branches || opts.paths || opts.head || opts.columns {
+		worktrees, err = labelWorktrees(worktrees, opts)
+		if err != nil {
+			return err
+		}
+	}
+	writeEntries(w, opts.output, worktrees)
 	return nil
 }
+
+// worktreeRecord describes a listed worktree for list --output json
+// Every field is always present; the ones git cannot tell are null, so scripts can rely on the shape
+type worktreeRecord struct {
+	Name       string  `json:"name"`
+	Path       string  `json:"path"`
+	Branch     *string `json:"branch"` // null when detached or not tracked by git
+	Head       *string `json:"head"`   // null when not tracked by git
+	Detached   bool    `json:"detached"`
+	Locked     bool    `json:"locked"`
+	LockReason *string `json:"lock_reason"` // null unless locked with a reason
+	Stale      bool    `json:"stale"`       // the directory exists but git no longer tracks it
+	Prunable   *string `json:"prunable"`    // git's reason when it can prune the worktree
+}
+
+// worktreeRecords describes the named worktrees from a single git worktree list call
+func worktreeRecords(names []string) ([]worktreeRecord, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	// nullable returns nil for an empty string so it is encoded as null
+	nullable := func(s string) *string {
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+	records := []worktreeRecord{}
+	for _, name := range names {
+		record := worktreeRecord{Name: name, Path: wm.WorktreePath(name)}
+		if info, ok := findWorktree(infos, record.Path); ok {
+			record.Branch = nullable(info.Branch)
+			record.Head = nullable(info.Head)
+			record.Detached = info.Detached
+			record.Locked = info.Locked
+			record.LockReason = nullable(info.LockReason)
+			record.Prunable = nullable(info.Prunable)
+		} else {
+			record.Stale = true
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// execInWorktrees runs command with sh -c in each of the named worktrees and writes its output to w
+// Each worktree's output is collected and printed under a "==> name <==" header so runs don't interleave;
+// a failing command is reported and the others still run
+func execInWorktrees(w io.Writer, names []string, command string) error {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return err
+	}
+	return forEachWorktree(names, "run --exec in", true, func(name string) error {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = wm.WorktreePath(name)
+		out, err := cmd.CombinedOutput()
+		fmt.Fprintf(w, "==> %s <==\n%s", name, out)
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			fmt.Fprintln(w)
+		}
+		return err
+	})
+}
+
+// currentWorktreeFirst moves the worktree containing the current directory, if any, to the front
+// The other worktrees keep their order
+func currentWorktreeFirst(worktrees []string) ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+	// CurrentWorktreeName returns empty string if not in worktree (never errors)
+	current, _ := wm.CurrentWorktreeName()
+	for i, wt := range worktrees {
+		if wt == current {
+			ordered := append([]string{wt}, worktrees[:i]...)
+			return append(ordered, worktrees[i+1:]...), nil
+		}
+	}
+	return worktrees, nil
+}
+
+// paginate returns the page of items starting at offset with at most limit entries (0 = no limit)
+// A footer describing the page is written to stderr
+func paginate(items []string, offset, limit int) []string {
+	total := len(items)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+
+	if start == end {
+		fmt.Fprintf(os.Stderr, "showing 0 of %d\n", total)
+	} else {
+		fmt.Fprintf(os.Stderr, "showing %d-%d of %d\n", start+1, end, total)
+	}
+	return items[start:end]
+}
+
+// shortSHALen is how many characters of a commit SHA list --show-head shows, as git log --oneline usually does
+const shortSHALen = 7
+
+// labelWorktrees rewrites worktree names for display using git's view of each worktree
+// With opts.paths a worktree is shown by its absolute path instead of its name, or with opts.abbrev
+// by its path relative to the home directory, as ~/...
+// With opts.branches a worktree is shown by its checked out branch, or its name marked
+// " (detached HEAD)" when it has none; with opts.columns the branch, "(detached HEAD)" or "-" when
+// git does not track the worktree follows in a column; with opts.head the short SHA of its HEAD follows the label;
+// with opts.check worktrees whose directory exists but git no longer tracks (their admin files
+// under .git/worktrees were deleted) get " (stale)", locked worktrees get " (locked: <reason>)",
+// and branches checked out in several worktrees are warned about on stderr
+func labelWorktrees(worktrees []string, opts listOptions) ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+
+	// Paths alone come from the worktrees directory, so only ask git when its view is needed
+	// A single git worktree list call covers every worktree
+	var infos []Worktree
+	if opts.branches || opts.check || opts.head || opts.columns {
+		if infos, err = listWorktreeInfos(); err != nil {
+			return nil, err
+		}
+	}
+	tracked := map[string]Worktree{}
+	for _, info := range infos {
+		tracked[resolvePath(info.Path)] = info
+	}
+	if opts.check {
+		for _, warning := range detectDuplicateBranches(infos) {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+
+	labels := make([]string, len(worktrees))
+	width := 0
+	for i, name := range worktrees {
+		labels[i] = name
+		if opts.paths {
+			labels[i] = wm.WorktreePath(name)
+			if opts.abbrev {
+				labels[i] = abbrevPath(labels[i])
+			}
+		}
+		width = max(width, utf8.RuneCountInString(labels[i]))
+	}
+
+	for i, name := range worktrees {
+		info, ok := tracked[resolvePath(wm.WorktreePath(name))]
+		if opts.columns {
+			branch := info.Branch
+			switch {
+			case !ok:
+				branch = "-"
+			case branch == "":
+				branch = "(detached HEAD)"
+			}
+			labels[i] = fmt.Sprintf("%-*s  %s", width, labels[i], branch)
+		}
+		if opts.branches && ok {
+			if info.Branch != "" {
+				labels[i] = info.Branch
+			} else {
+				labels[i] += " (detached HEAD)"
+			}
+		}
+		if opts.head && info.Head != "" {
+			labels[i] += " " + info.Head[:min(len(info.Head), shortSHALen)]
+		}
+		if opts.check && !ok {
+			labels[i] += " (stale)"
+		}
+		if opts.check && info.Locked {
+			if info.LockReason != "" {
+				labels[i] += " (locked: " + info.LockReason + ")"
+			} else {
+				labels[i] += " (locked)"
+			}
+		}
+	}
+	return labels, nil
+}
+
+// prunableWorktrees returns the worktrees git reports as prunable, annotated with git's reason
+// Their directories are usually gone, so they are taken from git rather than the worktrees directory
+func prunableWorktrees() ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	prunable := []string{}
+	for _, info := range infos {
+		if info.Prunable == "" {
+			continue
+		}
+		// Name worktrees under the worktrees directory as list does; show any other by its path
+		name, err := wm.WorktreeNameFromPath(info.Path)
+		if err != nil {
+			name = info.Path
+		}
+		prunable = append(prunable, fmt.Sprintf("%s (prunable: %s)", name, info.Prunable))
+	}
+	return prunable, nil
+}
+
+// orphanBranches returns the local branches no worktree has checked out, such as those left behind
+// by removing a worktree with git directly; the default branch is never reported
+// When branch_prefix is set, only branches with the prefix, the ones wt creates, are considered
+func orphanBranches() ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return nil, err
+	}
+	checkedOut := map[string]bool{}
+	for _, info := range infos {
+		checkedOut[info.Branch] = true
+	}
+	// Without a default branch there is simply nothing extra to leave out
+	defaultBranch, _ := gitDefaultBranch(wm.Root())
+
+	out, err := gitOutput(wm.Root(), "for-each-ref", "--format=%(refname:short)", "refs/heads/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	orphans := []string{}
+	for _, branch := range strings.Fields(out) {
+		if checkedOut[branch] || branch == defaultBranch || !strings.HasPrefix(branch, wm.Config().BranchPrefix) {
+			continue
+		}
+		orphans = append(orphans, branch)
+	}
+	return orphans, nil
+}
+
+// filterDetached keeps the worktrees with a branch checked out or, with detached, those on a detached HEAD
+// Worktrees git does not track have no HEAD to tell and match neither filter
+func filterDetached(worktrees []string, detached bool) ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []string{}
+	for _, name := range worktrees {
+		if info, ok := findWorktree(infos, wm.WorktreePath(name)); ok && (info.Branch == "") == detached {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered, nil
+}
+
+// filterMerged keeps the worktrees whose branch is (or, with opts.unmerged, is not) merged into the base
+func filterMerged(worktrees []string, opts listOptions) ([]string, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+
+	base := opts.base
+	if base == "" {
+		base, err = gitDefaultBranch(wm.Root())
+		if err != nil {
+			return nil, fmt.Errorf("%w (pass a base branch)", err)
+		}
+	}
+	if !gitRefExists(wm.Root(), base) {
+		return nil, fmt.Errorf("unknown base branch: %s", base)
+	}
+
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []string{}
+	for _, name := range worktrees {
+		// Compare the branch checked out in the worktree, which may differ from its directory name
+		// Detached and untracked worktrees have no branch to compare and match neither filter
+		info, ok := findWorktree(infos, wm.WorktreePath(name))
+		if !ok || info.Branch == "" {
+			continue
+		}
+		// A branch is merged when its tip is reachable from the base; git exits with 1 when it is not,
+		// and with another status when it cannot tell, such as for a missing object
+		_, err := gitOutput(wm.Root(), "merge-base", "--is-ancestor", "refs/heads/"+info.Branch, base)
+		var exitErr *exec.ExitError
+		if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 1) {
+			return nil, fmt.Errorf("failed to check whether %s is merged into %s: %w", info.Branch, base, err)
+		}
+		if (err == nil) == opts.merged {
+			filtered = append(filtered, name)
+		}
+	}
+	return filtered, nil
+}
@@ -3,6 +3,12 @@ package main
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -20,7 +26,7 @@ func TestList(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		err := list(&buf)
+		err := list(&buf, listOptions{})
 		if err != nil {
 			t.Errorf("list() unexpected error: %v", err)
 		}
@@ -44,7 +50,7 @@ func TestList(t *testing.T) {
 		}
 
 		var buf bytes.Buffer
-		err := list(&buf)
+		err := list(&buf, listOptions{})
 		if err != nil {
 			t.Errorf("list() unexpected error: %v", err)
 		}
@@ -53,13 +59,1057 @@ func TestList(t *testing.T) {
 		}
 	})
 
+	t.Run("null separates names containing spaces", func(t *testing.T) {
+		listWorktreesFn = func() ([]string, error) {
+			return []string{"my feature", "fix"}, nil
+		}
+
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{output: OutputNull}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		if want := "fix\x00my feature\x00"; buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
 	t.Run("error from listWorktrees", func(t *testing.T) {
 		listWorktreesFn = func() ([]string, error) {
 			return nil, errors.New("not in a git repository")
 		}
 
 		var buf bytes.Buffer
-		err := list(&buf)
+		err := list(&buf, listOptions{})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("list() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListMergedFilter(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origInfos
+	}()
+
+	root := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"feature-a", "feature-b", "bugfix-c", "spike", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return root, nil
+	}
+	// bugfix-c's directory name differs from its branch; spike is detached; orphan is untracked
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		dir := filepath.Join(root, WorktreesDir)
+		return []Worktree{
+			{Path: root, Branch: "main"},
+			{Path: filepath.Join(dir, "feature-a"), Branch: "feature-a"},
+			{Path: filepath.Join(dir, "feature-b"), Branch: "feature-b"},
+			{Path: filepath.Join(dir, "bugfix-c"), Branch: "fix/c"},
+			{Path: filepath.Join(dir, "spike"), Detached: true},
+		}, nil
+	}
+
+	// feature-a and fix/c are ancestors of main; the default branch is origin/main
+	// git merge-base --is-ancestor exits with 1 for a branch that is not an ancestor
+	mergedIntoMain := map[string]bool{"refs/heads/feature-a": true, "refs/heads/fix/c": true}
+	notAncestor := exec.Command("sh", "-c", "exit 1").Run()
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		switch args[0] {
+		case "symbolic-ref":
+			return "origin/main", nil
+		case "rev-parse":
+			if args[3] == "main^{commit}" || args[3] == "develop^{commit}" {
+				return "abc123", nil
+			}
+			return "", errors.New("exit status 1")
+		case "merge-base":
+			if args[3] == "main" && mergedIntoMain[args[2]] {
+				return "", nil
+			}
+			return "", notAncestor
+		}
+		return "", errors.New("unexpected git call")
+	}
+
+	tests := []struct {
+		name    string
+		opts    listOptions
+		want    string
+		wantErr string
+	}{
+		{"merged into default branch", listOptions{merged: true}, "bugfix-c\nfeature-a\n", ""},
+		{"unmerged from default branch", listOptions{unmerged: true}, "feature-b\n", ""},
+		{"merged into explicit base", listOptions{merged: true, base: "main"}, "bugfix-c\nfeature-a\n", ""},
+		{"nothing merged into other base", listOptions{merged: true, base: "develop"}, "", ""},
+		{"unknown base", listOptions{merged: true, base: "nope"}, "", "unknown base branch: nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("list() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("merge-base fails", func(t *testing.T) {
+		origGitOutput := gitOutputFn
+		defer func() {
+			gitOutputFn = origGitOutput
+		}()
+		corrupt := fmt.Errorf("%w: fatal: bad object", exec.Command("sh", "-c", "exit 128").Run())
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			if args[0] == "merge-base" {
+				return "", corrupt
+			}
+			return origGitOutput(dir, args...)
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{unmerged: true, base: "main"})
+		if want := "failed to check whether feature-a is merged into main: exit status 128: fatal: bad object"; err == nil || err.Error() != want {
+			t.Errorf("list() error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("default branch unknown", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 1")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{merged: true})
+		if err == nil || err.Error() != "could not determine the default branch (pass a base branch)" {
+			t.Errorf("list() error = %v, want default branch error", err)
+		}
+	})
+
+	t.Run("git worktree list error", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "abc123", nil
+		}
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("failed to list git worktrees: exit status 128")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{merged: true, base: "main"})
+		if err == nil || err.Error() != "failed to list git worktrees: exit status 128" {
+			t.Errorf("list() error = %v, want git worktree list error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{unmerged: true})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("list() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListPagination(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	defer func() { listWorktreesFn = origListWorktrees }()
+
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"e", "c", "a", "d", "b"}, nil
+	}
+
+	tests := []struct {
+		name       string
+		opts       listOptions
+		wantOut    string
+		wantFooter string
+	}{
+		{"no limit sorts all", listOptions{}, "a\nb\nc\nd\ne\n", ""},
+		{"limit", listOptions{limit: 2}, "a\nb\n", "showing 1-2 of 5\n"},
+		{"offset", listOptions{offset: 3}, "d\ne\n", "showing 4-5 of 5\n"},
+		{"limit and offset", listOptions{limit: 2, offset: 1}, "b\nc\n", "showing 2-3 of 5\n"},
+		{"limit past end", listOptions{limit: 10, offset: 4}, "e\n", "showing 5-5 of 5\n"},
+		{"offset past end", listOptions{offset: 9}, "", "showing 0 of 5\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+
+			w.Close()
+			os.Stderr = oldStderr
+
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.wantOut {
+				t.Errorf("list() stdout = %q, want %q", buf.String(), tt.wantOut)
+			}
+			if stderr.String() != tt.wantFooter {
+				t.Errorf("list() stderr = %q, want %q", stderr.String(), tt.wantFooter)
+			}
+		})
+	}
+}
+
+func TestListCheck(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"tracked", "orphan"} {
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, name), 0755)
+	}
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"tracked", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	t.Run("marks directories git does not track", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "tracked") + "\nHEAD def456\nbranch refs/heads/tracked", nil
+		}
+
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{check: true}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		want := "orphan (stale)\ntracked\n"
+		if buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("marks locked worktrees with their reason", func(t *testing.T) {
+		listWorktreesFn = func() ([]string, error) {
+			return []string{"tracked", "usb", "orphan"}, nil
+		}
+		defer func() {
+			listWorktreesFn = func() ([]string, error) {
+				return []string{"tracked", "orphan"}, nil
+			}
+		}()
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "tracked") + "\nHEAD def456\nbranch refs/heads/tracked\nlocked\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "usb") + "\nHEAD 789abc\nbranch refs/heads/usb\nlocked on a removable drive\n", nil
+		}
+
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{check: true}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		want := "orphan (stale)\ntracked (locked)\nusb (locked: on a removable drive)\n"
+		if buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("warns about branches checked out twice", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "tracked") + "\nHEAD def456\nbranch refs/heads/main", nil
+		}
+
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{check: true})
+
+		w.Close()
+		os.Stderr = oldStderr
+		var stderr bytes.Buffer
+		io.Copy(&stderr, r)
+
+		if err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		want := "warning: branch main is checked out in 2 worktrees: " + tmpDir + ", " + filepath.Join(tmpDir, WorktreesDir, "tracked") + "\n"
+		if stderr.String() != want {
+			t.Errorf("list() stderr = %q, want %q", stderr.String(), want)
+		}
+	})
+
+	t.Run("without check no marker", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		if strings.Contains(buf.String(), "(stale)") {
+			t.Errorf("list() output = %q, want no stale marker", buf.String())
+		}
+	})
+
+	t.Run("git worktree list error", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 128")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{check: true})
+		if err == nil || err.Error() != "failed to list git worktrees: exit status 128" {
+			t.Errorf("list() error = %v, want git worktree list error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{check: true})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("list() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListPrunable(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		t.Error("list --prunable should not read the worktrees directory")
+		return nil, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	t.Run("shows only prunable worktrees with reasons", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "kept") + "\nHEAD def456\nbranch refs/heads/kept\n\n" +
+				"worktree " + filepath.Join(tmpDir, WorktreesDir, "gone") + "\nHEAD 789abc\nbranch refs/heads/gone\nprunable gitdir file points to non-existent location\n\n" +
+				"worktree /elsewhere/spike\nHEAD 456def\ndetached\nprunable gitdir file points to non-existent location\n", nil
+		}
+
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{prunable: true}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		want := "/elsewhere/spike (prunable: gitdir file points to non-existent location)\n" +
+			"gone (prunable: gitdir file points to non-existent location)\n"
+		if buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("nothing prunable", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n", nil
+		}
+
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{prunable: true}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		if buf.String() != "" {
+			t.Errorf("list() output = %q, want empty", buf.String())
+		}
+	})
+
+	t.Run("git worktree list error", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 128")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{prunable: true})
+		if err == nil || err.Error() != "failed to list git worktrees: exit status 128" {
+			t.Errorf("list() error = %v, want git worktree list error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{prunable: true})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("list() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListBranches(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"login", "spike", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "login") + "\nHEAD def456\nbranch refs/heads/feature/login-form\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "spike") + "\nHEAD 789abc\ndetached\n", nil
+	}
+
+	tests := []struct {
+		name string
+		opts listOptions
+		want string
+	}{
+		{name: "branch names", opts: listOptions{branches: true}, want: "feature/login-form\norphan\nspike (detached HEAD)\n"},
+		{name: "with check", opts: listOptions{branches: true, check: true}, want: "feature/login-form\norphan (stale)\nspike (detached HEAD)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := list(&buf, tt.opts); err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestListShowHead(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"login", "spike", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	var calls [][]string
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		calls = append(calls, args)
+		return "worktree " + tmpDir + "\nHEAD 0123456789abcdef0123456789abcdef01234567\nbranch refs/heads/main\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "login") + "\nHEAD a1b2c3d4e5f60718293a4b5c6d7e8f9012345678\nbranch refs/heads/feature/login\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "spike") + "\nHEAD 9f8e7d6c5b4a39281706f5e4d3c2b1a098765432\ndetached\n", nil
+	}
+
+	tests := []struct {
+		name string
+		opts listOptions
+		want string
+	}{
+		{name: "names", opts: listOptions{head: true}, want: "login a1b2c3d\norphan\nspike 9f8e7d6\n"},
+		{name: "with branches", opts: listOptions{head: true, branches: true}, want: "feature/login a1b2c3d\norphan\nspike (detached HEAD) 9f8e7d6\n"},
+		{name: "with check", opts: listOptions{head: true, check: true}, want: "login a1b2c3d\norphan (stale)\nspike 9f8e7d6\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			var buf bytes.Buffer
+			if err := list(&buf, tt.opts); err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+			want := [][]string{{"worktree", "list", "--porcelain"}}
+			if !reflect.DeepEqual(calls, want) {
+				t.Errorf("git calls = %v, want a single %v", calls, want[0])
+			}
+		})
+	}
+}
+
+func TestListOutputModes(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"spike", "login", "api", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "api") + "\nHEAD def456\nbranch refs/heads/api\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "login") + "\nHEAD 123abc\nbranch refs/heads/feature/login-form\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "spike") + "\nHEAD 789abc\ndetached\n", nil
+	}
+
+	tests := []struct {
+		name string
+		opts listOptions
+		want string
+	}{
+		{
+			name: "names only",
+			want: "api\nlogin\norphan\nspike\n",
+		},
+		{
+			name: "names with branches",
+			opts: listOptions{columns: true},
+			want: "api     api\n" +
+				"login   feature/login-form\n" +
+				"orphan  -\n" +
+				"spike   (detached HEAD)\n",
+		},
+		{
+			name: "names with branches and check",
+			opts: listOptions{columns: true, check: true},
+			want: "api     api\n" +
+				"login   feature/login-form\n" +
+				"orphan  - (stale)\n" +
+				"spike   (detached HEAD)\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := list(&buf, tt.opts); err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestListDetachedFilters(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"spike", "login", "v1.2", "api", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	infos := []Worktree{
+		{Path: tmpDir, Branch: "main"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "api"), Branch: "api"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "login"), Branch: "feature/login"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "spike"), Detached: true},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "v1.2"), Detached: true},
+	}
+
+	tests := []struct {
+		name    string
+		opts    listOptions
+		listErr error
+		want    string
+		wantErr string
+	}{
+		{name: "attached only", opts: listOptions{attached: true}, want: "api\nlogin\n"},
+		{name: "detached only", opts: listOptions{detached: true}, want: "spike\nv1.2\n"},
+		{name: "detached with branches", opts: listOptions{detached: true, columns: true}, want: "spike  (detached HEAD)\nv1.2   (detached HEAD)\n"},
+		{name: "attached with limit", opts: listOptions{attached: true, limit: 1}, want: "api\n"},
+		{name: "git worktree list fails", opts: listOptions{attached: true}, listErr: errors.New("mock error"), wantErr: "mock error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				return infos, tt.listErr
+			}
+			oldStderr := os.Stderr
+			os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+			os.Stderr.Close()
+			os.Stderr = oldStderr
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("list() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("outside a repository", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		defer func() {
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+		}()
+		if _, err := filterDetached([]string{"api"}, false); err == nil {
+			t.Error("filterDetached() expected error outside a repository")
+		}
+	})
+}
+
+func TestListJSON(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"spike", "old"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	spike := filepath.Join(tmpDir, WorktreesDir, "spike")
+	old := filepath.Join(tmpDir, WorktreesDir, "old")
+	infos := []Worktree{
+		{Path: tmpDir, Branch: "main", Head: "abc123"},
+		{Path: spike, Head: "def456", Detached: true, Locked: true, LockReason: "on a USB drive"},
+		{Path: old, Branch: "old", Head: "789abc", Locked: true, Prunable: "gitdir file points to non-existent location"},
+	}
+
+	tests := []struct {
+		name    string
+		opts    listOptions
+		rootErr error
+		listErr error
+		want    string
+		wantErr string
+	}{
+		{
+			name: "detached, locked and prunable",
+			opts: listOptions{output: OutputJSON},
+			want: `[{"name":"old","path":"` + old + `","branch":"old","head":"789abc","detached":false,"locked":true,"lock_reason":null,"stale":false,"prunable":"gitdir file points to non-existent location"},` +
+				`{"name":"spike","path":"` + spike + `","branch":null,"head":"def456","detached":true,"locked":true,"lock_reason":"on a USB drive","stale":false,"prunable":null}]` + "\n",
+		},
+		{name: "after the filters", opts: listOptions{output: OutputJSON, attached: true}, want: `[{"name":"old","path":"` + old + `","branch":"old","head":"789abc","detached":false,"locked":true,"lock_reason":null,"stale":false,"prunable":"gitdir file points to non-existent location"}]` + "\n"},
+		{name: "past the last page", opts: listOptions{output: OutputJSON, offset: 5}, want: "[]\n"},
+		{name: "git worktree list fails", opts: listOptions{output: OutputJSON}, listErr: errors.New("mock error"), wantErr: "mock error"},
+		{name: "outside a repository", opts: listOptions{output: OutputJSON}, rootErr: errors.New("not in a git repository"), wantErr: "not in a git repository"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, tt.rootErr
+			}
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				return infos, tt.listErr
+			}
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("list() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestListPaths(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+		userHomeDirFn = origUserHomeDir
+	}()
+
+	tmpDir := t.TempDir()
+	userHomeDirFn = func() (string, error) {
+		return filepath.Dir(tmpDir), nil
+	}
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"login", "orphan"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		return "worktree " + tmpDir + "\nHEAD abc123\nbranch refs/heads/main\n\n" +
+			"worktree " + filepath.Join(tmpDir, WorktreesDir, "login") + "\nHEAD def456\nbranch refs/heads/login\n", nil
+	}
+
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		t.Fatalf("NewWorktreeManager() unexpected error: %v", err)
+	}
+	login := filepath.Join(wm.WorktreesPath(), "login")
+	orphan := filepath.Join(wm.WorktreesPath(), "orphan")
+	tests := []struct {
+		name string
+		opts listOptions
+		want string
+	}{
+		{name: "names by default", want: "login\norphan\n"},
+		{name: "absolute paths", opts: listOptions{paths: true}, want: login + "\n" + orphan + "\n"},
+		{name: "with check", opts: listOptions{paths: true, check: true}, want: login + "\n" + orphan + " (stale)\n"},
+		{name: "with limit", opts: listOptions{paths: true, offset: 1}, want: orphan + "\n"},
+		{name: "paths relative to home", opts: listOptions{paths: true, abbrev: true}, want: filepath.Join("~", filepath.Base(tmpDir), WorktreesDir, "login") + "\n" + filepath.Join("~", filepath.Base(tmpDir), WorktreesDir, "orphan") + "\n"},
+		{name: "null separated names", opts: listOptions{output: OutputNull}, want: "login\x00orphan\x00"},
+		{name: "null separated paths", opts: listOptions{paths: true, output: OutputNull}, want: login + "\x00" + orphan + "\x00"},
+		{name: "text output", opts: listOptions{output: OutputText}, want: "login\norphan\n"},
+		{name: "json objects", opts: listOptions{output: OutputJSON}, want: `[{"name":"login","path":"` + login + `","branch":"login","head":"def456","detached":false,"locked":false,"lock_reason":null,"stale":false,"prunable":null},` +
+			`{"name":"orphan","path":"` + orphan + `","branch":null,"head":null,"detached":false,"locked":false,"lock_reason":null,"stale":true,"prunable":null}]` + "\n"},
+		{name: "json past the last page", opts: listOptions{offset: 5, output: OutputJSON}, want: "[]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := list(&buf, tt.opts); err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("paths alone do not ask git", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("git unavailable")
+		}
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{paths: true}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		if buf.String() != login+"\n"+orphan+"\n" {
+			t.Errorf("list() output = %q, want paths", buf.String())
+		}
+	})
+}
+
+func TestListOrphanBranches(t *testing.T) {
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	branchesErr := error(nil)
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		switch args[0] {
+		case "for-each-ref":
+			return "alice/gone\nalice/login\nhotfix\nmain\nrelease", branchesErr
+		case "symbolic-ref":
+			return "origin/main", nil
+		}
+		return "", errors.New("exit status 1")
+	}
+	// The main worktree has release checked out, so main itself has no worktree
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{
+			{Path: tmpDir, Branch: "release"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "login"), Branch: "alice/login"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "spike"), Detached: true},
+		}, nil
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		opts   listOptions
+		want   string
+	}{
+		{name: "branches without a worktree", opts: listOptions{orphans: true}, want: "alice/gone\nhotfix\n"},
+		{name: "only the branch prefix", config: "branch_prefix = alice/\n", opts: listOptions{orphans: true}, want: "alice/gone\n"},
+		{name: "with offset", opts: listOptions{orphans: true, offset: 1}, want: "hotfix\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte(tt.config), 0644)
+
+			oldStderr := os.Stderr
+			os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+			os.Stderr.Close()
+			os.Stderr = oldStderr
+
+			if err != nil {
+				t.Fatalf("list() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("list() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	t.Run("branch list error", func(t *testing.T) {
+		branchesErr = errors.New("exit status 128")
+		defer func() { branchesErr = nil }()
+
+		err := list(io.Discard, listOptions{orphans: true})
+		if err == nil || err.Error() != "failed to list branches: exit status 128" {
+			t.Errorf("list() error = %v, want branch list error", err)
+		}
+	})
+
+	t.Run("worktree list error", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("failed to list git worktrees")
+		}
+
+		err := list(io.Discard, listOptions{orphans: true})
+		if err == nil || err.Error() != "failed to list git worktrees" {
+			t.Errorf("list() error = %v, want worktree list error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		err := list(io.Discard, listOptions{orphans: true})
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("list() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListExec(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitMainRoot := gitMainRootFn
+	origStderr := os.Stderr
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitMainRoot
+		os.Stderr = origStderr
+	}()
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, name), 0755)
+	}
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"c", "a", "b"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	t.Run("runs once per worktree in its directory", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{exec: `pwd; echo done >&2`}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		var want string
+		for _, name := range []string{"a", "b", "c"} {
+			want += "==> " + name + " <==\n" + filepath.Join(tmpDir, WorktreesDir, name) + "\ndone\n"
+		}
+		if buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("output without a trailing newline", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := list(&buf, listOptions{exec: `printf %s "${PWD##*/}"`, limit: 2}); err != nil {
+			t.Fatalf("list() unexpected error: %v", err)
+		}
+		if want := "==> a <==\na\n==> b <==\nb\n"; buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("failure in one worktree runs the others", func(t *testing.T) {
+		_, w, _ := os.Pipe()
+		os.Stderr = w
+		defer func() {
+			w.Close()
+			os.Stderr = origStderr
+		}()
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{exec: `echo "${PWD##*/}"; test "${PWD##*/}" != b`})
+		if err == nil || err.Error() != "failed to run --exec in 1 of 3 worktrees: b" {
+			t.Errorf("list() error = %v, want failure in b", err)
+		}
+		if want := "==> a <==\na\n==> b <==\nb\n==> c <==\nc\n"; buf.String() != want {
+			t.Errorf("list() output = %q, want %q", buf.String(), want)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		err := execInWorktrees(io.Discard, []string{"a"}, "true")
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("execInWorktrees() error = %v, want 'not in a git repository'", err)
+		}
+	})
+}
+
+func TestListActiveFirst(t *testing.T) {
+	origListWorktrees := listWorktreesFn
+	origGitRoot := gitMainRootFn
+	origGetwd := getwdFn
+	defer func() {
+		listWorktreesFn = origListWorktrees
+		gitMainRootFn = origGitRoot
+		getwdFn = origGetwd
+	}()
+
+	tmpDir := t.TempDir()
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"feature-b", "bugfix-c", "feature-a"}, nil
+	}
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	tests := []struct {
+		name string
+		cwd  string
+		opts listOptions
+		want string
+	}{
+		{
+			name: "inside a worktree lists it first",
+			cwd:  filepath.Join(tmpDir, WorktreesDir, "feature-b", "src"),
+			opts: listOptions{active: true},
+			want: "feature-b\nbugfix-c\nfeature-a\n",
+		},
+		{
+			name: "outside a worktree keeps the normal order",
+			cwd:  tmpDir,
+			opts: listOptions{active: true},
+			want: "bugfix-c\nfeature-a\nfeature-b\n",
+		},
+		{
+			name: "applied before the limit",
+			cwd:  filepath.Join(tmpDir, WorktreesDir, "feature-b"),
+			opts: listOptions{active: true, limit: 1},
+			want: "feature-b\n",
+		},
+		{
+			name: "off by default",
+			cwd:  filepath.Join(tmpDir, WorktreesDir, "feature-b"),
+			want: "bugfix-c\nfeature-a\nfeature-b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getwdFn = func() (string, error) {
+				return tt.cwd, nil
+			}
+
+			oldStderr := os.Stderr
+			os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			var buf bytes.Buffer
+			err := list(&buf, tt.opts)
+			os.Stderr = oldStderr
+
+			if err != nil || buf.String() != tt.want {
+				t.Errorf("list() = %q, %v; want %q", buf.String(), err, tt.want)
+			}
+		})
+	}
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		var buf bytes.Buffer
+		err := list(&buf, listOptions{active: true})
 		if err == nil || err.Error() != "not in a git repository" {
 			t.Errorf("list() error = %v, want 'not in a git repository'", err)
 		}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"runtime/debug"
+	"strconv"
+	"time"
 )
 
 // Sentinel errors for testing
@@ -18,33 +21,267 @@ var exitFn = os.Exit
 var readBuildInfo = debug.ReadBuildInfo
 
 // validCommands lists all valid command names
-var validCommands = []string{"create", "remove", "jump", "list", "completion", "version", "__complete"}
+var validCommands = []string{"init", "create", "remove", "jump", "list", "config", "repo-root", "completion", "version", "__complete"}
+
+// flagSpec describes a flag accepted by a command
+type flagSpec struct {
+	name  string // flag as typed, e.g. "--hook"
+	arg   string // value placeholder used in errors; empty for boolean flags
+	short string // optional single-letter alias, e.g. "-k"; recorded under name
+}
+
+// commandFlags lists the flags accepted by each command
+var commandFlags = map[string][]flagSpec{
+	"create": {{name: "--hook", arg: "path"}, {name: "--no-verify"}, {name: "--env-file", arg: "path"}, {name: "--quiet-hook"}, {name: "--hook-retries", arg: "n"}, {name: "--hook-timeout", arg: "duration"}, {name: "--no-checkout"}, {name: "--sparse", arg: "pattern"}, {name: "--dry-run"}, {name: "--copy", arg: "dir"}, {name: "--base", arg: "ref"}, {name: "--slug"}, {name: "--push"}, {name: "--open-url"}, {name: "--recurse-submodules"}, {name: "--template-dir", arg: "dir"}, {name: "--on-conflict", arg: "policy"}, {name: "--checkout-existing-ok"}, {name: "--from-pr", arg: "number"}, {name: "--attach", arg: "branch"}, {name: "--reuse-branch-dir", arg: "style"}, {name: "--run-hook"}, {name: "--detach", arg: "ref"}, {name: "--if-missing"}, {name: "--branch-from-current"}, {name: "--after", arg: "worktree"}, {name: "--no-claude"}, {name: "--yes"}},
+	"list": {
+		{name: "--merged"},
+		{name: "--unmerged"},
+		{name: "--limit", arg: "number"},
+		{name: "--offset", arg: "number"},
+		{name: "--attached-only"},
+		{name: "--detached-only"},
+		{name: "--check"},
+		{name: "--prunable"},
+		{name: "--branches"},
+		{name: "--pager"},
+		{name: "--no-pager"},
+		{name: "--active-first"},
+		{name: "--paths"},
+		{name: "--abbrev"},
+		{name: "--show-head"},
+		{name: "--name-only"},
+		{name: "--with-branch"},
+		{name: "--orphan-branches"},
+		{name: "--null", short: "-0"},
+		{name: "--output", arg: "format"},
+		{name: "--exec", arg: "command"},
+	},
+	"remove":     {{name: "--keep-dir"}, {name: "--archive", arg: "dir"}, {name: "--force"}, {name: "--yes"}, {name: "--older-than", arg: "duration"}, {name: "--keep-going", short: "-k"}, {name: "--no-cd"}, {name: "--select"}},
+	"init":       {{name: "--no-gitignore-check"}},
+	"jump":       {{name: "--relative"}, {name: "--relative-to", arg: "dir"}, {name: "--create"}, {name: "--yes"}},
+	"repo-root":  {{name: "--relative-to", arg: "dir"}},
+	"version":    {{name: "--check"}},
+	"__complete": {{name: "--descriptions"}},
+}
+
+// cliArgs holds the parsed command line
+type cliArgs struct {
+	cmd   string
+	name  string              // first positional argument, if any
+	args  []string            // all positional arguments
+	flags map[string][]string // values given for each flag (empty strings for boolean flags)
+}
+
+// has reports whether a flag was given
+func (a *cliArgs) has(flag string) bool {
+	_, ok := a.flags[flag]
+	return ok
+}
+
+// value returns the last value given for a flag, or an empty string
+func (a *cliArgs) value(flag string) string {
+	values := a.flags[flag]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// intValue returns a flag's value as a non-negative integer, or 0 if the flag was not given
+func (a *cliArgs) intValue(flag string) (int, error) {
+	if !a.has(flag) {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(a.value(flag))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", flag)
+	}
+	return n, nil
+}
+
+// durationValue returns a flag's value as a positive duration such as 30s or 5m, or 0 if the flag was not given
+func (a *cliArgs) durationValue(flag string) (time.Duration, error) {
+	if !a.has(flag) {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(a.value(flag))
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 30s or 5m", flag)
+	}
+	return d, nil
+}
+
+// expectArgs validates the number of positional arguments and sets name to the first one
+// A negative max allows any number of arguments
+func (a *cliArgs) expectArgs(min, max int, missing string) error {
+	if len(a.args) < min {
+		return errors.New(missing)
+	}
+	if max >= 0 && len(a.args) > max {
+		return fmt.Errorf("unexpected argument: %s", a.args[max])
+	}
+	if len(a.args) > 0 {
+		a.name = a.args[0]
+	}
+	return nil
+}
 
 func usageText() string {
 	return `Usage: wt <command> [options] [args]
 
 Commands:
+  init          Create the worktrees directory and add it to .gitignore
   jump          Jump to a worktree or repository root
   create        Create a new worktree with branch
   remove        Remove a worktree and its branch (auto-detects if inside worktree)
   list          List all worktrees
-  completion    Generate shell completion script (bash, zsh, fish)
+  config        Get or set configuration values (get, set, list)
+  repo-root     Print the main repository root
+  completion    Generate shell completion script (auto, bash, zsh, fish)
   version       Print version information
 
 Options:
-  --hook <path>    Custom hook script to run after create (default: .worktree-hook)
   -h, --help       Show this help message
 
+Init options:
+  --no-gitignore-check
+                   Do not add the worktrees directory to .gitignore
+
+Jump options:
+  --relative       Print the path relative to the current directory
+  --relative-to <dir>
+                   Print the path relative to dir (also for repo-root)
+  --create         Create the worktree first if it does not exist
+  --yes            With --create, don't ask before creating it in a repository nested inside another
+
+Create options:
+  --hook <path>    Custom hook script to run after create (default: $WT_HOOK, git config wt.hook or .worktree-hook)
+  --no-verify      Skip git hooks (e.g. post-checkout) while adding the worktree
+  --env-file <path>
+                   Add KEY=VALUE lines from path to the hook's environment
+  --quiet-hook     Hide hook output unless the hook fails
+  --hook-retries <n>
+                   Run a failing hook again up to n times, waiting longer each time
+  --hook-timeout <duration>
+                   Kill the hook if a run takes longer than duration (e.g. 30s, 5m; default: 30m)
+  --no-checkout    Register the worktree without checking out files (skips copy and hook)
+  --sparse <pattern>
+                   Check out only paths matching pattern (repeatable)
+  --dry-run        Print what create would do without changing anything
+  --copy <dir>     Also copy dir into the worktree, on top of copy_dirs (repeatable)
+  --base <ref>     Start the branch at ref, e.g. origin/main, HEAD~3 or @{upstream} (default: HEAD)
+  --slug           Turn the name into a slug, e.g. "PROJ-123 Fix login" into proj-123-fix-login
+  --push           Push the new branch to origin and set it as the upstream
+  --open-url       Print and open the page on origin comparing the branch with its base
+  --recurse-submodules
+                   Initialize and update submodules in the new worktree
+  --template-dir <dir>
+                   Copy the contents of dir into the new worktree
+  --on-conflict <policy>
+                   When the branch already exists: error (default), skip or checkout
+  --checkout-existing-ok
+                   Replace a stale worktree left at the target and check out its branch again
+  --from-pr <number>
+                   Fetch a pull request from origin and check out its branch, forks included (needs gh)
+  --attach <branch>
+                   Check out an existing branch instead of creating one
+  --reuse-branch-dir <style>
+                   With --attach or --from-pr and no name, name the worktree after the branch as a slug (default)
+                   or by its last segment (last), e.g. feature/login as feature-login or login
+  --run-hook       Run the hook when checking out an existing branch (--attach, --on-conflict checkout
+                   or --checkout-existing-ok), which skips it by default
+  --detach <ref>   Check out ref, e.g. a release tag, on a detached HEAD instead of a new branch
+  --if-missing     Succeed without changes if the worktree already exists
+  --branch-from-current
+                   Start the branch at the current worktree's HEAD (default: the main repository's HEAD)
+  --after <worktree>
+                   Start the branch at worktree's branch and track it, for stacked branches
+  --no-claude      Don't symlink .claude/ into the worktree; other copies still happen
+  --yes            Don't ask before creating the worktree in a repository nested inside another
+
+Remove options:
+  --keep-dir       Delete only the branch; keep the directory as a detached checkout
+  --archive <dir>  Save a tar.gz of the worktree in dir before removing it
+  --force          Delete the branch even if it has unmerged commits
+  --yes            Don't ask before force deleting an unmerged branch or bulk removing
+  --older-than <duration>
+                   Remove every worktree whose last commit is older than duration (e.g. 30d, 2w, 12h)
+  -k, --keep-going With --older-than or --select, carry on when a worktree fails to be removed
+  --select         List the worktrees and remove the ones you pick by number
+  --no-cd          Don't cd to the repository root after removing the current worktree
+
+List options:
+  --merged [base]  List only worktrees merged into base (default: default branch)
+  --unmerged [base]
+                   List only worktrees not merged into base
+  --limit <n>      Show at most n worktrees
+  --offset <n>     Skip the first n worktrees
+  --attached-only  List only worktrees with a branch checked out
+  --detached-only  List only worktrees on a detached HEAD
+  --check          Mark worktrees git no longer tracks as (stale) and locked ones with their reason
+  --prunable       List only worktrees git can prune, with the reason
+  --branches       Show each worktree's checked out branch instead of its directory
+  --pager          Page output longer than the terminal through $PAGER (default: less -FRX)
+  --no-pager       Never page output, even with --pager
+  --active-first   List the worktree you are in first
+  --paths          Show each worktree's absolute path instead of its name
+  --abbrev         With --paths, show paths under your home directory as ~/...
+  --show-head      Append the short SHA of each worktree's HEAD
+  --name-only      Show only worktree names (the default), refusing flags that change the output
+  --with-branch    Show each worktree's name and checked out branch in two columns
+  --orphan-branches
+                   List local branches no worktree has checked out
+  -0, --null       End each entry with a NUL byte instead of a newline, e.g. for xargs -0
+  --output <format>
+                   Print the entries as text (default), json (an array of worktree objects) or null (same as --null)
+  --exec <command> Run command with sh in each listed worktree and print its output under the name
+
+Version options:
+  --check          Check GitHub for a newer release (nothing is downloaded)
+
 Examples:
+  wt init                    Set up .worktrees/ in the current repository
   wt jump                    Navigate to repository root (from worktree)
   wt jump my-feature         Jump to 'my-feature' worktree
+  wt jump @                  Jump to the repository root from anywhere
+  wt jump my-feature/frontend   Jump to the frontend directory of 'my-feature'
+  wt jump --create feat      Jump to 'feat', creating it if needed
+  wt jump --relative-to ~ feat  Print the path of 'feat' relative to your home directory
   wt create my-feature       Create worktree for 'my-feature' branch
   wt create --hook setup.sh feat    Create worktree, run setup.sh as hook
+  wt create --hook-retries 2 --hook-timeout 5m feat  Retry a failing hook twice, killing runs over 5m
+  wt create --base origin/main feat  Create worktree with 'feat' starting at origin/main
+  wt create --on-conflict checkout feat  Create worktree on 'feat' even if the branch exists
+  wt create --from-pr 123    Create worktree for pull request #123, named after its branch
+  wt create --attach fix-ci  Create worktree on the existing branch fix-ci
+  wt create --attach feature/login --reuse-branch-dir last   Create worktree 'login' on feature/login
+  wt create --detach v2.0.0 release-review  Check out tag v2.0.0 without a branch
+  wt create --if-missing feat   Create worktree 'feat' unless it already exists
+  wt create --branch-from-current feat  Start 'feat' at the HEAD of the worktree you are in
+  wt create --after base-feature next  Stack 'next' on the branch of worktree 'base-feature'
+  wt create --push --open-url feat  Push 'feat' and open the page to start its pull request
   wt remove my-feature       Remove worktree and branch
   wt remove                  Remove current worktree (when inside one)
+  wt remove ./.worktrees/feat   Remove a worktree by its path
+  wt remove --older-than 30d    Remove worktrees without commits in the last 30 days
+  wt remove --select         Pick the worktrees to remove from a numbered list
   wt list                    List all worktrees
+  wt list --merged main      List worktrees whose branch is merged into main
+  wt list --prunable         List worktrees git can prune
+  wt list --paths            List absolute worktree paths, e.g. for xargs
+  wt list --paths --abbrev   List worktree paths with your home directory shortened to ~
+  wt list --show-head        List worktrees with the commit each one is at
+  wt list --detached-only    List worktrees not on a branch, e.g. release tags checked out with --detach
+  wt list --with-branch      List worktree names next to their branches
+  wt list --orphan-branches  List branches left behind without a worktree
+  wt list --paths -0 | xargs -0 du -sh   Show the disk usage of every worktree
+  wt list --output json      Print each worktree's name, path, branch, HEAD and state as JSON
+  wt list --exec 'git status -s'   Show the uncommitted changes in every worktree
+  wt config set copy_dirs .vscode   Copy .vscode/ into new worktrees
   wt completion bash         Generate bash completion script
+  wt completion auto         Generate completion for the shell in $SHELL
   wt version                 Print version information
+  wt version --check         Also report whether a newer release is available
 `
 }
 
@@ -83,119 +320,261 @@ func parseCommand(args []string) (cmd string, startIdx int, err error) {
 	return "", 0, fmt.Errorf("unknown command: %s", args[0])
 }
 
-// parseHookFlag parses the --hook flag from arguments starting at idx
-// Returns the new index, hook path, and any error
-func parseHookFlag(args []string, idx int, defaultHook string) (int, string, error) {
-	hookPath := defaultHook
+// parseFlags separates flags from positional arguments starting at idx
+// Flags may appear anywhere after the command; flags not listed in specs are rejected
+func parseFlags(args []string, idx int, specs []flagSpec) (map[string][]string, []string, error) {
+	flags := map[string][]string{}
+	positional := []string{}
+
+	for ; idx < len(args); idx++ {
+		arg := args[idx]
+		if len(arg) == 0 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
 
-	for idx < len(args) {
-		if args[idx] == "--hook" {
-			if idx+1 >= len(args) {
-				return 0, "", fmt.Errorf("--hook requires a path argument")
+		var spec *flagSpec
+		for i := range specs {
+			if specs[i].name == arg || (specs[i].short != "" && specs[i].short == arg) {
+				spec = &specs[i]
+				break
 			}
-			hookPath = args[idx+1]
-			idx += 2
-		} else if len(args[idx]) > 0 && args[idx][0] == '-' {
-			return 0, "", fmt.Errorf("unknown flag %s", args[idx])
-		} else {
-			break
 		}
+		if spec == nil {
+			return nil, nil, fmt.Errorf("unknown flag %s", arg)
+		}
+
+		if spec.arg == "" {
+			flags[spec.name] = append(flags[spec.name], "")
+			continue
+		}
+		if idx+1 >= len(args) {
+			return nil, nil, fmt.Errorf("%s requires a %s argument", arg, spec.arg)
+		}
+		idx++
+		flags[spec.name] = append(flags[spec.name], args[idx])
 	}
 
-	return idx, hookPath, nil
+	return flags, positional, nil
 }
 
-// parseArgs parses command line arguments and returns (command, name, hookPath, error)
-func parseArgs(args []string) (cmd string, name string, hookPath string, err error) {
+// parseArgs parses command line arguments
+func parseArgs(args []string) (*cliArgs, error) {
 	if len(args) == 0 {
-		return "", "", "", errShowHelp
+		return nil, errShowHelp
 	}
 
 	if isHelpRequested(args) {
-		return "", "", "", errShowHelp
+		return nil, errShowHelp
 	}
 
 	cmd, idx, err := parseCommand(args)
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
-	// Parse hook flag
-	idx, hookPath, err = parseHookFlag(args, idx, DefaultHook)
+	flags, positional, err := parseFlags(args, idx, commandFlags[cmd])
 	if err != nil {
-		return "", "", "", err
+		return nil, err
 	}
 
-	// jump command takes an optional worktree name
-	if cmd == "jump" {
-		if idx < len(args) {
-			name = args[idx]
-			if idx+1 < len(args) {
-				return "", "", "", fmt.Errorf("unexpected argument: %s", args[idx+1])
+	a := &cliArgs{cmd: cmd, args: positional, flags: flags}
+
+	switch cmd {
+	case "jump":
+		if a.has("--relative") && a.has("--relative-to") {
+			return nil, fmt.Errorf("cannot combine --relative and --relative-to")
+		}
+		if a.has("--yes") && !a.has("--create") {
+			return nil, fmt.Errorf("--yes requires --create")
+		}
+		// jump command takes an optional worktree name, which --create requires
+		if a.has("--create") {
+			err = a.expectArgs(1, 1, "--create requires a worktree name")
+		} else {
+			err = a.expectArgs(0, 1, "")
+		}
+	case "list":
+		// list command takes a base branch only when filtering by merge status
+		if a.has("--merged") && a.has("--unmerged") {
+			return nil, fmt.Errorf("cannot combine --merged and --unmerged")
+		}
+		if a.has("--prunable") && (a.has("--merged") || a.has("--unmerged") || a.has("--check") || a.has("--branches") || a.has("--paths") || a.has("--show-head")) {
+			return nil, fmt.Errorf("cannot combine --prunable with --merged, --unmerged, --check, --branches, --paths or --show-head")
+		}
+		if a.has("--attached-only") && a.has("--detached-only") {
+			return nil, fmt.Errorf("cannot combine --attached-only and --detached-only")
+		}
+		// Prunable entries and orphan branches are not worktrees with a HEAD to filter on
+		if a.has("--attached-only") || a.has("--detached-only") {
+			for _, flag := range []string{"--prunable", "--orphan-branches"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --attached-only or --detached-only with %s", flag)
+				}
 			}
 		}
-		return cmd, name, hookPath, nil
-	}
-
-	// list command takes no additional arguments
-	if cmd == "list" {
-		if idx < len(args) {
-			return "", "", "", fmt.Errorf("unexpected argument: %s", args[idx])
+		if a.has("--abbrev") && !a.has("--paths") {
+			return nil, fmt.Errorf("--abbrev requires --paths")
 		}
-		return cmd, "", hookPath, nil
-	}
-
-	// version command takes no additional arguments
-	if cmd == "version" {
-		if idx < len(args) {
-			return "", "", "", fmt.Errorf("unexpected argument: %s", args[idx])
+		if a.has("--paths") && a.has("--branches") {
+			return nil, fmt.Errorf("cannot combine --paths and --branches")
 		}
-		return cmd, "", hookPath, nil
-	}
-
-	// completion command takes a shell name
-	if cmd == "completion" {
-		if idx >= len(args) {
-			return "", "", "", fmt.Errorf("shell name required (bash, zsh, fish)")
+		if a.has("--orphan-branches") {
+			for _, flag := range []string{"--merged", "--unmerged", "--check", "--prunable", "--branches", "--paths", "--show-head", "--active-first"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --orphan-branches and %s", flag)
+				}
+			}
 		}
-		name = args[idx]
-		if idx+1 < len(args) {
-			return "", "", "", fmt.Errorf("unexpected argument: %s", args[idx+1])
+		// The explicit output modes each fix what is printed, so they refuse the flags that change it
+		if a.has("--name-only") {
+			for _, flag := range []string{"--with-branch", "--branches", "--paths", "--show-head", "--check", "--prunable", "--orphan-branches", "--exec"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --name-only and %s", flag)
+				}
+			}
 		}
-		return cmd, name, hookPath, nil
-	}
-
-	// __complete command takes a subcommand name
-	if cmd == "__complete" {
-		if idx >= len(args) {
-			return "", "", "", fmt.Errorf("subcommand required")
+		if a.has("--with-branch") {
+			for _, flag := range []string{"--branches", "--prunable", "--orphan-branches", "--exec"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --with-branch and %s", flag)
+				}
+			}
+		}
+		if a.has("--exec") {
+			for _, flag := range []string{"--prunable", "--orphan-branches", "--check", "--branches", "--paths", "--show-head", "--null", "--output"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --exec and %s", flag)
+				}
+			}
+		}
+		if err := checkOutputFormat(a.value("--output")); err != nil {
+			return nil, err
+		}
+		// JSON objects carry the branch, HEAD and state themselves, so the flags that decorate text labels don't apply
+		if a.value("--output") == OutputJSON {
+			for _, flag := range []string{"--with-branch", "--branches", "--paths", "--show-head", "--check", "--prunable", "--orphan-branches"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --output json and %s", flag)
+				}
+			}
+		}
+		// --null is short for --output null
+		if a.has("--null") && a.has("--output") {
+			return nil, fmt.Errorf("cannot combine --null and --output")
+		}
+		// A pager shows NUL bytes as garbage, and --null and --output json are meant for other programs
+		if a.has("--null") && a.has("--pager") {
+			return nil, fmt.Errorf("cannot combine --null and --pager")
+		}
+		if output := a.value("--output"); a.has("--pager") && output != "" && output != OutputText {
+			return nil, fmt.Errorf("cannot combine --output %s and --pager", output)
+		}
+		maxArgs := 0
+		if a.has("--merged") || a.has("--unmerged") {
+			maxArgs = 1
+		}
+		err = a.expectArgs(0, maxArgs, "")
+	case "init", "repo-root", "version":
+		err = a.expectArgs(0, 0, "")
+	case "config":
+		// config command passes its arguments through to configCmd
+	case "completion":
+		err = a.expectArgs(1, 1, "shell name required (auto, bash, zsh, fish)")
+	case "__complete":
+		err = a.expectArgs(1, -1, "subcommand required")
+	case "remove":
+		// remove command: name is optional (can detect from current worktree)
+		if a.has("--archive") && a.has("--keep-dir") {
+			return nil, fmt.Errorf("cannot combine --archive and --keep-dir")
+		}
+		if a.has("--keep-going") && !a.has("--older-than") && !a.has("--select") {
+			return nil, fmt.Errorf("--keep-going requires --older-than or --select")
+		}
+		if a.has("--select") && a.has("--older-than") {
+			return nil, fmt.Errorf("cannot combine --select and --older-than")
+		}
+		maxArgs := 1
+		if a.has("--older-than") || a.has("--select") {
+			maxArgs = 0
+		}
+		err = a.expectArgs(0, maxArgs, "")
+	default: // create
+		if a.has("--sparse") && a.has("--no-checkout") {
+			return nil, fmt.Errorf("cannot combine --sparse and --no-checkout")
+		}
+		if _, err := a.intValue("--hook-retries"); err != nil {
+			return nil, err
+		}
+		if _, err := a.durationValue("--hook-timeout"); err != nil {
+			return nil, err
+		}
+		switch policy := a.value("--on-conflict"); policy {
+		case "", OnConflictError, OnConflictSkip, OnConflictCheckout:
+		default:
+			return nil, fmt.Errorf("invalid --on-conflict %q (use error, skip or checkout)", policy)
+		}
+		// Only used by --attach without a name, so a configured default does not get in the way of other creates
+		switch style := a.value("--reuse-branch-dir"); style {
+		case "", BranchDirSlug, BranchDirLast:
+		default:
+			return nil, fmt.Errorf("invalid --reuse-branch-dir %q (use slug or last)", style)
+		}
+		// A detached worktree has no branch to start elsewhere, check out or push
+		if a.has("--detach") {
+			for _, flag := range []string{"--base", "--on-conflict", "--from-pr", "--attach", "--branch-from-current", "--push", "--open-url"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --detach and %s", flag)
+				}
+			}
+		}
+		if a.has("--branch-from-current") {
+			for _, flag := range []string{"--base", "--from-pr", "--attach"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --branch-from-current and %s", flag)
+				}
+			}
+		}
+		// A stacked branch starts at the other worktree's branch, which rules out the other starting points
+		if a.has("--after") {
+			for _, flag := range []string{"--base", "--from-pr", "--attach", "--detach", "--branch-from-current"} {
+				if a.has(flag) {
+					return nil, fmt.Errorf("cannot combine --after and %s", flag)
+				}
+			}
+		}
+		// Only these check out an existing branch, whose hook is skipped by default
+		if a.has("--run-hook") && !a.has("--attach") && a.value("--on-conflict") != OnConflictCheckout && !a.has("--checkout-existing-ok") {
+			return nil, fmt.Errorf("--run-hook requires --attach, --on-conflict checkout or --checkout-existing-ok")
+		}
+		if a.has("--from-pr") {
+			if n, err := a.intValue("--from-pr"); err != nil || n == 0 {
+				return nil, fmt.Errorf("--from-pr must be a pull request number")
+			}
+			if a.has("--base") || a.has("--dry-run") {
+				return nil, fmt.Errorf("cannot combine --from-pr with --base or --dry-run")
+			}
+			// The worktree is named after the pull request's branch unless a name is given
+			err = a.expectArgs(0, 1, "")
+		} else if a.has("--attach") {
+			if a.has("--base") || a.has("--on-conflict") || a.has("--from-pr") {
+				return nil, fmt.Errorf("cannot combine --attach with --base, --on-conflict or --from-pr")
+			}
+			// The worktree is named after the branch unless a name is given
+			err = a.expectArgs(0, 1, "")
+		} else {
+			err = a.expectArgs(1, 1, "branch name required")
 		}
-		name = args[idx]
-		return cmd, name, hookPath, nil
-	}
-
-	// remove command: name is optional (can detect from current worktree)
-	if cmd == "remove" && idx >= len(args) {
-		return cmd, "", hookPath, nil
-	}
-
-	// Remaining arg should be the name
-	if idx >= len(args) {
-		return "", "", "", fmt.Errorf("branch name required")
 	}
-
-	name = args[idx]
-
-	// Validate no extra args
-	if idx+1 < len(args) {
-		return "", "", "", fmt.Errorf("unexpected argument: %s", args[idx+1])
+	if err != nil {
+		return nil, err
 	}
 
-	return cmd, name, hookPath, nil
+	return a, nil
 }
 
 // runRemove executes the remove command, detecting current worktree if name is empty
-func runRemove(name string) error {
+func runRemove(name string, opts removeOptions) error {
 	if name == "" {
 		wm, err := NewWorktreeManager()
 		if err != nil {
@@ -207,32 +586,151 @@ func runRemove(name string) error {
 			return fmt.Errorf("not inside a worktree (specify branch name)")
 		}
 	}
-	return remove(name)
+	return remove(name, opts)
+}
+
+// listOptionsFromArgs builds listOptions from the parsed list flags
+func listOptionsFromArgs(a *cliArgs) (listOptions, error) {
+	opts := listOptions{
+		merged:   a.has("--merged"),
+		unmerged: a.has("--unmerged"),
+		base:     a.name,
+		attached: a.has("--attached-only"),
+		detached: a.has("--detached-only"),
+		check:    a.has("--check"),
+		prunable: a.has("--prunable"),
+		branches: a.has("--branches"),
+		active:   a.has("--active-first"),
+		paths:    a.has("--paths"),
+		abbrev:   a.has("--abbrev"),
+		head:     a.has("--show-head"),
+		columns:  a.has("--with-branch"),
+		orphans:  a.has("--orphan-branches"),
+		output:   a.value("--output"),
+		exec:     a.value("--exec"),
+	}
+	if a.has("--null") {
+		opts.output = OutputNull
+	}
+	var err error
+	if opts.limit, err = a.intValue("--limit"); err != nil {
+		return listOptions{}, err
+	}
+	if opts.offset, err = a.intValue("--offset"); err != nil {
+		return listOptions{}, err
+	}
+	return opts, nil
+}
+
+// warnOnConfigProblems warns about skipped config lines for commands that read the config
+// create refuses a broken config and config reports problems itself, so they are not warned here
+func warnOnConfigProblems(cmd string) {
+	switch cmd {
+	case "init", "jump", "remove", "list":
+	default:
+		return
+	}
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return // the command reports the error itself
+	}
+	warnConfigProblems(os.Stderr, wm.ConfigProblems())
 }
 
 // run executes the CLI with the given arguments
 func run(args []string) error {
-	cmd, name, hookPath, err := parseArgs(args)
+	a, err := parseArgs(args)
 	if err != nil {
 		return err
 	}
+	warnOnConfigProblems(a.cmd)
+	if a, err = withFlagDefaults(args, a); err != nil {
+		return err
+	}
 
-	switch cmd {
+	switch a.cmd {
+	case "init":
+		return initCmd(initOptions{noGitignoreCheck: a.has("--no-gitignore-check")})
 	case "jump":
-		return jump(name)
+		return jump(a.name, jumpOptions{relative: a.has("--relative"), relativeTo: a.value("--relative-to"), create: a.has("--create"), yes: a.has("--yes")})
 	case "create":
-		return create(name, hookPath)
+		// Both were validated by parseArgs
+		hookRetries, _ := a.intValue("--hook-retries")
+		hookTimeout, _ := a.durationValue("--hook-timeout")
+		return create(a.name, createOptions{
+			hookPath:     a.value("--hook"),
+			noVerify:     a.has("--no-verify"),
+			envFile:      a.value("--env-file"),
+			quietHook:    a.has("--quiet-hook"),
+			noCheckout:   a.has("--no-checkout"),
+			sparse:       a.flags["--sparse"],
+			dryRun:       a.has("--dry-run"),
+			copyDirs:     a.flags["--copy"],
+			base:         a.value("--base"),
+			slug:         a.has("--slug"),
+			push:         a.has("--push"),
+			openURL:      a.has("--open-url"),
+			submodules:   a.has("--recurse-submodules"),
+			templateDir:  a.value("--template-dir"),
+			onConflict:   a.value("--on-conflict"),
+			replaceStale: a.has("--checkout-existing-ok"),
+			fromPR:       a.value("--from-pr"),
+			attach:       a.value("--attach"),
+			branchDir:    a.value("--reuse-branch-dir"),
+			detach:       a.value("--detach"),
+			ifMissing:    a.has("--if-missing"),
+			fromCurrent:  a.has("--branch-from-current"),
+			after:        a.value("--after"),
+			noClaude:     a.has("--no-claude"),
+			hookRetries:  hookRetries,
+			hookTimeout:  hookTimeout,
+			runHook:      a.has("--run-hook"),
+			yes:          a.has("--yes"),
+		})
 	case "remove":
-		return runRemove(name)
+		opts := removeOptions{
+			keepDir:    a.has("--keep-dir"),
+			archiveDir: a.value("--archive"),
+			force:      a.has("--force"),
+			yes:        a.has("--yes"),
+			keepGoing:  a.has("--keep-going"),
+			noCd:       a.has("--no-cd"),
+		}
+		if a.has("--older-than") {
+			return removeOlderThan(a.value("--older-than"), opts)
+		}
+		if a.has("--select") {
+			return removeSelected(opts)
+		}
+		return runRemove(a.name, opts)
 	case "list":
-		return list(os.Stdout)
+		opts, err := listOptionsFromArgs(a)
+		if err != nil {
+			return err
+		}
+		// --no-pager wins so it can override --pager in an alias
+		if a.has("--pager") && !a.has("--no-pager") {
+			var buf bytes.Buffer
+			if err := list(&buf, opts); err != nil {
+				return err
+			}
+			return writePaged(buf.String())
+		}
+		return list(os.Stdout, opts)
+	case "config":
+		return configCmd(a.args, os.Stdout)
+	case "repo-root":
+		return repoRoot(os.Stdout, a.value("--relative-to"))
 	case "completion":
-		return completion(name, os.Stdout)
+		return completion(a.name, os.Stdout)
 	case "version":
+		if a.has("--check") {
+			return checkVersion(os.Stdout)
+		}
 		return version(os.Stdout)
 	default: // __complete
-		if name == "remove" || name == "jump" {
-			return completeWorktrees(os.Stdout)
+		if a.name == "remove" || a.name == "jump" {
+			return completeWorktrees(os.Stdout, a.has("--descriptions"), a.name == "jump")
 		}
 		return nil
 	}
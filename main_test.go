@@ -3,8 +3,11 @@ package main
 import (
 	"bytes"
 	"errors"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime/debug"
 	"strings"
 	"testing"
@@ -59,8 +62,10 @@ func TestIsValidCommand(t *testing.T) {
 	}{
 		{"create", "create", true},
 		{"remove", "remove", true},
+		{"init", "init", true},
 		{"jump", "jump", true},
 		{"list", "list", true},
+		{"config", "config", true},
 		{"completion", "completion", true},
 		{"version", "version", true},
 		{"__complete", "__complete", true},
@@ -141,42 +146,156 @@ func TestParseCommand(t *testing.T) {
 	}
 }
 
-func TestParseHookFlag(t *testing.T) {
+func TestParseFlags(t *testing.T) {
+	specs := []flagSpec{{name: "--hook", arg: "path", short: "-H"}, {name: "--merged"}, {name: "--keep-going", short: "-k"}}
+
 	tests := []struct {
 		name       string
 		args       []string
 		idx        int
-		wantIdx    int
-		wantHook   string
+		wantFlags  string
+		wantArgs   []string
 		wantErrMsg string
 	}{
-		{"no hook", []string{"foo"}, 0, 0, DefaultHook, ""},
-		{"with hook", []string{"--hook", "setup.sh", "foo"}, 0, 2, "setup.sh", ""},
-		{"hook missing value", []string{"--hook"}, 0, 0, "", "--hook requires a path argument"},
-		{"unknown flag", []string{"-x", "foo"}, 0, 0, "", "unknown flag -x"},
+		{"no flags", []string{"foo"}, 0, "", []string{"foo"}, ""},
+		{"with hook", []string{"--hook", "setup.sh", "foo"}, 0, "--hook=setup.sh", []string{"foo"}, ""},
+		{"flag after positional", []string{"foo", "--hook", "setup.sh"}, 0, "--hook=setup.sh", []string{"foo"}, ""},
+		{"boolean flag", []string{"--merged", "main"}, 0, "--merged=", []string{"main"}, ""},
+		{"short alias", []string{"-k", "main"}, 0, "--keep-going=", []string{"main"}, ""},
+		{"short alias with value", []string{"-H", "setup.sh"}, 0, "--hook=setup.sh", []string{}, ""},
+		{"short alias missing value", []string{"-H"}, 0, "", nil, "-H requires a path argument"},
+		{"starts at idx", []string{"create", "foo"}, 1, "", []string{"foo"}, ""},
+		{"hook missing value", []string{"--hook"}, 0, "", nil, "--hook requires a path argument"},
+		{"unknown flag", []string{"-x", "foo"}, 0, "", nil, "unknown flag -x"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			idx, hook, err := parseHookFlag(tt.args, tt.idx, DefaultHook)
+			flags, positional, err := parseFlags(tt.args, tt.idx, specs)
 
 			if tt.wantErrMsg != "" {
 				if err == nil || err.Error() != tt.wantErrMsg {
-					t.Errorf("parseHookFlag() error = %v, want %q", err, tt.wantErrMsg)
+					t.Errorf("parseFlags() error = %v, want %q", err, tt.wantErrMsg)
 				}
 				return
 			}
 
 			if err != nil {
-				t.Errorf("parseHookFlag() unexpected error: %v", err)
+				t.Errorf("parseFlags() unexpected error: %v", err)
 				return
 			}
 
-			if idx != tt.wantIdx {
-				t.Errorf("parseHookFlag() idx = %d, want %d", idx, tt.wantIdx)
+			var gotFlags []string
+			for name, values := range flags {
+				for _, v := range values {
+					gotFlags = append(gotFlags, name+"="+v)
+				}
+			}
+			if strings.Join(gotFlags, " ") != tt.wantFlags {
+				t.Errorf("parseFlags() flags = %v, want %q", gotFlags, tt.wantFlags)
+			}
+			if strings.Join(positional, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("parseFlags() positional = %v, want %v", positional, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCliArgsValue(t *testing.T) {
+	a := &cliArgs{flags: map[string][]string{"--hook": {"first.sh", "second.sh"}}}
+	if got := a.value("--hook"); got != "second.sh" {
+		t.Errorf("value(--hook) = %q, want last value %q", got, "second.sh")
+	}
+	if got := a.value("--merged"); got != "" {
+		t.Errorf("value(--merged) = %q, want empty", got)
+	}
+}
+
+func TestCliArgsIntValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   map[string][]string
+		want    int
+		wantErr bool
+	}{
+		{"not given", map[string][]string{}, 0, false},
+		{"valid", map[string][]string{"--limit": {"5"}}, 5, false},
+		{"last value wins", map[string][]string{"--limit": {"5", "7"}}, 7, false},
+		{"not a number", map[string][]string{"--limit": {"five"}}, 0, true},
+		{"negative", map[string][]string{"--limit": {"-1"}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &cliArgs{flags: tt.flags}
+			got, err := a.intValue("--limit")
+			if tt.wantErr {
+				if err == nil || err.Error() != "--limit must be a non-negative integer" {
+					t.Errorf("intValue() error = %v, want non-negative integer error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("intValue() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("intValue() = %d, want %d", got, tt.want)
 			}
-			if hook != tt.wantHook {
-				t.Errorf("parseHookFlag() hook = %q, want %q", hook, tt.wantHook)
+		})
+	}
+}
+
+func TestListOptionsFromArgs(t *testing.T) {
+	t.Run("prunable", func(t *testing.T) {
+		a, err := parseArgs([]string{"list", "--prunable", "--limit", "3"})
+		if err != nil {
+			t.Fatalf("parseArgs() unexpected error: %v", err)
+		}
+		opts, err := listOptionsFromArgs(a)
+		if err != nil {
+			t.Fatalf("listOptionsFromArgs() unexpected error: %v", err)
+		}
+		want := listOptions{prunable: true, limit: 3}
+		if opts != want {
+			t.Errorf("listOptionsFromArgs() = %+v, want %+v", opts, want)
+		}
+	})
+
+	t.Run("all options", func(t *testing.T) {
+		a, err := parseArgs([]string{"list", "--merged", "main", "--limit", "3", "--offset", "2", "--check", "--branches", "-0"})
+		if err != nil {
+			t.Fatalf("parseArgs() unexpected error: %v", err)
+		}
+		opts, err := listOptionsFromArgs(a)
+		if err != nil {
+			t.Fatalf("listOptionsFromArgs() unexpected error: %v", err)
+		}
+		want := listOptions{merged: true, base: "main", limit: 3, offset: 2, check: true, branches: true, output: OutputNull}
+		if opts != want {
+			t.Errorf("listOptionsFromArgs() = %+v, want %+v", opts, want)
+		}
+	})
+
+	t.Run("output format", func(t *testing.T) {
+		a, err := parseArgs([]string{"list", "--output", "json"})
+		if err != nil {
+			t.Fatalf("parseArgs() unexpected error: %v", err)
+		}
+		if opts, err := listOptionsFromArgs(a); err != nil || opts.output != OutputJSON {
+			t.Errorf("listOptionsFromArgs() = %+v, %v; want output %q", opts, err, OutputJSON)
+		}
+	})
+
+	for _, flag := range []string{"--limit", "--offset"} {
+		t.Run("invalid "+flag, func(t *testing.T) {
+			a, err := parseArgs([]string{"list", flag, "x"})
+			if err != nil {
+				t.Fatalf("parseArgs() unexpected error: %v", err)
+			}
+			_, err = listOptionsFromArgs(a)
+			want := flag + " must be a non-negative integer"
+			if err == nil || err.Error() != want {
+				t.Errorf("listOptionsFromArgs() error = %v, want %q", err, want)
 			}
 		})
 	}
@@ -189,6 +308,7 @@ func TestParseArgs(t *testing.T) {
 		wantCmd    string
 		wantName   string
 		wantHook   string
+		wantArgs   []string
 		wantErr    error
 		wantErrMsg string
 	}{
@@ -222,14 +342,14 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"create", "my-feature"},
 			wantCmd:  "create",
 			wantName: "my-feature",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:     "remove command",
 			args:     []string{"remove", "my-feature"},
 			wantCmd:  "remove",
 			wantName: "my-feature",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:       "hook without command is unknown flag",
@@ -278,12 +398,173 @@ func TestParseArgs(t *testing.T) {
 			args:       []string{"create"},
 			wantErrMsg: "branch name required",
 		},
+		{
+			name:       "repo-root takes no arguments",
+			args:       []string{"repo-root", "extra"},
+			wantErrMsg: "unexpected argument: extra",
+		},
+		{
+			name:    "init command",
+			args:    []string{"init", "--no-gitignore-check"},
+			wantCmd: "init",
+		},
+		{
+			name:       "init takes no arguments",
+			args:       []string{"init", "extra"},
+			wantErrMsg: "unexpected argument: extra",
+		},
+		{
+			name:     "list with exec",
+			args:     []string{"list", "--exec", "git status -s", "--merged", "main"},
+			wantCmd:  "list",
+			wantName: "main",
+		},
+		{
+			name:       "list with exec and paths",
+			args:       []string{"list", "--exec", "pwd", "--paths"},
+			wantErrMsg: "cannot combine --exec and --paths",
+		},
+		{
+			name:       "list with exec and show-head",
+			args:       []string{"list", "--exec", "pwd", "--show-head"},
+			wantErrMsg: "cannot combine --exec and --show-head",
+		},
+		{
+			name:     "list with name-only",
+			args:     []string{"list", "--name-only", "--merged", "main"},
+			wantCmd:  "list",
+			wantName: "main",
+		},
+		{
+			name:       "list with name-only and paths",
+			args:       []string{"list", "--name-only", "--paths"},
+			wantErrMsg: "cannot combine --name-only and --paths",
+		},
+		{
+			name:    "list with with-branch",
+			args:    []string{"list", "--with-branch", "--paths"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list with with-branch and branches",
+			args:       []string{"list", "--with-branch", "--branches"},
+			wantErrMsg: "cannot combine --with-branch and --branches",
+		},
+		{
+			name:    "list with show-head",
+			args:    []string{"list", "--show-head", "--branches"},
+			wantCmd: "list",
+		},
+		{
+			name:    "list with null",
+			args:    []string{"list", "-0", "--paths"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list with null and pager",
+			args:       []string{"list", "--null", "--pager"},
+			wantErrMsg: "cannot combine --null and --pager",
+		},
+		{
+			name:    "list detached only",
+			args:    []string{"list", "--detached-only", "--with-branch"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list attached and detached only",
+			args:       []string{"list", "--attached-only", "--detached-only"},
+			wantErrMsg: "cannot combine --attached-only and --detached-only",
+		},
+		{
+			name:       "list attached only with orphan branches",
+			args:       []string{"list", "--attached-only", "--orphan-branches"},
+			wantErrMsg: "cannot combine --attached-only or --detached-only with --orphan-branches",
+		},
+		{
+			name:    "list with output",
+			args:    []string{"list", "--output", "json", "--active-first"},
+			wantCmd: "list",
+		},
+		{
+			name:    "list with text output and pager",
+			args:    []string{"list", "--output", "text", "--pager"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list with unknown output",
+			args:       []string{"list", "--output", "yaml"},
+			wantErrMsg: `invalid --output "yaml" (use text, json or null)`,
+		},
+		{
+			name:       "list with json output and with-branch",
+			args:       []string{"list", "--output", "json", "--with-branch"},
+			wantErrMsg: "cannot combine --output json and --with-branch",
+		},
+		{
+			name:       "list with json output and check",
+			args:       []string{"list", "--check", "--output", "json"},
+			wantErrMsg: "cannot combine --output json and --check",
+		},
+		{
+			name:    "list with text output and with-branch",
+			args:    []string{"list", "--output", "text", "--with-branch"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list with json output and pager",
+			args:       []string{"list", "--output", "json", "--pager"},
+			wantErrMsg: "cannot combine --output json and --pager",
+		},
+		{
+			name:       "list with null and output",
+			args:       []string{"list", "-0", "--output", "null"},
+			wantErrMsg: "cannot combine --null and --output",
+		},
+		{
+			name:       "list with exec and output",
+			args:       []string{"list", "--exec", "pwd", "--output", "json"},
+			wantErrMsg: "cannot combine --exec and --output",
+		},
+		{
+			name:     "jump with relative",
+			args:     []string{"jump", "--relative", "my-feature"},
+			wantCmd:  "jump",
+			wantName: "my-feature",
+		},
+		{
+			name:     "jump with relative to",
+			args:     []string{"jump", "--relative-to", "/tmp", "my-feature"},
+			wantCmd:  "jump",
+			wantName: "my-feature",
+		},
+		{
+			name:       "jump with relative and relative to",
+			args:       []string{"jump", "--relative", "--relative-to", "/tmp", "my-feature"},
+			wantErrMsg: "cannot combine --relative and --relative-to",
+		},
+		{
+			name:    "repo-root with relative to",
+			args:    []string{"repo-root", "--relative-to", "/tmp"},
+			wantCmd: "repo-root",
+		},
+		{
+			name:     "remove with no-cd",
+			args:     []string{"remove", "--no-cd"},
+			wantCmd:  "remove",
+			wantName: "",
+		},
+		{
+			name:     "remove with keep-dir",
+			args:     []string{"remove", "--keep-dir", "my-feature"},
+			wantCmd:  "remove",
+			wantName: "my-feature",
+		},
 		{
 			name:     "remove without name (auto-detect)",
 			args:     []string{"remove"},
 			wantCmd:  "remove",
 			wantName: "",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:       "extra argument",
@@ -300,14 +581,14 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"jump"},
 			wantCmd:  "jump",
 			wantName: "",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:     "jump command with name",
 			args:     []string{"jump", "my-feature"},
 			wantCmd:  "jump",
 			wantName: "my-feature",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:       "jump command with extra arg",
@@ -319,7 +600,7 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"list"},
 			wantCmd:  "list",
 			wantName: "",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:       "list command with extra arg",
@@ -331,26 +612,26 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"completion", "bash"},
 			wantCmd:  "completion",
 			wantName: "bash",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:     "completion command zsh",
 			args:     []string{"completion", "zsh"},
 			wantCmd:  "completion",
 			wantName: "zsh",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:     "completion command fish",
 			args:     []string{"completion", "fish"},
 			wantCmd:  "completion",
 			wantName: "fish",
-			wantHook: DefaultHook,
+			wantHook: "",
 		},
 		{
 			name:       "completion without shell",
 			args:       []string{"completion"},
-			wantErrMsg: "shell name required (bash, zsh, fish)",
+			wantErrMsg: "shell name required (auto, bash, zsh, fish)",
 		},
 		{
 			name:       "completion with extra arg",
@@ -362,7 +643,13 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"__complete", "remove"},
 			wantCmd:  "__complete",
 			wantName: "remove",
-			wantHook: DefaultHook,
+			wantHook: "",
+		},
+		{
+			name:     "__complete jump with descriptions",
+			args:     []string{"__complete", "jump", "--descriptions"},
+			wantCmd:  "__complete",
+			wantName: "jump",
 		},
 		{
 			name:       "__complete without subcommand",
@@ -374,18 +661,448 @@ func TestParseArgs(t *testing.T) {
 			args:     []string{"version"},
 			wantCmd:  "version",
 			wantName: "",
-			wantHook: DefaultHook,
+			wantHook: "",
+		},
+		{
+			name:    "version command with check",
+			args:    []string{"version", "--check"},
+			wantCmd: "version",
 		},
 		{
 			name:       "version command with extra arg",
 			args:       []string{"version", "extra"},
 			wantErrMsg: "unexpected argument: extra",
 		},
+		{
+			name:     "create with hook after name",
+			args:     []string{"create", "my-feature", "--hook", "setup.sh"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+			wantHook: "setup.sh",
+		},
+		{
+			name:     "create with no-verify",
+			args:     []string{"create", "--no-verify", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:     "create with env file",
+			args:     []string{"create", "--env-file", "dev.env", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:       "create env file without value",
+			args:       []string{"create", "my-feature", "--env-file"},
+			wantErrMsg: "--env-file requires a path argument",
+		},
+		{
+			name:     "create with quiet hook",
+			args:     []string{"create", "--quiet-hook", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:     "create with repeated copy",
+			args:     []string{"create", "--copy", ".idea", "my-feature", "--copy", "fixtures"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:     "create with base",
+			args:     []string{"create", "--base", "@{upstream}", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:     "create with slug",
+			args:     []string{"create", "--slug", "PROJ-123 Fix login"},
+			wantCmd:  "create",
+			wantName: "PROJ-123 Fix login",
+		},
+		{
+			name:       "create copy without value",
+			args:       []string{"create", "my-feature", "--copy"},
+			wantErrMsg: "--copy requires a dir argument",
+		},
+		{
+			name:       "create sparse with no checkout",
+			args:       []string{"create", "--sparse", "web", "--no-checkout", "my-feature"},
+			wantErrMsg: "cannot combine --sparse and --no-checkout",
+		},
+		{
+			name:       "remove archive with keep dir",
+			args:       []string{"remove", "--archive", "/tmp/archives", "--keep-dir", "feat"},
+			wantErrMsg: "cannot combine --archive and --keep-dir",
+		},
+		{
+			name:    "remove older than",
+			args:    []string{"remove", "--older-than", "30d", "--yes"},
+			wantCmd: "remove",
+		},
+		{
+			name:       "remove older than with a name",
+			args:       []string{"remove", "--older-than", "30d", "feat"},
+			wantErrMsg: "unexpected argument: feat",
+		},
+		{
+			name:     "remove force yes",
+			args:     []string{"remove", "--force", "--yes", "feat"},
+			wantCmd:  "remove",
+			wantName: "feat",
+		},
+		{
+			name:     "remove archive",
+			args:     []string{"remove", "--archive", "/tmp/archives", "feat"},
+			wantCmd:  "remove",
+			wantName: "feat",
+		},
+		{
+			name:     "jump create",
+			args:     []string{"jump", "--create", "my-feature"},
+			wantCmd:  "jump",
+			wantName: "my-feature",
+		},
+		{
+			name:     "jump create yes",
+			args:     []string{"jump", "--create", "--yes", "my-feature"},
+			wantCmd:  "jump",
+			wantName: "my-feature",
+		},
+		{
+			name:       "jump yes without create",
+			args:       []string{"jump", "--yes", "my-feature"},
+			wantErrMsg: "--yes requires --create",
+		},
+		{
+			name:     "create yes",
+			args:     []string{"create", "--yes", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:       "jump create without name",
+			args:       []string{"jump", "--create"},
+			wantErrMsg: "--create requires a worktree name",
+		},
+		{
+			name:     "create dry run",
+			args:     []string{"create", "--dry-run", "my-feature"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:     "create with no checkout",
+			args:     []string{"create", "my-feature", "--no-checkout"},
+			wantCmd:  "create",
+			wantName: "my-feature",
+		},
+		{
+			name:       "hook is only accepted by create",
+			args:       []string{"list", "--hook", "setup.sh"},
+			wantErrMsg: "unknown flag --hook",
+		},
+		{
+			name:     "list merged with base",
+			args:     []string{"list", "--merged", "main"},
+			wantCmd:  "list",
+			wantName: "main",
+		},
+		{
+			name:     "list unmerged without base",
+			args:     []string{"list", "--unmerged"},
+			wantCmd:  "list",
+			wantName: "",
+		},
+		{
+			name:       "list merged with extra arg",
+			args:       []string{"list", "--merged", "main", "extra"},
+			wantErrMsg: "unexpected argument: extra",
+		},
+		{
+			name:       "list merged and unmerged",
+			args:       []string{"list", "--merged", "--unmerged"},
+			wantErrMsg: "cannot combine --merged and --unmerged",
+		},
+		{
+			name:       "list prunable with check",
+			args:       []string{"list", "--prunable", "--check"},
+			wantErrMsg: "cannot combine --prunable with --merged, --unmerged, --check, --branches, --paths or --show-head",
+		},
+		{
+			name:       "list prunable with merged",
+			args:       []string{"list", "--merged", "--prunable"},
+			wantErrMsg: "cannot combine --prunable with --merged, --unmerged, --check, --branches, --paths or --show-head",
+		},
+		{
+			name:       "list prunable with branches",
+			args:       []string{"list", "--prunable", "--branches"},
+			wantErrMsg: "cannot combine --prunable with --merged, --unmerged, --check, --branches, --paths or --show-head",
+		},
+		{
+			name:       "list prunable with paths",
+			args:       []string{"list", "--paths", "--prunable"},
+			wantErrMsg: "cannot combine --prunable with --merged, --unmerged, --check, --branches, --paths or --show-head",
+		},
+		{
+			name:       "list paths with branches",
+			args:       []string{"list", "--paths", "--branches"},
+			wantErrMsg: "cannot combine --paths and --branches",
+		},
+		{
+			name:    "list paths with check",
+			args:    []string{"list", "--paths", "--check"},
+			wantCmd: "list",
+		},
+		{
+			name:    "remove older than keep going",
+			args:    []string{"remove", "--older-than", "30d", "-k"},
+			wantCmd: "remove",
+		},
+		{
+			name:       "remove keep going without older than",
+			args:       []string{"remove", "--keep-going", "feat"},
+			wantErrMsg: "--keep-going requires --older-than or --select",
+		},
+		{
+			name:     "create from pr without a name",
+			args:     []string{"create", "--from-pr", "123"},
+			wantCmd:  "create",
+			wantName: "",
+		},
+		{
+			name:     "create from pr with a name",
+			args:     []string{"create", "--from-pr", "123", "review"},
+			wantCmd:  "create",
+			wantName: "review",
+		},
+		{
+			name:       "create from pr with a bad number",
+			args:       []string{"create", "--from-pr", "0"},
+			wantErrMsg: "--from-pr must be a pull request number",
+		},
+		{
+			name:       "create from pr with base",
+			args:       []string{"create", "--from-pr", "123", "--base", "main"},
+			wantErrMsg: "cannot combine --from-pr with --base or --dry-run",
+		},
+		{
+			name:     "create branch from current",
+			args:     []string{"create", "--branch-from-current", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:       "create branch from current with base",
+			args:       []string{"create", "--branch-from-current", "--base", "main", "feat"},
+			wantErrMsg: "cannot combine --branch-from-current and --base",
+		},
+		{
+			name:     "create after another worktree",
+			args:     []string{"create", "--after", "base-feature", "next"},
+			wantCmd:  "create",
+			wantName: "next",
+		},
+		{
+			name:       "create after with base",
+			args:       []string{"create", "--after", "base-feature", "--base", "main", "next"},
+			wantErrMsg: "cannot combine --after and --base",
+		},
+		{
+			name:       "create after with detach",
+			args:       []string{"create", "--after", "base-feature", "--detach", "v1.0", "next"},
+			wantErrMsg: "cannot combine --after and --detach",
+		},
+		{
+			name:     "create hook retries and timeout",
+			args:     []string{"create", "--hook-retries", "2", "--hook-timeout", "90s", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:       "create invalid hook retries",
+			args:       []string{"create", "--hook-retries", "-1", "feat"},
+			wantErrMsg: "--hook-retries must be a non-negative integer",
+		},
+		{
+			name:       "create invalid hook timeout",
+			args:       []string{"create", "--hook-timeout", "5", "feat"},
+			wantErrMsg: "--hook-timeout must be a positive duration such as 30s or 5m",
+		},
+		{
+			name:    "list paths abbreviated",
+			args:    []string{"list", "--paths", "--abbrev"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list abbrev without paths",
+			args:       []string{"list", "--abbrev"},
+			wantErrMsg: "--abbrev requires --paths",
+		},
+		{
+			name:     "create detached at a tag",
+			args:     []string{"create", "--detach", "v2.0.0", "release-review"},
+			wantCmd:  "create",
+			wantName: "release-review",
+		},
+		{
+			name:       "create detached with push",
+			args:       []string{"create", "--detach", "v2.0.0", "--push", "release-review"},
+			wantErrMsg: "cannot combine --detach and --push",
+		},
+		{
+			name:       "create detached with open-url",
+			args:       []string{"create", "--detach", "v2.0.0", "--open-url", "release-review"},
+			wantErrMsg: "cannot combine --detach and --open-url",
+		},
+		{
+			name:     "create with push and open-url",
+			args:     []string{"create", "--push", "--open-url", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:       "create detached without a name",
+			args:       []string{"create", "--detach", "v2.0.0"},
+			wantErrMsg: "branch name required",
+		},
+		{
+			name:     "create attach running the hook",
+			args:     []string{"create", "--attach", "fix-ci", "--run-hook"},
+			wantCmd:  "create",
+			wantName: "",
+		},
+		{
+			name:     "create checkout on conflict running the hook",
+			args:     []string{"create", "--on-conflict", "checkout", "--run-hook", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:     "create replacing a stale worktree running the hook",
+			args:     []string{"create", "--checkout-existing-ok", "--run-hook", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:       "create run hook with another policy",
+			args:       []string{"create", "--on-conflict", "skip", "--run-hook", "feat"},
+			wantErrMsg: "--run-hook requires --attach, --on-conflict checkout or --checkout-existing-ok",
+		},
+		{
+			name:       "create run hook without attach",
+			args:       []string{"create", "--run-hook", "feat"},
+			wantErrMsg: "--run-hook requires --attach, --on-conflict checkout or --checkout-existing-ok",
+		},
+		{
+			name:    "remove select",
+			args:    []string{"remove", "--select", "-k"},
+			wantCmd: "remove",
+		},
+		{
+			name:       "remove select with older than",
+			args:       []string{"remove", "--select", "--older-than", "30d"},
+			wantErrMsg: "cannot combine --select and --older-than",
+		},
+		{
+			name:       "remove select with a name",
+			args:       []string{"remove", "--select", "feat"},
+			wantErrMsg: "unexpected argument: feat",
+		},
+		{
+			name:     "create no claude",
+			args:     []string{"create", "--no-claude", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:     "create if missing",
+			args:     []string{"create", "--if-missing", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:     "create attach without a name",
+			args:     []string{"create", "--attach", "fix-ci"},
+			wantCmd:  "create",
+			wantName: "",
+		},
+		{
+			name:       "create attach with base",
+			args:       []string{"create", "--attach", "fix-ci", "--base", "main"},
+			wantErrMsg: "cannot combine --attach with --base, --on-conflict or --from-pr",
+		},
+		{
+			name:     "create on conflict checkout",
+			args:     []string{"create", "--on-conflict", "checkout", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:     "create checkout existing ok",
+			args:     []string{"create", "--checkout-existing-ok", "feat"},
+			wantCmd:  "create",
+			wantName: "feat",
+		},
+		{
+			name:       "create on conflict unknown policy",
+			args:       []string{"create", "--on-conflict", "overwrite", "feat"},
+			wantErrMsg: `invalid --on-conflict "overwrite" (use error, skip or checkout)`,
+		},
+		{
+			name:     "create attach with reuse-branch-dir",
+			args:     []string{"create", "--attach", "feature/login", "--reuse-branch-dir", "last"},
+			wantCmd:  "create",
+			wantName: "",
+		},
+		{
+			name:       "create reuse-branch-dir unknown style",
+			args:       []string{"create", "--attach", "feature/login", "--reuse-branch-dir", "full"},
+			wantErrMsg: `invalid --reuse-branch-dir "full" (use slug or last)`,
+		},
+		{
+			name:    "list orphan branches",
+			args:    []string{"list", "--orphan-branches", "--limit", "5"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list orphan branches with merged",
+			args:       []string{"list", "--orphan-branches", "--merged"},
+			wantErrMsg: "cannot combine --orphan-branches and --merged",
+		},
+		{
+			name:    "list prunable",
+			args:    []string{"list", "--prunable"},
+			wantCmd: "list",
+		},
+		{
+			name:    "list with limit and offset",
+			args:    []string{"list", "--limit", "5", "--offset", "10"},
+			wantCmd: "list",
+		},
+		{
+			name:       "list limit without value",
+			args:       []string{"list", "--limit"},
+			wantErrMsg: "--limit requires a number argument",
+		},
+		{
+			name:     "config command passes args through",
+			args:     []string{"config", "set", "default_hook", "setup.sh"},
+			wantCmd:  "config",
+			wantArgs: []string{"set", "default_hook", "setup.sh"},
+		},
+		{
+			name:     "config command without args",
+			args:     []string{"config"},
+			wantCmd:  "config",
+			wantArgs: []string{},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cmd, name, hook, err := parseArgs(tt.args)
+			parsed, err := parseArgs(tt.args)
 
 			if tt.wantErr != nil {
 				if !errors.Is(err, tt.wantErr) {
@@ -410,14 +1127,17 @@ func TestParseArgs(t *testing.T) {
 				return
 			}
 
-			if cmd != tt.wantCmd {
-				t.Errorf("parseArgs() cmd = %q, want %q", cmd, tt.wantCmd)
+			if parsed.cmd != tt.wantCmd {
+				t.Errorf("parseArgs() cmd = %q, want %q", parsed.cmd, tt.wantCmd)
 			}
-			if name != tt.wantName {
-				t.Errorf("parseArgs() name = %q, want %q", name, tt.wantName)
+			if parsed.name != tt.wantName {
+				t.Errorf("parseArgs() name = %q, want %q", parsed.name, tt.wantName)
 			}
-			if hook != tt.wantHook {
-				t.Errorf("parseArgs() hook = %q, want %q", hook, tt.wantHook)
+			if parsed.value("--hook") != tt.wantHook {
+				t.Errorf("parseArgs() hook = %q, want %q", parsed.value("--hook"), tt.wantHook)
+			}
+			if tt.wantArgs != nil && strings.Join(parsed.args, " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("parseArgs() args = %v, want %v", parsed.args, tt.wantArgs)
 			}
 		})
 	}
@@ -439,6 +1159,19 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("invalid config defaults fail", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("list.output = yaml\n"), 0644)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+
+		err := run([]string{"list"})
+		if err == nil || err.Error() != `invalid --output "yaml" (use text, json or null) (with --output yaml from config)` {
+			t.Errorf("run() error = %v, want the invalid config default reported", err)
+		}
+	})
+
 	t.Run("jump command runs jump", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		origGetwd := getwdFn
@@ -457,6 +1190,38 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("warns about bad config lines", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("bogus = 1\n"), 0644)
+		origGetwd := getwdFn
+		defer func() { getwdFn = origGetwd }()
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		getwdFn = func() (string, error) {
+			return "/some/other/dir", nil
+		}
+
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+
+		err := run([]string{"jump"})
+
+		w.Close()
+		os.Stderr = oldStderr
+		var stderr bytes.Buffer
+		io.Copy(&stderr, r)
+
+		if err != nil {
+			t.Errorf("run() unexpected error: %v", err)
+		}
+		if stderr.String() != "warning: "+ConfigFile+":1: unknown config key: bogus (ignored)\n" {
+			t.Errorf("run() stderr = %q, want config warning", stderr.String())
+		}
+	})
+
 	t.Run("jump command with name", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		worktreesDir := filepath.Join(tmpDir, WorktreesDir)
@@ -479,6 +1244,67 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("list --pager pages the list", func(t *testing.T) {
+		origListWorktrees := listWorktreesFn
+		origHeight := terminalHeightFn
+		origRunPager := runPagerFn
+		defer func() {
+			listWorktreesFn = origListWorktrees
+			terminalHeightFn = origHeight
+			runPagerFn = origRunPager
+		}()
+		listWorktreesFn = func() ([]string, error) {
+			return []string{"b", "a"}, nil
+		}
+		terminalHeightFn = func(f *os.File) (int, bool) {
+			return 2, true
+		}
+		var paged []string
+		runPagerFn = func(pager, out string) error {
+			paged = append(paged, out)
+			return nil
+		}
+
+		if err := run([]string{"list", "--pager"}); err != nil {
+			t.Errorf("run() unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(paged, []string{"a\nb\n"}) {
+			t.Errorf("paged = %q, want the sorted list", paged)
+		}
+
+		// --no-pager wins over --pager
+		oldStdout := os.Stdout
+		os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		err := run([]string{"list", "--pager", "--no-pager"})
+		os.Stdout = oldStdout
+		if err != nil || len(paged) != 1 {
+			t.Errorf("run() = %v, paged %d times; want the pager skipped", err, len(paged))
+		}
+
+		listWorktreesFn = func() ([]string, error) {
+			return nil, errors.New("mock error")
+		}
+		if err := run([]string{"list", "--pager"}); err == nil || err.Error() != "mock error" {
+			t.Errorf("run() error = %v, want 'mock error'", err)
+		}
+	})
+
+	t.Run("remove --older-than calls removeOlderThan", func(t *testing.T) {
+		err := run([]string{"remove", "--older-than", "soon"})
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid --older-than") {
+			t.Errorf("run() error = %v, want invalid --older-than error", err)
+		}
+	})
+
+	t.Run("remove --select calls removeSelected", func(t *testing.T) {
+		listWorktreesFn = func() ([]string, error) {
+			return nil, errors.New("mock error")
+		}
+		if err := run([]string{"remove", "--select"}); err == nil || err.Error() != "mock error" {
+			t.Errorf("run() error = %v, want 'mock error'", err)
+		}
+	})
+
 	t.Run("create command calls create", func(t *testing.T) {
 		gitMainRootFn = func() (string, error) {
 			return "", errors.New("mock: not in git repo")
@@ -590,6 +1416,46 @@ func TestRun(t *testing.T) {
 		}
 	})
 
+	t.Run("list command with invalid limit", func(t *testing.T) {
+		err := run([]string{"list", "--limit", "abc"})
+		if err == nil || err.Error() != "--limit must be a non-negative integer" {
+			t.Errorf("run() error = %v, want '--limit must be a non-negative integer'", err)
+		}
+	})
+
+	t.Run("init command calls initCmd", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("mock: not in git repo")
+		}
+
+		err := run([]string{"init", "--no-gitignore-check"})
+		if err == nil || err.Error() != "mock: not in git repo" {
+			t.Errorf("run() error = %v, want 'mock: not in git repo'", err)
+		}
+	})
+
+	t.Run("repo-root command calls repoRoot", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("mock: not in git repo")
+		}
+
+		err := run([]string{"repo-root"})
+		if err == nil || err.Error() != "mock: not in git repo" {
+			t.Errorf("run() error = %v, want 'mock: not in git repo'", err)
+		}
+	})
+
+	t.Run("config command calls configCmd", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("mock: not in git repo")
+		}
+
+		err := run([]string{"config", "list"})
+		if err == nil || err.Error() != "mock: not in git repo" {
+			t.Errorf("run() error = %v, want 'mock: not in git repo'", err)
+		}
+	})
+
 	t.Run("completion command calls completion", func(t *testing.T) {
 		err := run([]string{"completion", "bash"})
 		if err != nil {
@@ -645,6 +1511,13 @@ func TestRun(t *testing.T) {
 			t.Errorf("run() unexpected error: %v", err)
 		}
 	})
+
+	t.Run("version --check calls checkVersion", func(t *testing.T) {
+		serveRelease(t, http.StatusOK, `{"tag_name": "v1.0.0"}`)
+		if err := run([]string{"version", "--check"}); err != nil {
+			t.Errorf("run() unexpected error: %v", err)
+		}
+	})
 }
 
 func TestVersionFunc(t *testing.T) {
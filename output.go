@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Formats for --output, which selects how a command prints its entries
+const (
+	OutputText = "text" // one entry per line
+	OutputJSON = "json" // a JSON array of objects describing the entries, for scripts
+	OutputNull = "null" // each entry ended by a NUL byte, for xargs -0
+)
+
+// checkOutputFormat rejects an --output value that is not one of the formats
+func checkOutputFormat(format string) error {
+	switch format {
+	case "", OutputText, OutputJSON, OutputNull:
+		return nil
+	}
+	return fmt.Errorf("invalid --output %q (use text, json or null)", format)
+}
+
+// writeEntries writes the rendered entries to w, NUL-terminated with OutputNull and one per line otherwise
+// OutputJSON describes entries with their structured data instead, through writeJSON
+func writeEntries(w io.Writer, format string, entries []string) {
+	end := "\n"
+	if format == OutputNull {
+		end = "\x00"
+	}
+	for _, entry := range entries {
+		fmt.Fprint(w, entry+end)
+	}
+}
+
+// writeJSON writes v to w as a single line of JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCheckOutputFormat(t *testing.T) {
+	for _, format := range []string{"", OutputText, OutputJSON, OutputNull} {
+		if err := checkOutputFormat(format); err != nil {
+			t.Errorf("checkOutputFormat(%q) unexpected error: %v", format, err)
+		}
+	}
+	want := `invalid --output "yaml" (use text, json or null)`
+	if err := checkOutputFormat("yaml"); err == nil || err.Error() != want {
+		t.Errorf("checkOutputFormat() error = %v, want %q", err, want)
+	}
+}
+
+func TestWriteEntries(t *testing.T) {
+	entries := []string{"login", "fix <b>", "two\nlines"}
+	tests := []struct {
+		name    string
+		format  string
+		entries []string
+		want    string
+	}{
+		{name: "default is text", entries: entries, want: "login\nfix <b>\ntwo\nlines\n"},
+		{name: "text", format: OutputText, entries: entries, want: "login\nfix <b>\ntwo\nlines\n"},
+		{name: "null", format: OutputNull, entries: entries, want: "login\x00fix <b>\x00two\nlines\x00"},
+		{name: "no entries as text", format: OutputText},
+		{name: "no entries as null", format: OutputNull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeEntries(&buf, tt.format, tt.entries)
+			if buf.String() != tt.want {
+				t.Errorf("writeEntries() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	value := struct {
+		Name   string  `json:"name"`
+		Branch *string `json:"branch"`
+	}{Name: "fix <b> & co"}
+	if err := writeJSON(&buf, value); err != nil {
+		t.Fatalf("writeJSON() unexpected error: %v", err)
+	}
+	if want := `{"name":"fix <b> & co","branch":null}` + "\n"; buf.String() != want {
+		t.Errorf("writeJSON() = %q, want %q", buf.String(), want)
+	}
+}
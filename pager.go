@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Function variables for testing
+var (
+	terminalHeightFn = terminalHeight
+	runPagerFn       = defaultRunPager
+)
+
+// DefaultPager is used when $PAGER is not set
+// -F exits at once if the output fits on one screen, -R keeps colors and -X leaves it on screen
+const DefaultPager = "less -FRX"
+
+// writePaged writes out to stdout, through $PAGER when stdout is a terminal too short to show it all
+// If the pager cannot be run, out is written directly after a warning
+func writePaged(out string) error {
+	height, ok := terminalHeightFn(os.Stdout)
+	if ok && strings.Count(out, "\n") >= height {
+		pager := os.Getenv("PAGER")
+		if pager == "" {
+			pager = DefaultPager
+		}
+		err := runPagerFn(pager, out)
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "warning: failed to run pager %q: %v\n", pager, err)
+	}
+	_, err := fmt.Fprint(os.Stdout, out)
+	return err
+}
+
+// defaultRunPager runs the pager command line through the shell, like git, with out on its stdin
+func defaultRunPager(pager, out string) error {
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// terminalHeight reports that f is not a terminal, so output is never paged where
+// the terminal size cannot be read with an ioctl
+func terminalHeight(f *os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePaged(t *testing.T) {
+	origHeight := terminalHeightFn
+	origRunPager := runPagerFn
+	defer func() {
+		terminalHeightFn = origHeight
+		runPagerFn = origRunPager
+	}()
+
+	long := strings.Repeat("worktree\n", 30)
+	tests := []struct {
+		name       string
+		tty        bool
+		out        string
+		pagerEnv   string
+		pagerErr   error
+		wantPager  string // pager command expected to run; empty if none
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "long terminal output is paged", tty: true, out: long, wantPager: DefaultPager},
+		{name: "$PAGER is used", tty: true, out: long, pagerEnv: "more", wantPager: "more"},
+		{name: "output that fits is printed", tty: true, out: "a\nb\n", wantStdout: "a\nb\n"},
+		{name: "piped output is printed", out: long, wantStdout: long},
+		{
+			name:       "pager failure falls back to printing",
+			tty:        true,
+			out:        long,
+			pagerEnv:   "nopager",
+			pagerErr:   errors.New("exit status 127"),
+			wantPager:  "nopager",
+			wantStdout: long,
+			wantStderr: "warning: failed to run pager \"nopager\": exit status 127\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PAGER", tt.pagerEnv)
+			terminalHeightFn = func(f *os.File) (int, bool) {
+				return 24, tt.tty
+			}
+			var ranPager, paged string
+			runPagerFn = func(pager, out string) error {
+				ranPager, paged = pager, out
+				return tt.pagerErr
+			}
+
+			oldStdout, oldStderr := os.Stdout, os.Stderr
+			outR, outW, _ := os.Pipe()
+			errR, errW, _ := os.Pipe()
+			os.Stdout, os.Stderr = outW, errW
+			err := writePaged(tt.out)
+			outW.Close()
+			errW.Close()
+			os.Stdout, os.Stderr = oldStdout, oldStderr
+			var stdout, stderr bytes.Buffer
+			io.Copy(&stdout, outR)
+			io.Copy(&stderr, errR)
+
+			if err != nil {
+				t.Fatalf("writePaged() unexpected error: %v", err)
+			}
+			if ranPager != tt.wantPager {
+				t.Errorf("pager = %q, want %q", ranPager, tt.wantPager)
+			}
+			if tt.wantPager != "" && paged != tt.out {
+				t.Errorf("pager input = %q, want the output", paged)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestDefaultRunPager(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "paged")
+
+	if err := defaultRunPager("cat > "+out, "a\nb\n"); err != nil {
+		t.Fatalf("defaultRunPager() unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "a\nb\n" {
+		t.Errorf("pager received %q, want %q", data, "a\nb\n")
+	}
+	if err := defaultRunPager("exit 3", ""); err == nil {
+		t.Error("defaultRunPager() expected an error from a failing pager")
+	}
+}
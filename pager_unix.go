@@ -0,0 +1,20 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalHeight returns the number of rows of the terminal f refers to
+// It reports false when f is not a terminal, such as when output is piped
+func terminalHeight(f *os.File) (int, bool) {
+	var ws struct{ row, col, xpixel, ypixel uint16 }
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 || ws.row == 0 {
+		return 0, false
+	}
+	return int(ws.row), true
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestTerminalHeight(t *testing.T) {
+	t.Run("pipe is not a terminal", func(t *testing.T) {
+		r, w, _ := os.Pipe()
+		defer r.Close()
+		defer w.Close()
+		if _, ok := terminalHeight(w); ok {
+			t.Error("terminalHeight() reported a pipe as a terminal")
+		}
+	})
+
+	t.Run("pseudo terminal", func(t *testing.T) {
+		ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+		if err != nil {
+			t.Skipf("no pseudo terminals: %v", err)
+		}
+		defer ptmx.Close()
+		ws := struct{ row, col, xpixel, ypixel uint16 }{row: 40, col: 100}
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), uintptr(syscall.TIOCSWINSZ), uintptr(unsafe.Pointer(&ws))); errno != 0 {
+			t.Skipf("cannot set the terminal size: %v", errno)
+		}
+
+		if height, ok := terminalHeight(ptmx); !ok || height != 40 {
+			t.Errorf("terminalHeight() = %d, %v; want 40, true", height, ok)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// promptInput is where answers to confirmation prompts are read from; replaceable for testing
+var promptInput io.Reader = os.Stdin
+
+// confirm asks question on stderr and reports whether the answer was yes
+// Anything other than y or yes counts as no, including a closed or non-interactive stdin
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := readLine(promptInput)
+	if err != nil && answer == "" {
+		// Finish the prompt line so later output doesn't run into it
+		fmt.Fprintln(os.Stderr)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// readLine reads up to and including the next newline from r
+// It reads a byte at a time so input meant for later prompts is not buffered away
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			line = append(line, b[0])
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+// choose lists items numbered from 1 on stderr, asks question and returns the items picked by number
+// The answer is a comma- or space-separated list of numbers such as "1,3"; an empty answer picks nothing
+func choose(question string, items []string) ([]string, error) {
+	for i, item := range items {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, item)
+	}
+	fmt.Fprintf(os.Stderr, "%s ", question)
+	answer, err := readLine(promptInput)
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+	}
+	indices, err := parseSelection(answer, len(items))
+	if err != nil {
+		return nil, err
+	}
+	chosen := make([]string, len(indices))
+	for i, index := range indices {
+		chosen[i] = items[index]
+	}
+	return chosen, nil
+}
+
+// parseSelection parses a list of numbers from 1 to n, such as "1, 3" or "2 4", into 0-based indices
+// Each number is picked once, in the order first given
+func parseSelection(answer string, n int) ([]int, error) {
+	var indices []int
+	seen := map[int]bool{}
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		number, err := strconv.Atoi(field)
+		if err != nil || number < 1 || number > n {
+			return nil, fmt.Errorf("invalid selection %q (use numbers from 1 to %d)", field, n)
+		}
+		if !seen[number] {
+			seen[number] = true
+			indices = append(indices, number-1)
+		}
+	}
+	return indices, nil
+}
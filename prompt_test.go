@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	origInput := promptInput
+	defer func() { promptInput = origInput }()
+
+	tests := []struct {
+		name       string
+		input      string
+		want       bool
+		wantStderr string
+	}{
+		{name: "y", input: "y\n", want: true, wantStderr: "Continue? [y/N] "},
+		{name: "yes with spaces and case", input: "  YES \n", want: true, wantStderr: "Continue? [y/N] "},
+		{name: "no", input: "n\n", want: false, wantStderr: "Continue? [y/N] "},
+		{name: "empty answer", input: "\n", want: false, wantStderr: "Continue? [y/N] "},
+		{name: "answer without newline", input: "y", want: true, wantStderr: "Continue? [y/N] "},
+		{name: "closed input", input: "", want: false, wantStderr: "Continue? [y/N] \n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promptInput = strings.NewReader(tt.input)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			got := confirm("Continue?")
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if got != tt.want {
+				t.Errorf("confirm() = %v, want %v", got, tt.want)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestConfirmLeavesLaterAnswers(t *testing.T) {
+	origInput := promptInput
+	defer func() { promptInput = origInput }()
+	promptInput = strings.NewReader("y\nn\n")
+
+	oldStderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	first, second := confirm("First?"), confirm("Second?")
+	os.Stderr = oldStderr
+
+	if !first || second {
+		t.Errorf("confirm() answers = %v, %v; want true, false", first, second)
+	}
+}
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		answer     string
+		want       []int
+		wantErrMsg string
+	}{
+		{answer: "", want: nil},
+		{answer: "  \n", want: nil},
+		{answer: "2\n", want: []int{1}},
+		{answer: "1,3", want: []int{0, 2}},
+		{answer: "3, 1 2", want: []int{2, 0, 1}},
+		{answer: "1,,1", want: []int{0}},
+		{answer: "0", wantErrMsg: `invalid selection "0" (use numbers from 1 to 3)`},
+		{answer: "4", wantErrMsg: `invalid selection "4" (use numbers from 1 to 3)`},
+		{answer: "1-2", wantErrMsg: `invalid selection "1-2" (use numbers from 1 to 3)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			got, err := parseSelection(tt.answer, 3)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("parseSelection(%q) error = %v, want %q", tt.answer, err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSelection(%q) = %v, %v; want %v", tt.answer, got, err, tt.want)
+			}
+		})
+	}
+}
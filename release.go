@@ -0,0 +1,139 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReleasesPage lists the published releases and their downloads
+const ReleasesPage = "https://github.com/bsamek/wt/releases"
+
+// Replaceable for testing
+var (
+	latestReleaseURL = "https://api.github.com/repos/bsamek/wt/releases/latest"
+	releaseClient    = &http.Client{Timeout: 10 * time.Second}
+)
+
+// latestRelease returns the tag of the newest published release, as reported by the GitHub API
+func latestRelease() (string, error) {
+	// latestReleaseURL is a valid URL, so NewRequest cannot fail
+	req, _ := http.NewRequest(http.MethodGet, latestReleaseURL, nil)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := releaseClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("invalid release data: %w", err)
+	}
+	if release.TagName == "" {
+		return "", errors.New("the latest release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// semver is a parsed release tag: its three numbers and its prerelease identifiers, if any
+type semver struct {
+	nums []int
+	pre  []string // "rc", "1" for v2.0.0-rc.1; empty for a release
+}
+
+// parseVersion parses a release tag such as v1.2.3 or v2.0.0-rc.1, ignoring any +build suffix
+// ok is false for anything else, such as a dev build
+func parseVersion(tag string) (v semver, ok bool) {
+	core, _, _ := strings.Cut(strings.TrimPrefix(tag, "v"), "+")
+	core, pre, hasPre := strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+	for _, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		v.nums = append(v.nums, n)
+	}
+	if hasPre {
+		v.pre = strings.Split(pre, ".")
+		if slices.Contains(v.pre, "") {
+			return semver{}, false
+		}
+	}
+	return v, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, the same as or newer than b, following semver:
+// a prerelease comes before its release, and its identifiers are compared in order, numbers by value
+// and below any text, with fewer identifiers first when the rest are equal
+func compareVersions(a, b semver) int {
+	if c := slices.Compare(a.nums, b.nums); c != 0 {
+		return c
+	}
+	switch {
+	case len(a.pre) == 0 && len(b.pre) == 0:
+		return 0
+	case len(a.pre) == 0:
+		return 1
+	case len(b.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.pre) && i < len(b.pre); i++ {
+		x, xErr := strconv.Atoi(a.pre[i])
+		y, yErr := strconv.Atoi(b.pre[i])
+		var c int
+		switch {
+		case xErr == nil && yErr == nil:
+			c = cmp.Compare(x, y)
+		case xErr == nil:
+			c = -1
+		case yErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(a.pre[i], b.pre[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a.pre), len(b.pre))
+}
+
+// checkVersion prints the version information and whether a newer release is available
+// Nothing is downloaded, and a failed check is reported without failing, since the version itself is known
+func checkVersion(w io.Writer) error {
+	fmt.Fprintln(w, versionString())
+	latest, err := latestRelease()
+	if err != nil {
+		fmt.Fprintf(w, "Could not check for a newer release: %v\n", err)
+		return nil
+	}
+
+	current, currentOK := parseVersion(Version)
+	newest, newestOK := parseVersion(latest)
+	switch {
+	case !currentOK || !newestOK:
+		fmt.Fprintf(w, "The latest release is %s; this build cannot be compared with it\n", latest)
+	case compareVersions(newest, current) > 0:
+		fmt.Fprintf(w, "A newer release is available: %s (see %s)\n", latest, ReleasesPage)
+	default:
+		fmt.Fprintln(w, "wt is up to date")
+	}
+	return nil
+}
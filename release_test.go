@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// serveRelease points latestReleaseURL at a server that answers with status and body until the test ends
+func serveRelease(t *testing.T, status int, body string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "application/vnd.github+json" {
+			t.Errorf("Accept header = %q, want the GitHub JSON media type", got)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	origURL := latestReleaseURL
+	latestReleaseURL = server.URL
+	t.Cleanup(func() {
+		latestReleaseURL = origURL
+		server.Close()
+	})
+}
+
+func TestLatestRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		want       string
+		wantErrMsg string
+	}{
+		{name: "tag of the latest release", status: http.StatusOK, body: `{"tag_name": "v1.4.0", "name": "wt 1.4.0"}`, want: "v1.4.0"},
+		{name: "no releases", status: http.StatusNotFound, body: `{"message": "Not Found"}`, wantErrMsg: "GitHub returned 404 Not Found"},
+		{name: "invalid JSON", status: http.StatusOK, body: `<html>`, wantErrMsg: "invalid release data: invalid character '<' looking for beginning of value"},
+		{name: "missing tag", status: http.StatusOK, body: `{}`, wantErrMsg: "the latest release has no tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serveRelease(t, tt.status, tt.body)
+			got, err := latestRelease()
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("latestRelease() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("latestRelease() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("network error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+		origURL := latestReleaseURL
+		defer func() { latestReleaseURL = origURL }()
+		latestReleaseURL = server.URL
+
+		if _, err := latestRelease(); err == nil {
+			t.Error("latestRelease() expected error for an unreachable server")
+		}
+	})
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   semver
+		wantOK bool
+	}{
+		{tag: "v1.2.3", want: semver{nums: []int{1, 2, 3}}, wantOK: true},
+		{tag: "0.10.0", want: semver{nums: []int{0, 10, 0}}, wantOK: true},
+		{tag: "v2.0.0-rc.1", want: semver{nums: []int{2, 0, 0}, pre: []string{"rc", "1"}}, wantOK: true},
+		{tag: "v1.2.3-rc1+build.5", want: semver{nums: []int{1, 2, 3}, pre: []string{"rc1"}}, wantOK: true},
+		{tag: "v1.2.3+build.5", want: semver{nums: []int{1, 2, 3}}, wantOK: true},
+		{tag: "dev"},
+		{tag: "v1.2"},
+		{tag: "v1.x.3"},
+		{tag: "v1.-2.3"},
+		{tag: "v1.2.3-"},
+		{tag: "v1.2.3-rc..1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := parseVersion(tt.tag)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseVersion(%q) = %v, %v; want %v, %v", tt.tag, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	// Each tag is older than the next, as in the semver specification's example
+	ordered := []string{"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta", "v1.0.0-beta.2", "v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0", "v1.2.3-rc1", "v1.2.3", "v1.10.0"}
+	for i, older := range ordered {
+		for j, newer := range ordered {
+			a, _ := parseVersion(older)
+			b, _ := parseVersion(newer)
+			if got, want := compareVersions(a, b), cmp.Compare(i, j); got != want {
+				t.Errorf("compareVersions(%s, %s) = %d, want %d", older, newer, got, want)
+			}
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	origVersion := Version
+	defer func() { Version = origVersion }()
+
+	tests := []struct {
+		name    string
+		version string
+		status  int
+		body    string
+		want    string
+	}{
+		{
+			name:    "newer release",
+			version: "v1.2.3",
+			status:  http.StatusOK,
+			body:    `{"tag_name": "v1.10.0"}`,
+			want:    "v1.2.3\nA newer release is available: v1.10.0 (see " + ReleasesPage + ")\n",
+		},
+		{
+			name:    "same release",
+			version: "v1.2.3",
+			status:  http.StatusOK,
+			body:    `{"tag_name": "v1.2.3"}`,
+			want:    "v1.2.3\nwt is up to date\n",
+		},
+		{
+			name:    "release candidate of the latest release",
+			version: "v1.2.3-rc1",
+			status:  http.StatusOK,
+			body:    `{"tag_name": "v1.2.3"}`,
+			want:    "v1.2.3-rc1\nA newer release is available: v1.2.3 (see " + ReleasesPage + ")\n",
+		},
+		{
+			name:    "ahead of the latest release",
+			version: "v1.3.0-rc.1",
+			status:  http.StatusOK,
+			body:    `{"tag_name": "v1.2.3"}`,
+			want:    "v1.3.0-rc.1\nwt is up to date\n",
+		},
+		{
+			name:    "release tag that is not a version",
+			version: "v1.2.3",
+			status:  http.StatusOK,
+			body:    `{"tag_name": "nightly"}`,
+			want:    "v1.2.3\nThe latest release is nightly; this build cannot be compared with it\n",
+		},
+		{
+			name:    "failed check",
+			version: "v1.2.3",
+			status:  http.StatusForbidden,
+			body:    `{"message": "API rate limit exceeded"}`,
+			want:    "v1.2.3\nCould not check for a newer release: GitHub returned 403 Forbidden\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Version = tt.version
+			serveRelease(t, tt.status, tt.body)
+
+			var buf bytes.Buffer
+			if err := checkVersion(&buf); err != nil {
+				t.Fatalf("checkVersion() unexpected error: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("checkVersion() output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// openURLFn is replaceable for testing
+var openURLFn = defaultOpenURL
+
+// sshWebHosts maps the hosts that serve SSH on another name, for networks that block port 22,
+// to the host of their web pages
+var sshWebHosts = map[string]string{
+	"ssh.github.com":       "github.com",
+	"altssh.gitlab.com":    "gitlab.com",
+	"altssh.bitbucket.org": "bitbucket.org",
+}
+
+// remoteWebURL turns the URL of a git remote into the web address of the repository, as for
+// GitHub, GitLab (including subgroups) and Bitbucket, or a self-hosted server that uses the same paths
+// It understands HTTPS (https://github.com/org/repo.git, Bitbucket's https://user@bitbucket.org/org/repo.git),
+// the scp-like SSH form (git@github.com:org/repo.git), and ssh:// and git:// URLs
+func remoteWebURL(remote string) (string, error) {
+	scheme, host, repo := "https", "", ""
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", fmt.Errorf("cannot tell the web address of remote %q", remote)
+		}
+		switch u.Scheme {
+		case "https", "http":
+			// Any port is the web server's, but credentials must not end up in a printed URL
+			scheme, host = u.Scheme, u.Host
+		case "ssh", "git", "git+ssh", "ssh+git":
+			// The port of these is git's, not the web server's
+			host = u.Hostname()
+		default:
+			return "", fmt.Errorf("cannot tell the web address of remote %q", remote)
+		}
+		repo = u.Path
+	} else {
+		hostPath := remote
+		if _, after, ok := strings.Cut(remote, "@"); ok {
+			hostPath = after
+		}
+		host, repo, _ = strings.Cut(hostPath, ":")
+		// A path with a slash before any colon is a local directory
+		if strings.Contains(host, "/") {
+			host = ""
+		}
+	}
+	if web, ok := sshWebHosts[host]; ok {
+		host = web
+	}
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	if host == "" || repo == "" {
+		return "", fmt.Errorf("cannot tell the web address of remote %q", remote)
+	}
+	return scheme + "://" + host + "/" + repo, nil
+}
+
+// compareURL returns the page of the repository at web comparing branch with base
+// Without a base the page compares branch with the repository's default branch
+func compareURL(web, base, branch string) string {
+	if base == "" {
+		return web + "/compare/" + escapeRef(branch)
+	}
+	return web + "/compare/" + escapeRef(base) + "..." + escapeRef(branch)
+}
+
+// escapeRef escapes a branch name for a URL path, keeping the slashes that separate its parts
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// branchCompareURL returns the compare page for branch on origin, against base when it names another
+// branch and otherwise against the repository's default branch
+func branchCompareURL(root, base, branch string) (string, error) {
+	remote, err := gitOutput(root, "remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("no origin remote to compare %s on", branch)
+	}
+	web, err := remoteWebURL(remote)
+	if err != nil {
+		return "", err
+	}
+	base = strings.TrimPrefix(base, "origin/")
+	if base == "" || base == branch || !gitRefExists(root, "refs/heads/"+base) {
+		// Without a default branch the page still opens, comparing against the default on the remote
+		base, _ = gitDefaultBranch(root)
+	}
+	return compareURL(web, base, branch), nil
+}
+
+// browserCommand returns the program that opens a URL in the default browser on goos
+func browserCommand(goos string) string {
+	if goos == "darwin" {
+		return "open"
+	}
+	return "xdg-open"
+}
+
+// defaultOpenURL opens u with $BROWSER, or the platform's opener when it is not set
+func defaultOpenURL(u string) error {
+	browser := os.Getenv("BROWSER")
+	if browser == "" {
+		browser = browserCommand(runtime.GOOS)
+	}
+	cmd := exec.Command(browser, u)
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -1,43 +1,276 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-func remove(name string) error {
+// removeOptions controls what remove deletes
+type removeOptions struct {
+	keepDir    bool   // detach the worktree and keep its directory, deleting only the branch
+	archiveDir string // directory to write a tar.gz of the worktree to before removing it
+	force      bool   // delete the branch even if it has unmerged commits
+	yes        bool   // answer yes to confirmation prompts
+	keepGoing  bool   // with --older-than, remove the other worktrees when one fails
+	noCd       bool   // never print the root for the shell wrapper, even when the current worktree is removed
+}
+
+// remove deletes the worktree and branch for name, which may also be a path inside the worktrees directory
+func remove(name string, opts removeOptions) error {
 	wm, err := NewWorktreeManager()
 	if err != nil {
 		return err
 	}
 
+	if isPathArg(name) {
+		path, err := absPath(name)
+		if err != nil {
+			return err
+		}
+		if wm.IsMainWorktree(path) {
+			return fmt.Errorf("cannot remove the main worktree")
+		}
+		if name, err = wm.WorktreeNameFromPath(path); err != nil {
+			return err
+		}
+	}
+
 	worktreePath := wm.WorktreePath(name)
+	if wm.IsMainWorktree(worktreePath) {
+		return fmt.Errorf("cannot remove the main worktree")
+	}
+
+	// Refuse the branch checked out in the main worktree; git would report a confusing missing worktree
+	// A registered worktree of that name, such as main with branch_prefix = alice/, has a branch of its own
+	infos, infosErr := listWorktreeInfos()
+	if _, registered := findWorktree(infos, worktreePath); !registered && len(infos) > 0 && infos[0].Branch == name {
+		return fmt.Errorf("cannot remove the main worktree (%s is checked out at %s)", name, infos[0].Path)
+	}
+
+	if opts.keepDir {
+		if infosErr != nil {
+			return infosErr
+		}
+		return removeBranchOnly(wm, name, infos, opts)
+	}
 
 	// Check if we're currently inside the worktree being removed
 	cwd, err := getwdFn()
 	insideWorktree := err == nil && (cwd == worktreePath || strings.HasPrefix(cwd, worktreePath+string(filepath.Separator)))
 
+	// Archive before anything is deleted so a failed archive leaves the worktree untouched
+	if opts.archiveDir != "" {
+		archivePath, err := archiveWorktree(worktreePath, opts.archiveDir, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Archived worktree to %s\n", archivePath)
+	}
+
 	// Remove worktree
-	fmt.Fprintf(os.Stderr, "Removing worktree %s/%s\n", WorktreesDir, name)
+	fmt.Fprintf(os.Stderr, "Removing worktree %s/%s\n", wm.WorktreesDirName(), name)
 	if err := gitCmd(wm.Root(), "worktree", "remove", worktreePath); err != nil {
 		return fmt.Errorf("failed to remove worktree: %w", err)
 	}
+	pruneEmptyParents(worktreePath, wm.WorktreesPath(), cwd)
 
-	// Delete branch
-	fmt.Fprintf(os.Stderr, "Deleting branch %s\n", name)
-	if err := gitCmd(wm.Root(), "branch", "-D", name); err != nil {
-		return fmt.Errorf("failed to delete branch: %w", err)
+	// Delete the branch the worktree has checked out, which differs from name with a branch_prefix
+	// A detached worktree, such as one created with create --detach, has no branch to delete
+	if info, ok := findWorktree(infos, worktreePath); ok && info.Detached {
+		runPostRemoveHook(wm, name)
+		fmt.Fprintln(os.Stderr, "Done! Worktree removed; it was detached, so there was no branch to delete")
+	} else {
+		branch := name
+		if ok && info.Branch != "" {
+			branch = info.Branch
+		}
+		deleted, err := deleteBranch(wm, branch, opts)
+		if err != nil {
+			return err
+		}
+		runPostRemoveHook(wm, name)
+		if deleted {
+			fmt.Fprintln(os.Stderr, "Done! Worktree and branch removed")
+		} else {
+			fmt.Fprintf(os.Stderr, "warning: worktree removed but branch %s was kept; delete it with 'git branch -D %s'\n", branch, branch)
+		}
 	}
 
-	fmt.Fprintln(os.Stderr, "Done! Worktree and branch removed")
-
 	// Output path to stdout for shell wrapper to cd into
 	// If we were inside the worktree, output root so shell can cd there
 	// Otherwise, output empty line (no directory change needed)
-	if insideWorktree {
+	if insideWorktree && !opts.noCd {
 		fmt.Println(wm.Root())
 	}
 	return nil
 }
+
+// pruneEmptyParents removes the directories between a removed nested worktree, such as team/feature,
+// and the worktrees directory stop that it leaves empty
+// os.Remove only deletes empty directories, so one holding a sibling worktree or any file ends the walk,
+// as does the directory the shell is in, which would otherwise be left pointing at nothing
+func pruneEmptyParents(path, stop, cwd string) {
+	for dir := filepath.Dir(path); strings.HasPrefix(dir, stop+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if cwd == dir || strings.HasPrefix(cwd, dir+string(filepath.Separator)) || os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// runPostRemoveHook runs the post_remove_hook script, if it exists, from the repository root
+// The worktree is already gone, so a failing hook is reported as a warning rather than failing remove
+func runPostRemoveHook(wm *WorktreeManager, name string) {
+	hookPath := wm.Config().PostRemoveHook
+	if !wm.HookExists(hookPath) {
+		return
+	}
+	shell, err := lookPathHookShell(wm.Config().HookShell)
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Running post-remove hook: %s\n", hookPath)
+		err = runHook(wm.HookPath(hookPath), wm.Root(), hookOptions{env: []string{NameEnv + "=" + name}, shell: shell})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: post-remove hook failed: %v\n", err)
+	}
+}
+
+// removeOlderThan removes every worktree whose last commit is older than age, such as 30d
+// The worktrees are listed and removal must be confirmed unless opts.yes is set
+// Removal stops at the first worktree that fails to be removed unless opts.keepGoing is set
+func removeOlderThan(age string, opts removeOptions) error {
+	d, err := parseAge(age)
+	if err != nil {
+		return fmt.Errorf("invalid --older-than: %w", err)
+	}
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return err
+	}
+	infos, err := listWorktreeInfos()
+	if err != nil {
+		return err
+	}
+	stale, err := findStaleWorktrees(wm, infos, d)
+	if err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		fmt.Fprintf(os.Stderr, "No worktrees without commits in the last %s\n", age)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Worktrees without commits in the last %s:\n", age)
+	for _, s := range stale {
+		fmt.Fprintf(os.Stderr, "  %s (last commit %s)\n", s.name, humanizeDuration(nowFn().Sub(s.lastCommit)))
+	}
+	names := make([]string, len(stale))
+	for i, s := range stale {
+		names[i] = s.name
+	}
+	return removeWorktrees(names, opts)
+}
+
+// removeSelected lists the worktrees and removes the ones the user picks by number
+// An empty selection removes nothing
+func removeSelected(opts removeOptions) error {
+	names, err := listWorktrees()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "No worktrees to remove")
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "Worktrees:")
+	chosen, err := choose("Remove which worktrees? (numbers such as 1,3; empty for none)", names)
+	if err != nil {
+		return err
+	}
+	if len(chosen) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing removed")
+		return nil
+	}
+	return removeWorktrees(chosen, opts)
+}
+
+// removeWorktrees removes each of names after one confirmation, which opts.yes skips
+// Removal stops at the first worktree that fails to be removed unless opts.keepGoing is set
+func removeWorktrees(names []string, opts removeOptions) error {
+	if !opts.yes && !confirm(fmt.Sprintf("Remove %d worktree(s)?", len(names))) {
+		fmt.Fprintln(os.Stderr, "Nothing removed")
+		return nil
+	}
+	return forEachWorktree(names, "remove", opts.keepGoing, func(name string) error {
+		return remove(name, opts)
+	})
+}
+
+// removeBranchOnly detaches the worktree at name from its branch and deletes the branch,
+// leaving the directory in place as a detached checkout
+func removeBranchOnly(wm *WorktreeManager, name string, infos []Worktree, opts removeOptions) error {
+	// Only touch directories git knows as worktrees; checkout in a plain directory would act on the main repo
+	info, ok := findWorktree(infos, wm.WorktreePath(name))
+	if !ok {
+		return fmt.Errorf("%s/%s is not a git worktree", wm.WorktreesDirName(), name)
+	}
+	if info.Branch == "" {
+		return fmt.Errorf("worktree %s has no branch checked out", name)
+	}
+
+	fmt.Fprintf(os.Stderr, "Detaching worktree %s/%s\n", wm.WorktreesDirName(), name)
+	if err := gitCmd(info.Path, "checkout", "--detach"); err != nil {
+		return fmt.Errorf("failed to detach worktree: %w", err)
+	}
+
+	deleted, err := deleteBranch(wm, info.Branch, opts)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		fmt.Fprintf(os.Stderr, "warning: worktree detached but branch %s was kept; delete it with 'git branch -D %s'\n", info.Branch, info.Branch)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Done! Branch removed, worktree kept at %s/%s\n", wm.WorktreesDirName(), name)
+	return nil
+}
+
+// deleteBranch deletes branch with git branch -d, which refuses branches with unmerged commits
+// An unmerged branch is force deleted with --force or --yes, or after the user confirms;
+// it reports false if the user declined and the branch was kept
+func deleteBranch(wm *WorktreeManager, branch string, opts removeOptions) (bool, error) {
+	fmt.Fprintf(os.Stderr, "Deleting branch %s\n", branch)
+	if opts.force {
+		return true, deleteBranchForce(wm, branch)
+	}
+
+	err := gitCmd(wm.Root(), "branch", "-d", branch)
+	if err == nil {
+		return true, nil
+	}
+	if !isUnmergedBranchError(err) {
+		return false, fmt.Errorf("failed to delete branch: %w", err)
+	}
+	if !opts.yes && !confirm(fmt.Sprintf("Branch %s has unmerged commits, force delete?", branch)) {
+		return false, nil
+	}
+	return true, deleteBranchForce(wm, branch)
+}
+
+// deleteBranchForce deletes branch with git branch -D, whether or not it is merged
+func deleteBranchForce(wm *WorktreeManager, branch string) error {
+	if err := gitCmd(wm.Root(), "branch", "-D", branch); err != nil {
+		return fmt.Errorf("failed to delete branch: %w", err)
+	}
+	return nil
+}
+
+// isUnmergedBranchError reports whether err is git branch -d refusing a branch that is not fully merged
+func isUnmergedBranchError(err error) bool {
+	var cmdErr *gitCmdError
+	return errors.As(err, &cmdErr) && strings.Contains(cmdErr.stderr, "not fully merged")
+}
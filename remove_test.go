@@ -6,8 +6,11 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRemove(t *testing.T) {
@@ -26,12 +29,39 @@ func TestRemove(t *testing.T) {
 			return "", errors.New("not in a git repository")
 		}
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 		if err == nil || err.Error() != "not in a git repository" {
 			t.Errorf("remove() error = %v, want 'not in a git repository'", err)
 		}
 	})
 
+	t.Run("refuses to remove the repository root", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCalled := false
+		gitCmdFn = func(dir string, args ...string) error {
+			gitCalled = true
+			return nil
+		}
+		getwdFn = func() (string, error) {
+			return filepath.Join(tmpDir, WorktreesDir), nil
+		}
+
+		// ".." is a path relative to cwd; "x/../.." is a name that escapes the worktrees dir
+		for _, name := range []string{"..", "x/../.."} {
+			err := remove(name, removeOptions{})
+			if err == nil || err.Error() != "cannot remove the main worktree" {
+				t.Errorf("remove(%q) error = %v, want 'cannot remove the main worktree'", name, err)
+			}
+		}
+		if gitCalled {
+			t.Error("remove() should not call git when refusing to remove the main worktree")
+		}
+	})
+
 	t.Run("worktree remove fails", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -48,7 +78,7 @@ func TestRemove(t *testing.T) {
 			return "/some/other/dir", nil
 		}
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 		if err == nil || !strings.Contains(err.Error(), "failed to remove worktree") {
 			t.Errorf("remove() error = %v, want error about failed to remove worktree", err)
 		}
@@ -61,7 +91,7 @@ func TestRemove(t *testing.T) {
 			return tmpDir, nil
 		}
 		gitCmdFn = func(dir string, args ...string) error {
-			if len(args) > 0 && args[0] == "branch" && args[1] == "-D" {
+			if len(args) > 0 && args[0] == "branch" && args[1] == "-d" {
 				return errors.New("branch delete failed")
 			}
 			return nil
@@ -70,7 +100,7 @@ func TestRemove(t *testing.T) {
 			return "/some/other/dir", nil
 		}
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 		if err == nil || !strings.Contains(err.Error(), "failed to delete branch") {
 			t.Errorf("remove() error = %v, want error about failed to delete branch", err)
 		}
@@ -94,7 +124,7 @@ func TestRemove(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -112,6 +142,64 @@ func TestRemove(t *testing.T) {
 		}
 	})
 
+	t.Run("accepts a worktree path", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		var calls [][]string
+		gitCmdFn = func(dir string, args ...string) error {
+			calls = append(calls, args)
+			return nil
+		}
+		getwdFn = func() (string, error) {
+			return tmpDir, nil
+		}
+
+		err := remove("./"+WorktreesDir+"/test-branch", removeOptions{})
+		if err != nil {
+			t.Fatalf("remove() unexpected error: %v", err)
+		}
+		wantPath := filepath.Join(tmpDir, WorktreesDir, "test-branch")
+		if len(calls) != 2 || calls[0][2] != wantPath || calls[1][2] != "test-branch" {
+			t.Errorf("git calls = %v, want worktree remove %s and branch -d test-branch", calls, wantPath)
+		}
+	})
+
+	t.Run("path getwd error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return t.TempDir(), nil
+		}
+		getwdFn = func() (string, error) {
+			return "", errors.New("getwd failed")
+		}
+
+		err := remove("./test-branch", removeOptions{})
+		if err == nil || err.Error() != "failed to get current directory: getwd failed" {
+			t.Errorf("remove() error = %v, want getwd error", err)
+		}
+	})
+
+	t.Run("rejects a path outside the worktrees dir", func(t *testing.T) {
+		tmpDir := t.TempDir()
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			t.Errorf("git %v should not run for a path outside the worktrees dir", args)
+			return nil
+		}
+
+		outside := filepath.Join(tmpDir, "src")
+		err := remove(outside, removeOptions{})
+		want := outside + " is not inside " + filepath.Join(tmpDir, WorktreesDir)
+		if err == nil || err.Error() != want {
+			t.Errorf("remove() error = %v, want %q", err, want)
+		}
+	})
+
 	t.Run("success from inside worktree outputs root", func(t *testing.T) {
 		tmpDir := t.TempDir()
 		worktreePath := filepath.Join(tmpDir, WorktreesDir, "test-branch")
@@ -131,7 +219,7 @@ func TestRemove(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -169,7 +257,7 @@ func TestRemove(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -187,6 +275,40 @@ func TestRemove(t *testing.T) {
 		}
 	})
 
+	t.Run("no-cd from inside worktree outputs nothing", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		worktreePath := filepath.Join(tmpDir, WorktreesDir, "test-branch")
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			return nil
+		}
+		getwdFn = func() (string, error) {
+			return filepath.Join(worktreePath, "src"), nil
+		}
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := remove("test-branch", removeOptions{noCd: true})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err != nil {
+			t.Errorf("remove() unexpected error: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("remove() stdout = %q, want nothing with --no-cd", buf.String())
+		}
+	})
+
 	t.Run("getwd error is handled gracefully", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
@@ -205,7 +327,7 @@ func TestRemove(t *testing.T) {
 		r, w, _ := os.Pipe()
 		os.Stdout = w
 
-		err := remove("test-branch")
+		err := remove("test-branch", removeOptions{})
 
 		w.Close()
 		os.Stdout = oldStdout
@@ -223,3 +345,1107 @@ func TestRemove(t *testing.T) {
 		}
 	})
 }
+
+func TestRemoveMainBranch(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Name: "repo", Path: tmpDir, Branch: "main"}}, nil
+	}
+	gitCalled := false
+	gitCmdFn = func(dir string, args ...string) error {
+		gitCalled = true
+		return nil
+	}
+
+	for _, opts := range []removeOptions{{}, {keepDir: true}} {
+		err := remove("main", opts)
+		want := "cannot remove the main worktree (main is checked out at " + tmpDir + ")"
+		if err == nil || err.Error() != want {
+			t.Errorf("remove(main, %+v) error = %v, want %q", opts, err, want)
+		}
+	}
+	if gitCalled {
+		t.Error("remove() should not call git when refusing the main worktree's branch")
+	}
+
+	t.Run("worktree of the same name on a prefixed branch", func(t *testing.T) {
+		// wt create main with branch_prefix = alice/
+		worktreePath := filepath.Join(tmpDir, WorktreesDir, "main")
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return []Worktree{
+				{Path: tmpDir, Branch: "main"},
+				{Path: worktreePath, Branch: "alice/main"},
+			}, nil
+		}
+		var calls []string
+		gitCmdFn = func(dir string, args ...string) error {
+			calls = append(calls, strings.Join(args, " "))
+			return nil
+		}
+
+		oldStderr := os.Stderr
+		os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		err := remove("main", removeOptions{})
+		os.Stderr.Close()
+		os.Stderr = oldStderr
+		if err != nil {
+			t.Fatalf("remove() unexpected error: %v", err)
+		}
+		want := []string{"worktree remove " + worktreePath, "branch -d alice/main"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("git calls = %q, want %q", calls, want)
+		}
+	})
+}
+
+func TestRemoveKeepDir(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "test-branch")
+	os.MkdirAll(worktreePath, 0755)
+	os.MkdirAll(filepath.Join(tmpDir, WorktreesDir, "plain"), 0755)
+
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return worktreePath, nil
+	}
+	// The directory name differs from the branch to show the real branch is deleted
+	registered := []Worktree{
+		{Name: "repo", Path: tmpDir, Branch: "main"},
+		{Name: "test-branch", Path: worktreePath, Branch: "feature/x"},
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return registered, nil
+	}
+
+	t.Run("detaches then deletes only the branch", func(t *testing.T) {
+		var calls []string
+		gitCmdFn = func(dir string, args ...string) error {
+			calls = append(calls, dir+": "+strings.Join(args, " "))
+			return nil
+		}
+
+		oldStdout := os.Stdout
+		r, w, _ := os.Pipe()
+		os.Stdout = w
+
+		err := remove("test-branch", removeOptions{keepDir: true})
+
+		w.Close()
+		os.Stdout = oldStdout
+
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+
+		if err != nil {
+			t.Fatalf("remove() unexpected error: %v", err)
+		}
+		want := []string{
+			worktreePath + ": checkout --detach",
+			tmpDir + ": branch -d feature/x",
+		}
+		if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+			t.Errorf("remove() git calls = %q, want %q", calls, want)
+		}
+		if _, err := os.Stat(worktreePath); err != nil {
+			t.Errorf("remove() removed the worktree directory: %v", err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("remove() stdout = %q, want empty since the directory is kept", buf.String())
+		}
+	})
+
+	refusals := []struct {
+		name    string
+		target  string
+		infos   []Worktree
+		infoErr error
+		wantErr string
+	}{
+		{"plain directory", "plain", registered, nil, WorktreesDir + "/plain is not a git worktree"},
+		{"detached worktree", "test-branch", []Worktree{registered[0], {Path: worktreePath, Detached: true}}, nil, "worktree test-branch has no branch checked out"},
+		{"git worktree list fails", "test-branch", nil, errors.New("failed to list git worktrees: exit status 128"), "failed to list git worktrees: exit status 128"},
+	}
+	for _, tt := range refusals {
+		t.Run("refuses "+tt.name, func(t *testing.T) {
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				return tt.infos, tt.infoErr
+			}
+			defer func() {
+				listWorktreeInfosFn = func() ([]Worktree, error) { return registered, nil }
+			}()
+			gitCalled := false
+			gitCmdFn = func(dir string, args ...string) error {
+				gitCalled = true
+				return nil
+			}
+
+			err := remove(tt.target, removeOptions{keepDir: true})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("remove() error = %v, want %q", err, tt.wantErr)
+			}
+			if gitCalled {
+				t.Error("remove() ran git despite refusing")
+			}
+		})
+	}
+
+	t.Run("detach fails", func(t *testing.T) {
+		branchDeleted := false
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "checkout" {
+				return errors.New("exit status 1")
+			}
+			branchDeleted = branchDeleted || args[0] == "branch"
+			return nil
+		}
+
+		err := remove("test-branch", removeOptions{keepDir: true})
+		if err == nil || err.Error() != "failed to detach worktree: exit status 1" {
+			t.Errorf("remove() error = %v, want detach error", err)
+		}
+		if branchDeleted {
+			t.Error("remove() deleted the branch after detaching failed")
+		}
+	})
+
+	t.Run("branch delete fails", func(t *testing.T) {
+		gitCmdFn = func(dir string, args ...string) error {
+			if args[0] == "branch" {
+				return errors.New("exit status 1")
+			}
+			return nil
+		}
+
+		err := remove("test-branch", removeOptions{keepDir: true})
+		if err == nil || err.Error() != "failed to delete branch: exit status 1" {
+			t.Errorf("remove() error = %v, want branch delete error", err)
+		}
+	})
+}
+
+func TestRemoveArchive(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+	}()
+
+	setup := func(t *testing.T) (worktreePath string, calls *[]string) {
+		tmpDir := t.TempDir()
+		worktreePath = filepath.Join(tmpDir, WorktreesDir, "test-branch")
+		os.MkdirAll(worktreePath, 0755)
+		os.WriteFile(filepath.Join(worktreePath, "scratch.txt"), []byte("experiment"), 0644)
+		calls = &[]string{}
+
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+		gitCmdFn = func(dir string, args ...string) error {
+			// The worktree must still be intact when git is asked to remove it
+			if _, err := os.Stat(filepath.Join(worktreePath, "scratch.txt")); err != nil {
+				t.Errorf("worktree files missing before git %v: %v", args, err)
+			}
+			*calls = append(*calls, strings.Join(args[:2], " "))
+			return nil
+		}
+		getwdFn = func() (string, error) {
+			return "/some/other/dir", nil
+		}
+		return worktreePath, calls
+	}
+
+	t.Run("archives then removes", func(t *testing.T) {
+		_, calls := setup(t)
+		dest := filepath.Join(t.TempDir(), "archives")
+
+		if err := remove("test-branch", removeOptions{archiveDir: dest}); err != nil {
+			t.Fatalf("remove() unexpected error: %v", err)
+		}
+
+		entries, _ := os.ReadDir(dest)
+		if len(entries) != 1 {
+			t.Fatalf("archive directory has %d entries, want 1", len(entries))
+		}
+		got := readTarGz(t, filepath.Join(dest, entries[0].Name()))
+		if got["test-branch/scratch.txt"] != "experiment" {
+			t.Errorf("archive entries = %v, want test-branch/scratch.txt", got)
+		}
+		if want := []string{"worktree remove", "branch -d"}; !reflect.DeepEqual(*calls, want) {
+			t.Errorf("git calls = %v, want %v", *calls, want)
+		}
+	})
+
+	t.Run("archive failure keeps the worktree", func(t *testing.T) {
+		_, calls := setup(t)
+		blocker := filepath.Join(t.TempDir(), "file")
+		os.WriteFile(blocker, []byte{}, 0644)
+
+		err := remove("test-branch", removeOptions{archiveDir: filepath.Join(blocker, "archives")})
+		if err == nil || !strings.HasPrefix(err.Error(), "failed to create archive directory") {
+			t.Errorf("remove() error = %v, want archive error", err)
+		}
+		if len(*calls) != 0 {
+			t.Errorf("git calls = %v, want none after a failed archive", *calls)
+		}
+	})
+}
+
+func TestRemoveUnmergedBranch(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	origInput := promptInput
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+		promptInput = origInput
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return "/some/other/dir", nil
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Path: tmpDir, Branch: "main"}, {Path: worktreePath, Branch: "feat"}}, nil
+	}
+	unmerged := &gitCmdError{err: errors.New("exit status 1"), stderr: "error: the branch 'feat' is not fully merged\n"}
+
+	tests := []struct {
+		name       string
+		opts       removeOptions
+		input      string
+		deleteErr  error // returned by git branch -d
+		forceErr   error // returned by git branch -D
+		wantCalls  []string
+		wantPrompt bool
+		wantStderr string
+		wantErr    string
+	}{
+		{
+			name:       "merged branch deletes cleanly",
+			wantCalls:  []string{"worktree remove", "branch -d"},
+			wantStderr: "Done! Worktree and branch removed",
+		},
+		{
+			name:       "unmerged branch declined",
+			input:      "n\n",
+			deleteErr:  unmerged,
+			wantCalls:  []string{"worktree remove", "branch -d"},
+			wantPrompt: true,
+			wantStderr: "warning: worktree removed but branch feat was kept; delete it with 'git branch -D feat'",
+		},
+		{
+			name:       "unmerged branch confirmed",
+			input:      "y\n",
+			deleteErr:  unmerged,
+			wantCalls:  []string{"worktree remove", "branch -d", "branch -D"},
+			wantPrompt: true,
+			wantStderr: "Done! Worktree and branch removed",
+		},
+		{
+			name:       "--force deletes without asking",
+			opts:       removeOptions{force: true},
+			wantCalls:  []string{"worktree remove", "branch -D"},
+			wantStderr: "Done! Worktree and branch removed",
+		},
+		{
+			name:       "--yes force deletes an unmerged branch",
+			opts:       removeOptions{yes: true},
+			deleteErr:  unmerged,
+			wantCalls:  []string{"worktree remove", "branch -d", "branch -D"},
+			wantStderr: "Done! Worktree and branch removed",
+		},
+		{
+			name:      "other branch -d failures are not prompted",
+			deleteErr: &gitCmdError{err: errors.New("exit status 1"), stderr: "error: branch 'feat' not found\n"},
+			wantCalls: []string{"worktree remove", "branch -d"},
+			wantErr:   "failed to delete branch: exit status 1",
+		},
+		{
+			name:      "force delete fails",
+			opts:      removeOptions{force: true},
+			forceErr:  errors.New("exit status 1"),
+			wantCalls: []string{"worktree remove", "branch -D"},
+			wantErr:   "failed to delete branch: exit status 1",
+		},
+		{
+			name:       "keep dir with unmerged branch declined",
+			opts:       removeOptions{keepDir: true},
+			deleteErr:  unmerged,
+			wantCalls:  []string{"checkout --detach", "branch -d"},
+			wantPrompt: true,
+			wantStderr: "warning: worktree detached but branch feat was kept; delete it with 'git branch -D feat'",
+		},
+		{
+			name:       "keep dir with unmerged branch confirmed",
+			opts:       removeOptions{keepDir: true},
+			input:      "yes\n",
+			deleteErr:  unmerged,
+			wantCalls:  []string{"checkout --detach", "branch -d", "branch -D"},
+			wantPrompt: true,
+			wantStderr: "Done! Branch removed, worktree kept at .worktrees/feat",
+		},
+		{
+			name:      "keep dir branch -d fails",
+			opts:      removeOptions{keepDir: true},
+			deleteErr: errors.New("exit status 128"),
+			wantCalls: []string{"checkout --detach", "branch -d"},
+			wantErr:   "failed to delete branch: exit status 128",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				call := strings.Join(args[:2], " ")
+				calls = append(calls, call)
+				switch call {
+				case "branch -d":
+					return tt.deleteErr
+				case "branch -D":
+					return tt.forceErr
+				}
+				return nil
+			}
+			promptInput = strings.NewReader(tt.input)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := remove("feat", tt.opts)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("remove() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("remove() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			prompted := strings.Contains(stderr.String(), "Branch feat has unmerged commits, force delete? [y/N]")
+			if prompted != tt.wantPrompt {
+				t.Errorf("prompted = %v, want %v; stderr = %q", prompted, tt.wantPrompt, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRemoveDeletesCheckedOutBranch(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return "/some/other/dir", nil
+	}
+	// A worktree created with branch_prefix = alice/
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{
+			{Path: tmpDir, Branch: "main"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "feat"), Branch: "alice/feat"},
+		}, nil
+	}
+	var calls []string
+	gitCmdFn = func(dir string, args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	if err := remove("feat", removeOptions{}); err != nil {
+		t.Fatalf("remove() unexpected error: %v", err)
+	}
+	want := []string{"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "feat"), "branch -d alice/feat"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("git calls = %q, want %q", calls, want)
+	}
+}
+
+func TestRemoveDetached(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	origStdout := os.Stdout
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+		os.Stdout = origStdout
+	}()
+
+	tmpDir := t.TempDir()
+	worktreePath := filepath.Join(tmpDir, WorktreesDir, "release-review")
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	// A worktree created with create --detach v2.0.0, next to an unrelated branch of the same name
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{
+			{Path: tmpDir, Branch: "main"},
+			{Path: worktreePath, Head: "abc123", Detached: true},
+		}, nil
+	}
+	var calls []string
+	gitCmdFn = func(dir string, args ...string) error {
+		calls = append(calls, strings.Join(args, " "))
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		cwd        string
+		wantStdout string
+	}{
+		{name: "from elsewhere", cwd: "/some/other/dir", wantStdout: ""},
+		{name: "from inside the worktree", cwd: worktreePath, wantStdout: tmpDir + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			getwdFn = func() (string, error) {
+				return tt.cwd, nil
+			}
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := remove("release-review", removeOptions{})
+
+			w.Close()
+			os.Stdout = origStdout
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+
+			if err != nil {
+				t.Fatalf("remove() unexpected error: %v", err)
+			}
+			if want := []string{"worktree remove " + worktreePath}; !reflect.DeepEqual(calls, want) {
+				t.Errorf("git calls = %q, want %q", calls, want)
+			}
+			if buf.String() != tt.wantStdout {
+				t.Errorf("remove() stdout = %q, want %q", buf.String(), tt.wantStdout)
+			}
+		})
+	}
+}
+
+func TestRemoveNestedWorktree(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+	}()
+
+	tmpDir := t.TempDir()
+	worktreesPath := filepath.Join(tmpDir, WorktreesDir)
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Path: tmpDir, Branch: "main"}}, nil
+	}
+	// git worktree remove deletes the worktree's own directory, but not its parents
+	gitCmdFn = func(dir string, args ...string) error {
+		if args[0] == "worktree" {
+			os.RemoveAll(args[2])
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name     string
+		siblings []string // other entries under the worktrees directory
+		cwd      string
+		wantGone []string
+		wantKept []string
+	}{
+		{
+			name:     "empty parents are removed",
+			wantGone: []string{"team/web", "team"},
+			wantKept: []string{""},
+		},
+		{
+			name:     "parent with a sibling worktree is kept",
+			siblings: []string{"team/web/api"},
+			wantGone: []string{"team/web/login"},
+			wantKept: []string{"team/web/api", "team/web", "team"},
+		},
+		{
+			name:     "only the empty levels are removed",
+			siblings: []string{"team/notes.txt"},
+			wantGone: []string{"team/web"},
+			wantKept: []string{"team/notes.txt", "team"},
+		},
+		{
+			name:     "the current directory is kept",
+			cwd:      "team",
+			wantGone: []string{"team/web"},
+			wantKept: []string{"team"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.RemoveAll(worktreesPath)
+			os.MkdirAll(filepath.Join(worktreesPath, "team", "web", "login"), 0755)
+			for _, sibling := range tt.siblings {
+				if filepath.Ext(sibling) != "" {
+					os.WriteFile(filepath.Join(worktreesPath, sibling), []byte("keep"), 0644)
+				} else {
+					os.MkdirAll(filepath.Join(worktreesPath, sibling), 0755)
+				}
+			}
+			getwdFn = func() (string, error) {
+				return filepath.Join(worktreesPath, tt.cwd), nil
+			}
+
+			oldStderr := os.Stderr
+			os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			err := remove("team/web/login", removeOptions{})
+			os.Stderr.Close()
+			os.Stderr = oldStderr
+			if err != nil {
+				t.Fatalf("remove() unexpected error: %v", err)
+			}
+
+			for _, rel := range append(tt.wantGone, "team/web/login") {
+				if _, err := os.Stat(filepath.Join(worktreesPath, rel)); !os.IsNotExist(err) {
+					t.Errorf("%s still exists (err = %v), want it removed", rel, err)
+				}
+			}
+			for _, rel := range tt.wantKept {
+				if _, err := os.Stat(filepath.Join(worktreesPath, rel)); err != nil {
+					t.Errorf("%s was removed: %v", rel, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRemovePostRemoveHook(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+	}()
+
+	getwdFn = func() (string, error) {
+		return "/some/other/dir", nil
+	}
+
+	tests := []struct {
+		name       string
+		config     string
+		hookFile   string
+		hookExit   int
+		opts       removeOptions
+		removeErr  error
+		deleteErr  error
+		wantErr    string
+		wantRan    bool
+		wantStderr string
+	}{
+		{
+			name:     "runs from the root after the branch is deleted",
+			hookFile: DefaultPostRemoveHook,
+			wantRan:  true,
+		},
+		{
+			name:     "configured hook",
+			config:   "post_remove_hook = hooks/cleanup.sh\n",
+			hookFile: "hooks/cleanup.sh",
+			wantRan:  true,
+		},
+		{
+			name:     "configured hook replaces the default",
+			config:   "post_remove_hook = hooks/cleanup.sh\n",
+			hookFile: DefaultPostRemoveHook,
+		},
+		{
+			name:      "skipped when the worktree is not removed",
+			hookFile:  DefaultPostRemoveHook,
+			removeErr: errors.New("exit status 128"),
+			wantErr:   "failed to remove worktree: exit status 128",
+		},
+		{
+			name:      "skipped when the branch is not deleted",
+			hookFile:  DefaultPostRemoveHook,
+			deleteErr: errors.New("exit status 1"),
+			wantErr:   "failed to delete branch: exit status 1",
+		},
+		{
+			name:     "skipped with --keep-dir",
+			hookFile: DefaultPostRemoveHook,
+			opts:     removeOptions{keepDir: true},
+		},
+		{
+			name:       "failing hook only warns",
+			hookFile:   DefaultPostRemoveHook,
+			hookExit:   1,
+			wantRan:    true,
+			wantStderr: "warning: post-remove hook failed: exit status 1",
+		},
+		{
+			name:       "missing hook_shell only warns",
+			config:     "hook_shell = no-such-shell-for-wt\n",
+			hookFile:   DefaultPostRemoveHook,
+			wantStderr: "warning: post-remove hook failed: invalid hook_shell",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			gitMainRootFn = func() (string, error) {
+				return tmpDir, nil
+			}
+			worktreePath := filepath.Join(tmpDir, WorktreesDir, "feat")
+			os.MkdirAll(worktreePath, 0755)
+			listWorktreeInfosFn = func() ([]Worktree, error) {
+				return []Worktree{{Path: tmpDir, Branch: "main"}, {Path: worktreePath, Branch: "feat"}}, nil
+			}
+			gitCmdFn = func(dir string, args ...string) error {
+				switch args[0] {
+				case "worktree":
+					return tt.removeErr
+				case "branch":
+					return tt.deleteErr
+				}
+				return nil
+			}
+			if tt.config != "" {
+				os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte(tt.config), 0644)
+			}
+			out := filepath.Join(t.TempDir(), "ran")
+			hook := filepath.Join(tmpDir, tt.hookFile)
+			os.MkdirAll(filepath.Dir(hook), 0755)
+			script := "#!/bin/sh\necho \"$WT_NAME $(pwd)\" > " + out + "\nexit " + strconv.Itoa(tt.hookExit) + "\n"
+			os.WriteFile(hook, []byte(script), 0755)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := remove("feat", tt.opts)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("remove() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("remove() unexpected error: %v", err)
+			}
+			data, readErr := os.ReadFile(out)
+			if ran := readErr == nil; ran != tt.wantRan {
+				t.Fatalf("hook ran = %v, want %v; stderr = %q", ran, tt.wantRan, stderr.String())
+			}
+			if tt.wantRan && string(data) != "feat "+tmpDir+"\n" {
+				t.Errorf("hook saw %q, want WT_NAME=feat and the repository root as its directory", data)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRemoveOlderThan(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGitOutput := gitOutputFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	origInput := promptInput
+	origNow := nowFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		gitOutputFn = origGitOutput
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+		promptInput = origInput
+		nowFn = origNow
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return "/some/other/dir", nil
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	// Canned committer dates, in seconds, by HEAD SHA
+	commitDates := map[string]string{
+		"main1":  strconv.FormatInt(now.AddDate(-1, 0, 0).Unix(), 10),
+		"old1":   strconv.FormatInt(now.AddDate(0, 0, -45).Unix(), 10),
+		"older1": strconv.FormatInt(now.AddDate(0, -3, 0).Unix(), 10),
+		"new1":   strconv.FormatInt(now.AddDate(0, 0, -2).Unix(), 10),
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if args[0] != "show" {
+			return "", errors.New("exit status 128")
+		}
+		return commitDates[args[3]], nil
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{
+			{Path: tmpDir, Branch: "main", Head: "main1"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "old"), Branch: "old", Head: "old1"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "new"), Branch: "new", Head: "new1"},
+			{Path: filepath.Join(tmpDir, WorktreesDir, "older"), Branch: "older", Head: "older1"},
+		}, nil
+	}
+
+	tests := []struct {
+		name       string
+		age        string
+		opts       removeOptions
+		input      string
+		removeErr  error // returned by git worktree remove
+		wantCalls  []string
+		wantPrompt bool
+		wantStderr string
+		wantErr    string
+	}{
+		{
+			name:  "confirmed removes only stale worktrees",
+			age:   "30d",
+			input: "y\n",
+			wantCalls: []string{
+				"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "old"), "branch -d old",
+				"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "older"), "branch -d older",
+			},
+			wantPrompt: true,
+			wantStderr: "Worktrees without commits in the last 30d:\n  old (last commit 6w ago)\n  older (last commit 13w ago)\n",
+		},
+		{
+			name:       "declined removes nothing",
+			age:        "30d",
+			input:      "n\n",
+			wantPrompt: true,
+			wantStderr: "Nothing removed",
+		},
+		{
+			name: "--yes skips the confirmation",
+			age:  "8w",
+			opts: removeOptions{yes: true},
+			wantCalls: []string{
+				"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "older"), "branch -d older",
+			},
+			wantStderr: "  older (last commit 13w ago)\n",
+		},
+		{
+			name:       "nothing older than the cutoff",
+			age:        "52w",
+			wantStderr: "No worktrees without commits in the last 52w\n",
+		},
+		{
+			name:      "stops at the first failure",
+			age:       "30d",
+			opts:      removeOptions{yes: true},
+			removeErr: errors.New("exit status 128"),
+			wantCalls: []string{"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "old")},
+			wantErr:   "failed to remove old: failed to remove worktree: exit status 128",
+		},
+		{
+			name:      "--keep-going removes the rest after a failure",
+			age:       "30d",
+			opts:      removeOptions{yes: true, keepGoing: true},
+			removeErr: errors.New("exit status 128"),
+			wantCalls: []string{
+				"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "old"),
+				"worktree remove " + filepath.Join(tmpDir, WorktreesDir, "older"),
+			},
+			wantStderr: "error: failed to remove old: failed to remove worktree: exit status 128\n",
+			wantErr:    "failed to remove 2 of 2 worktrees: old, older",
+		},
+		{
+			name:    "invalid duration",
+			age:     "soon",
+			wantErr: `invalid --older-than: "soon" is not a duration (use e.g. 30d, 2w or 12h)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				calls = append(calls, strings.Join(args, " "))
+				if args[0] == "worktree" {
+					return tt.removeErr
+				}
+				return nil
+			}
+			promptInput = strings.NewReader(tt.input)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := removeOlderThan(tt.age, tt.opts)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("removeOlderThan() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("removeOlderThan() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			prompted := strings.Contains(stderr.String(), "Remove 2 worktree(s)? [y/N]")
+			if prompted != tt.wantPrompt {
+				t.Errorf("prompted = %v, want %v; stderr = %q", prompted, tt.wantPrompt, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+		defer func() {
+			gitMainRootFn = func() (string, error) { return tmpDir, nil }
+		}()
+
+		if err := removeOlderThan("30d", removeOptions{}); err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("removeOlderThan() error = %v, want 'not in a git repository'", err)
+		}
+	})
+
+	t.Run("worktree list error", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return nil, errors.New("failed to list git worktrees: exit status 128")
+		}
+
+		err := removeOlderThan("30d", removeOptions{})
+		if err == nil || err.Error() != "failed to list git worktrees: exit status 128" {
+			t.Errorf("removeOlderThan() error = %v, want worktree list error", err)
+		}
+	})
+
+	t.Run("commit date error", func(t *testing.T) {
+		listWorktreeInfosFn = func() ([]Worktree, error) {
+			return []Worktree{{Path: filepath.Join(tmpDir, WorktreesDir, "old"), Head: "old1"}}, nil
+		}
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 128")
+		}
+
+		err := removeOlderThan("30d", removeOptions{})
+		if err == nil || err.Error() != "failed to read last commit of old: exit status 128" {
+			t.Errorf("removeOlderThan() error = %v, want commit date error", err)
+		}
+	})
+}
+
+func TestRemoveSelected(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitCmd := gitCmdFn
+	origGetwd := getwdFn
+	origInfos := listWorktreeInfosFn
+	origListWorktrees := listWorktreesFn
+	origInput := promptInput
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitCmdFn = origGitCmd
+		getwdFn = origGetwd
+		listWorktreeInfosFn = origInfos
+		listWorktreesFn = origListWorktrees
+		promptInput = origInput
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	getwdFn = func() (string, error) {
+		return "/some/other/dir", nil
+	}
+	listWorktreeInfosFn = func() ([]Worktree, error) {
+		return []Worktree{{Path: tmpDir, Branch: "main"}}, nil
+	}
+	listWorktreesFn = func() ([]string, error) {
+		return []string{"api", "docs", "spike"}, nil
+	}
+	removed := func(name string) []string {
+		return []string{"worktree remove " + filepath.Join(tmpDir, WorktreesDir, name), "branch -d " + name}
+	}
+
+	tests := []struct {
+		name       string
+		opts       removeOptions
+		input      string // the selection, then the answer to the confirmation
+		wantCalls  []string
+		wantStderr string
+		wantErr    string
+	}{
+		{
+			name:       "removes exactly the selected worktrees",
+			input:      "3, 1\ny\n",
+			wantCalls:  append(removed("spike"), removed("api")...),
+			wantStderr: "Worktrees:\n  1) api\n  2) docs\n  3) spike\nRemove which worktrees? (numbers such as 1,3; empty for none) Remove 2 worktree(s)? [y/N] ",
+		},
+		{
+			name:       "--yes skips the confirmation",
+			opts:       removeOptions{yes: true},
+			input:      "2\n",
+			wantCalls:  removed("docs"),
+			wantStderr: "empty for none) Removing worktree",
+		},
+		{
+			name:       "empty selection is a no-op",
+			input:      "\n",
+			wantStderr: "empty for none) Nothing removed\n",
+		},
+		{
+			name:       "closed input is a no-op",
+			input:      "",
+			wantStderr: "empty for none) \nNothing removed\n",
+		},
+		{
+			name:       "declined confirmation removes nothing",
+			input:      "1 2\nn\n",
+			wantStderr: "Remove 2 worktree(s)? [y/N] Nothing removed\n",
+		},
+		{
+			name:    "invalid selection",
+			input:   "1,4\n",
+			wantErr: `invalid selection "4" (use numbers from 1 to 3)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			gitCmdFn = func(dir string, args ...string) error {
+				calls = append(calls, strings.Join(args, " "))
+				return nil
+			}
+			promptInput = strings.NewReader(tt.input)
+
+			oldStderr := os.Stderr
+			r, w, _ := os.Pipe()
+			os.Stderr = w
+			err := removeSelected(tt.opts)
+			w.Close()
+			os.Stderr = oldStderr
+			var stderr bytes.Buffer
+			io.Copy(&stderr, r)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("removeSelected() error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("removeSelected() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("git calls = %q, want %q", calls, tt.wantCalls)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("no worktrees", func(t *testing.T) {
+		listWorktreesFn = func() ([]string, error) {
+			return []string{}, nil
+		}
+		oldStderr := os.Stderr
+		r, w, _ := os.Pipe()
+		os.Stderr = w
+		err := removeSelected(removeOptions{})
+		w.Close()
+		os.Stderr = oldStderr
+		var stderr bytes.Buffer
+		io.Copy(&stderr, r)
+
+		if err != nil || stderr.String() != "No worktrees to remove\n" {
+			t.Errorf("removeSelected() = %v, stderr %q; want nothing to remove", err, stderr.String())
+		}
+	})
+
+	t.Run("listing worktrees fails", func(t *testing.T) {
+		listWorktreesFn = func() ([]string, error) {
+			return nil, errors.New("permission denied")
+		}
+		if err := removeSelected(removeOptions{}); err == nil || err.Error() != "permission denied" {
+			t.Errorf("removeSelected() error = %v, want 'permission denied'", err)
+		}
+	})
+}
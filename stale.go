@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ageUnits are the duration suffixes parseAge accepts on top of those time.ParseDuration knows
+var ageUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"w", 7 * 24 * time.Hour},
+}
+
+// parseAge parses a positive duration such as 30d, 2w or 12h
+// A days or weeks count must be a whole number; other values use time.ParseDuration syntax
+func parseAge(s string) (time.Duration, error) {
+	invalid := fmt.Errorf("%q is not a duration (use e.g. 30d, 2w or 12h)", s)
+	for _, u := range ageUnits {
+		if count, ok := strings.CutSuffix(s, u.suffix); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil || n <= 0 {
+				return 0, invalid
+			}
+			return time.Duration(n) * u.unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, invalid
+	}
+	return d, nil
+}
+
+// humanizeUnits are the units humanizeDuration rounds down to, largest first
+var humanizeUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// humanizeDuration formats how long ago something happened as a compact relative time such as 3d ago
+// The largest whole unit is used; durations under a second, including negative ones, are "just now"
+func humanizeDuration(d time.Duration) string {
+	for _, u := range humanizeUnits {
+		if d >= u.unit {
+			return fmt.Sprintf("%d%s ago", d/u.unit, u.suffix)
+		}
+	}
+	return "just now"
+}
+
+// staleWorktree is a worktree whose last commit is older than a cutoff
+type staleWorktree struct {
+	name       string    // name relative to the worktrees directory
+	lastCommit time.Time // committer date of the worktree's HEAD
+}
+
+// findStaleWorktrees returns the worktrees in the worktrees directory whose HEAD commit is older than age
+// Worktrees git registers elsewhere and the main worktree are never returned
+func findStaleWorktrees(wm *WorktreeManager, infos []Worktree, age time.Duration) ([]staleWorktree, error) {
+	cutoff := nowFn().Add(-age)
+	var stale []staleWorktree
+	for _, info := range infos {
+		name, err := wm.WorktreeNameFromPath(info.Path)
+		if err != nil || info.Head == "" {
+			continue
+		}
+		lastCommit, err := commitTime(wm.Root(), info.Head)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read last commit of %s: %w", name, err)
+		}
+		if lastCommit.Before(cutoff) {
+			stale = append(stale, staleWorktree{name: name, lastCommit: lastCommit})
+		}
+	}
+	return stale, nil
+}
+
+// commitTime returns the committer date of commit in the repository at dir
+func commitTime(dir, commit string) (time.Time, error) {
+	out, err := gitOutput(dir, "show", "-s", "--format=%ct", commit)
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected commit date %q", out)
+	}
+	return time.Unix(seconds, 0), nil
+}
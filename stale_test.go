@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "30d", want: 30 * 24 * time.Hour},
+		{in: "2w", want: 14 * 24 * time.Hour},
+		{in: "12h", want: 12 * time.Hour},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "0d", wantErr: true},
+		{in: "-3d", wantErr: true},
+		{in: "1.5d", wantErr: true},
+		{in: "d", wantErr: true},
+		{in: "0s", wantErr: true},
+		{in: "month", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAge(tt.in)
+			if tt.wantErr {
+				want := `"` + tt.in + `" is not a duration (use e.g. 30d, 2w or 12h)`
+				if err == nil || err.Error() != want {
+					t.Errorf("parseAge(%q) error = %v, want %q", tt.in, err, want)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseAge(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: -time.Minute, want: "just now"},
+		{d: 0, want: "just now"},
+		{d: 999 * time.Millisecond, want: "just now"},
+		{d: time.Second, want: "1s ago"},
+		{d: 59 * time.Second, want: "59s ago"},
+		{d: time.Minute, want: "1m ago"},
+		{d: 59*time.Minute + 59*time.Second, want: "59m ago"},
+		{d: time.Hour, want: "1h ago"},
+		{d: 23*time.Hour + 59*time.Minute, want: "23h ago"},
+		{d: day, want: "1d ago"},
+		{d: 6*day + 23*time.Hour, want: "6d ago"},
+		{d: 7 * day, want: "1w ago"},
+		{d: 100 * day, want: "14w ago"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want+" "+tt.d.String(), func(t *testing.T) {
+			if got := humanizeDuration(tt.d); got != tt.want {
+				t.Errorf("humanizeDuration(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindStaleWorktrees(t *testing.T) {
+	origGitRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	origNow := nowFn
+	defer func() {
+		gitMainRootFn = origGitRoot
+		gitOutputFn = origGitOutput
+		nowFn = origNow
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	// Commit dates by HEAD SHA
+	commitDates := map[string]time.Time{
+		"main1": now.AddDate(0, -6, 0),
+		"old1":  now.AddDate(0, 0, -40),
+		"new1":  now.AddDate(0, 0, -3),
+		"out1":  now.AddDate(-1, 0, 0),
+	}
+	gitOutputFn = func(dir string, args ...string) (string, error) {
+		if dir != tmpDir || !reflect.DeepEqual(args[:3], []string{"show", "-s", "--format=%ct"}) {
+			t.Errorf("unexpected git %v in %s", args, dir)
+		}
+		date, ok := commitDates[args[3]]
+		if !ok {
+			return "", errors.New("exit status 128")
+		}
+		return strconv.FormatInt(date.Unix(), 10), nil
+	}
+
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := []Worktree{
+		{Path: tmpDir, Branch: "main", Head: "main1"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "old"), Branch: "old", Head: "old1"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "team", "new"), Branch: "team/new", Head: "new1"},
+		{Path: "/elsewhere/out", Branch: "out", Head: "out1"},
+		{Path: filepath.Join(tmpDir, WorktreesDir, "bare"), Bare: true},
+	}
+
+	t.Run("selects only worktrees older than the cutoff", func(t *testing.T) {
+		got, err := findStaleWorktrees(wm, infos, 30*24*time.Hour)
+		want := []staleWorktree{{name: "old", lastCommit: time.Unix(commitDates["old1"].Unix(), 0)}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("findStaleWorktrees() = %v, %v; want %v", got, err, want)
+		}
+	})
+
+	t.Run("shorter age selects more", func(t *testing.T) {
+		got, err := findStaleWorktrees(wm, infos, 24*time.Hour)
+		if err != nil || len(got) != 2 || got[1].name != "team/new" {
+			t.Errorf("findStaleWorktrees() = %v, %v; want old and team/new", got, err)
+		}
+	})
+
+	t.Run("commit date error", func(t *testing.T) {
+		broken := append(infos[:1:1], Worktree{Path: filepath.Join(tmpDir, WorktreesDir, "gone"), Head: "missing"})
+		_, err := findStaleWorktrees(wm, broken, time.Hour)
+		if err == nil || err.Error() != "failed to read last commit of gone: exit status 128" {
+			t.Errorf("findStaleWorktrees() error = %v, want last commit error", err)
+		}
+	})
+}
+
+func TestCommitTime(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() { gitOutputFn = origGitOutput }()
+
+	t.Run("parses the committer date", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "1700000000", nil
+		}
+		got, err := commitTime("/repo", "abc")
+		if err != nil || !got.Equal(time.Unix(1700000000, 0)) {
+			t.Errorf("commitTime() = %v, %v; want %v", got, err, time.Unix(1700000000, 0))
+		}
+	})
+
+	t.Run("unexpected output", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "yesterday", nil
+		}
+		_, err := commitTime("/repo", "abc")
+		if err == nil || err.Error() != `unexpected commit date "yesterday"` {
+			t.Errorf("commitTime() error = %v, want unexpected commit date", err)
+		}
+	})
+}
@@ -7,12 +7,118 @@ import (
 	"strings"
 )
 
-// getwdFn is replaceable for testing
-var getwdFn = os.Getwd
+// Function variables for testing
+var (
+	getwdFn             = os.Getwd
+	userHomeDirFn       = os.UserHomeDir
+	listWorktreeInfosFn = defaultListWorktreeInfos
+)
+
+// Worktree describes one entry of `git worktree list --porcelain`
+type Worktree struct {
+	Name       string // base name of the worktree directory
+	Path       string // absolute path of the worktree
+	Branch     string // checked out branch without refs/heads/; empty when detached or bare
+	Head       string // commit SHA of HEAD; empty for bare repositories
+	Bare       bool
+	Detached   bool
+	Locked     bool   // set by git worktree lock, which keeps git from pruning or moving it
+	LockReason string // reason given with git worktree lock --reason; empty when none was given
+	Prunable   string // reason git considers the worktree prunable; empty otherwise
+}
+
+// parseWorktreePorcelain parses `git worktree list --porcelain` output into Worktrees
+// Records are separated by blank lines and start with a "worktree <path>" line; attributes
+// outside a record are an error, while unknown attributes are skipped for newer git versions
+func parseWorktreePorcelain(out string) ([]Worktree, error) {
+	var worktrees []Worktree
+	var current *Worktree
+	for i, line := range strings.Split(out, "\n") {
+		if line == "" {
+			current = nil
+			continue
+		}
+		attr, value, _ := strings.Cut(line, " ")
+		if attr == "worktree" {
+			if value == "" {
+				return nil, fmt.Errorf("line %d: worktree without a path", i+1)
+			}
+			worktrees = append(worktrees, Worktree{Name: filepath.Base(value), Path: value})
+			current = &worktrees[len(worktrees)-1]
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %q is not part of a worktree record", i+1, line)
+		}
+		switch attr {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		case "locked":
+			current.Locked = true
+			current.LockReason = value
+		case "prunable":
+			current.Prunable = value
+		}
+	}
+	return worktrees, nil
+}
+
+// listWorktreeInfos returns every worktree git knows about, starting with the main worktree
+func listWorktreeInfos() ([]Worktree, error) {
+	return listWorktreeInfosFn()
+}
+
+func defaultListWorktreeInfos() ([]Worktree, error) {
+	wm, err := NewWorktreeManager()
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOutput(wm.Root(), "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git worktrees: %w", err)
+	}
+	worktrees, err := parseWorktreePorcelain(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse git worktree list: %w", err)
+	}
+	return worktrees, nil
+}
+
+// detectDuplicateBranches returns a warning for each branch checked out in more than one worktree
+// git refuses this normally, but `git worktree add --force` or manual checkouts can still cause it
+func detectDuplicateBranches(infos []Worktree) []string {
+	paths := map[string][]string{}
+	var branches []string
+	for _, info := range infos {
+		if info.Branch == "" {
+			continue
+		}
+		if _, seen := paths[info.Branch]; !seen {
+			branches = append(branches, info.Branch)
+		}
+		paths[info.Branch] = append(paths[info.Branch], info.Path)
+	}
+
+	var warnings []string
+	for _, branch := range branches {
+		if len(paths[branch]) > 1 {
+			warnings = append(warnings, fmt.Sprintf("branch %s is checked out in %d worktrees: %s", branch, len(paths[branch]), strings.Join(paths[branch], ", ")))
+		}
+	}
+	return warnings
+}
 
 // WorktreeManager provides centralized worktree path management
 type WorktreeManager struct {
-	root string
+	root           string
+	config         *Config
+	configProblems []error // config lines that were skipped
 }
 
 // NewWorktreeManager creates a WorktreeManager after finding the main git root
@@ -22,7 +128,16 @@ func NewWorktreeManager() (*WorktreeManager, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &WorktreeManager{root: root}, nil
+	config, problems, err := loadConfig(filepath.Join(root, ConfigFile))
+	if err != nil {
+		return nil, err
+	}
+	return &WorktreeManager{root: root, config: config, configProblems: problems}, nil
+}
+
+// ConfigProblems returns the config file lines that were skipped because they are invalid
+func (wm *WorktreeManager) ConfigProblems() []error {
+	return wm.configProblems
 }
 
 // Root returns the git repository root path
@@ -30,9 +145,22 @@ func (wm *WorktreeManager) Root() string {
 	return wm.root
 }
 
+// Config returns the repository configuration, falling back to defaults
+func (wm *WorktreeManager) Config() *Config {
+	if wm.config == nil {
+		return defaultConfig()
+	}
+	return wm.config
+}
+
+// WorktreesDirName returns the configured worktrees directory, relative to the root
+func (wm *WorktreeManager) WorktreesDirName() string {
+	return wm.Config().WorktreesDir
+}
+
 // WorktreesPath returns the path to the .worktrees directory
 func (wm *WorktreeManager) WorktreesPath() string {
-	return filepath.Join(wm.root, WorktreesDir)
+	return filepath.Join(wm.root, wm.WorktreesDirName())
 }
 
 // WorktreePath returns the path to a specific worktree
@@ -40,20 +168,124 @@ func (wm *WorktreeManager) WorktreePath(name string) string {
 	return filepath.Join(wm.WorktreesPath(), name)
 }
 
+// isPathArg reports whether arg names a path rather than a worktree name
+// Branch name components cannot start with '.', so ./, ../ and dot-directories are always paths
+func isPathArg(arg string) bool {
+	return filepath.IsAbs(arg) || strings.HasPrefix(arg, ".") || strings.HasPrefix(arg, "~")
+}
+
+// absPath expands a leading ~ and resolves a relative path against the current directory
+func absPath(path string) (string, error) {
+	path = expandHome(path)
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	cwd, err := getwdFn()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+	return filepath.Join(cwd, path), nil
+}
+
+// WorktreeNameFromPath returns the name of the worktree at path, which must lie inside the worktrees directory
+// Relative paths are resolved against the current directory
+func (wm *WorktreeManager) WorktreeNameFromPath(path string) (string, error) {
+	path, err := absPath(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Both paths are absolute, so Rel cannot fail
+	rel, _ := filepath.Rel(resolvePath(wm.WorktreesPath()), resolvePath(path))
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not inside %s", path, wm.WorktreesPath())
+	}
+	return filepath.ToSlash(rel), nil
+}
+
 // ClaudePath returns the path to the .claude directory in the root
 func (wm *WorktreeManager) ClaudePath() string {
 	return filepath.Join(wm.root, ClaudeDir)
 }
 
 // HookPath returns the full path to a hook script
+// Relative paths are resolved against the root; absolute and ~-prefixed paths are used as-is
 func (wm *WorktreeManager) HookPath(hookRelPath string) string {
-	return filepath.Join(wm.root, hookRelPath)
+	hookPath := expandHome(hookRelPath)
+	if filepath.IsAbs(hookPath) {
+		return filepath.Clean(hookPath)
+	}
+	return filepath.Join(wm.root, hookPath)
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+// The path is returned unchanged if it has no ~ prefix or the home directory is unknown
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+	home, err := userHomeDirFn()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
+
+// abbrevPath replaces the user's home directory at the start of path with ~, undoing expandHome
+// The path is returned unchanged if it is outside the home directory or the home directory is unknown
+func abbrevPath(path string) string {
+	home, err := userHomeDirFn()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(home, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	if rel == "." {
+		return "~"
+	}
+	return filepath.Join("~", rel)
+}
+
+// IsMainWorktree reports whether path is the repository's main worktree
+// It checks the root itself and the first entry of `git worktree list --porcelain`
+func (wm *WorktreeManager) IsMainWorktree(path string) bool {
+	path = filepath.Clean(path)
+	if path == filepath.Clean(wm.root) {
+		return true
+	}
+	out, err := gitOutput(wm.root, "worktree", "list", "--porcelain")
+	if err != nil {
+		return false
+	}
+	worktrees, err := parseWorktreePorcelain(out)
+	return err == nil && len(worktrees) > 0 && filepath.Clean(worktrees[0].Path) == path
+}
+
+// findWorktree returns the entry of infos whose path is path, comparing resolved paths
+func findWorktree(infos []Worktree, path string) (Worktree, bool) {
+	path = resolvePath(path)
+	for _, info := range infos {
+		if resolvePath(info.Path) == path {
+			return info, true
+		}
+	}
+	return Worktree{}, false
+}
+
+// resolvePath returns path with symlinks resolved, or the cleaned path if it cannot be resolved
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
 }
 
 // ValidateWorktreesDir checks that the .worktrees directory exists
 func (wm *WorktreeManager) ValidateWorktreesDir() error {
 	if _, err := os.Stat(wm.WorktreesPath()); os.IsNotExist(err) {
-		return fmt.Errorf("%s directory does not exist (create it first)", WorktreesDir)
+		return fmt.Errorf("%s directory does not exist (run 'wt init' to create it)", wm.WorktreesDirName())
 	}
 	return nil
 }
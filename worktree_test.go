@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -84,6 +85,182 @@ func TestWorktreeManagerPaths(t *testing.T) {
 	})
 }
 
+func TestWorktreeManagerHookPath(t *testing.T) {
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		userHomeDirFn = origUserHomeDir
+	}()
+	userHomeDirFn = func() (string, error) {
+		return "/home/user", nil
+	}
+
+	wm := &WorktreeManager{root: "/test/repo"}
+
+	tests := []struct {
+		name string
+		hook string
+		want string
+	}{
+		{"relative", "scripts/setup.sh", "/test/repo/scripts/setup.sh"},
+		{"relative with dot segments", "./scripts//setup.sh", "/test/repo/scripts/setup.sh"},
+		{"absolute", "/opt/hooks/setup.sh", "/opt/hooks/setup.sh"},
+		{"absolute unclean", "/opt/hooks//setup.sh", "/opt/hooks/setup.sh"},
+		{"tilde prefix", "~/scripts/setup.sh", "/home/user/scripts/setup.sh"},
+		{"tilde alone", "~", "/home/user"},
+		{"tilde in name is literal", "~setup.sh", "/test/repo/~setup.sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wm.HookPath(tt.hook); got != tt.want {
+				t.Errorf("HookPath(%q) = %q, want %q", tt.hook, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		userHomeDirFn = origUserHomeDir
+	}()
+
+	t.Run("expands leading tilde", func(t *testing.T) {
+		userHomeDirFn = func() (string, error) {
+			return "/home/user", nil
+		}
+		if got := expandHome("~/hook.sh"); got != "/home/user/hook.sh" {
+			t.Errorf("expandHome() = %q, want %q", got, "/home/user/hook.sh")
+		}
+	})
+
+	t.Run("home lookup fails", func(t *testing.T) {
+		userHomeDirFn = func() (string, error) {
+			return "", errors.New("no home")
+		}
+		if got := expandHome("~/hook.sh"); got != "~/hook.sh" {
+			t.Errorf("expandHome() = %q, want path unchanged", got)
+		}
+	})
+}
+
+func TestAbbrevPath(t *testing.T) {
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		userHomeDirFn = origUserHomeDir
+	}()
+	userHomeDirFn = func() (string, error) {
+		return "/home/user", nil
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "path under home", path: "/home/user/src/repo/.worktrees/feat", want: "~/src/repo/.worktrees/feat"},
+		{name: "home itself", path: "/home/user", want: "~"},
+		{name: "path outside home", path: "/srv/repo/.worktrees/feat", want: "/srv/repo/.worktrees/feat"},
+		{name: "sibling sharing the home prefix", path: "/home/username/repo", want: "/home/username/repo"},
+		{name: "relative path", path: "repo/.worktrees/feat", want: "repo/.worktrees/feat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := abbrevPath(tt.path); got != tt.want {
+				t.Errorf("abbrevPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("home lookup fails", func(t *testing.T) {
+		userHomeDirFn = func() (string, error) {
+			return "", errors.New("no home")
+		}
+		if got := abbrevPath("/home/user/repo"); got != "/home/user/repo" {
+			t.Errorf("abbrevPath() = %q, want path unchanged", got)
+		}
+	})
+}
+
+func TestIsPathArg(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{"my-feature", false},
+		{"feature/login", false},
+		{"/repo/.worktrees/my-feature", true},
+		{"./my-feature", true},
+		{"../my-feature", true},
+		{".worktrees/my-feature", true},
+		{"~/repo/.worktrees/my-feature", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := isPathArg(tt.arg); got != tt.want {
+				t.Errorf("isPathArg(%q) = %v, want %v", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorktreeNameFromPath(t *testing.T) {
+	origGetwd := getwdFn
+	origUserHomeDir := userHomeDirFn
+	defer func() {
+		getwdFn = origGetwd
+		userHomeDirFn = origUserHomeDir
+	}()
+
+	wm := &WorktreeManager{root: "/test/repo"}
+	getwdFn = func() (string, error) {
+		return "/test/repo", nil
+	}
+	userHomeDirFn = func() (string, error) {
+		return "/test", nil
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "absolute path", path: "/test/repo/.worktrees/my-feature", want: "my-feature"},
+		{name: "trailing slash", path: "/test/repo/.worktrees/my-feature/", want: "my-feature"},
+		{name: "relative to cwd", path: ".worktrees/my-feature", want: "my-feature"},
+		{name: "home relative", path: "~/repo/.worktrees/my-feature", want: "my-feature"},
+		{name: "nested name", path: "./.worktrees/feature/login", want: "feature/login"},
+		{name: "worktrees dir itself", path: "/test/repo/.worktrees", wantErr: "/test/repo/.worktrees is not inside /test/repo/.worktrees"},
+		{name: "outside worktrees dir", path: "../other/my-feature", wantErr: "/test/other/my-feature is not inside /test/repo/.worktrees"},
+		{name: "repository root", path: "/test/repo", wantErr: "/test/repo is not inside /test/repo/.worktrees"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := wm.WorktreeNameFromPath(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("WorktreeNameFromPath(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("WorktreeNameFromPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("getwd error", func(t *testing.T) {
+		getwdFn = func() (string, error) {
+			return "", errors.New("getwd failed")
+		}
+		_, err := wm.WorktreeNameFromPath("./my-feature")
+		if err == nil || err.Error() != "failed to get current directory: getwd failed" {
+			t.Errorf("WorktreeNameFromPath() error = %v, want getwd error", err)
+		}
+	})
+}
+
 func TestWorktreeManagerValidateWorktreesDir(t *testing.T) {
 	t.Run("exists", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -242,3 +419,301 @@ func TestCurrentWorktreeName(t *testing.T) {
 		}
 	})
 }
+
+func TestWorktreeManagerConfig(t *testing.T) {
+	origGitMainRoot := gitMainRootFn
+	defer func() {
+		gitMainRootFn = origGitMainRoot
+	}()
+
+	t.Run("defaults when constructed without config", func(t *testing.T) {
+		wm := &WorktreeManager{root: "/test/repo"}
+		if wm.WorktreesDirName() != WorktreesDir {
+			t.Errorf("WorktreesDirName() = %q, want %q", wm.WorktreesDirName(), WorktreesDir)
+		}
+		if wm.Config().DefaultHook != DefaultHook {
+			t.Errorf("Config().DefaultHook = %q, want %q", wm.Config().DefaultHook, DefaultHook)
+		}
+	})
+
+	t.Run("loads config file from root", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("worktrees_dir = trees\n"), 0644)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+
+		wm, err := NewWorktreeManager()
+		if err != nil {
+			t.Fatalf("NewWorktreeManager() unexpected error: %v", err)
+		}
+		if wm.WorktreesPath() != filepath.Join(tmpDir, "trees") {
+			t.Errorf("WorktreesPath() = %q, want %q", wm.WorktreesPath(), filepath.Join(tmpDir, "trees"))
+		}
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.WriteFile(filepath.Join(tmpDir, ConfigFile), []byte("bogus = 1\n"), 0644)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+
+		wm, err := NewWorktreeManager()
+		if err != nil {
+			t.Fatalf("NewWorktreeManager() unexpected error: %v", err)
+		}
+		problems := wm.ConfigProblems()
+		if len(problems) != 1 || problems[0].Error() != ConfigFile+":1: unknown config key: bogus" {
+			t.Errorf("ConfigProblems() = %v, want the unknown key", problems)
+		}
+		if wm.WorktreesDirName() != WorktreesDir {
+			t.Errorf("WorktreesDirName() = %q, want default", wm.WorktreesDirName())
+		}
+	})
+
+	t.Run("unreadable config file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		os.Mkdir(filepath.Join(tmpDir, ConfigFile), 0755)
+		gitMainRootFn = func() (string, error) {
+			return tmpDir, nil
+		}
+
+		_, err := NewWorktreeManager()
+		if err == nil {
+			t.Error("NewWorktreeManager() expected error for unreadable config")
+		}
+	})
+}
+
+func TestIsMainWorktree(t *testing.T) {
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitOutputFn = origGitOutput
+	}()
+
+	wm := &WorktreeManager{root: "/test/repo"}
+	porcelain := "worktree /test/main\nHEAD abc123\nbranch refs/heads/main\n\nworktree /test/repo/.worktrees/feature\nHEAD def456\nbranch refs/heads/feature"
+
+	tests := []struct {
+		name   string
+		path   string
+		output string
+		gitErr error
+		want   bool
+	}{
+		{"repository root", "/test/repo/", "", errors.New("not a git repository"), true},
+		{"main worktree from git", "/test/main", porcelain, nil, true},
+		{"linked worktree", "/test/repo/.worktrees/feature", porcelain, nil, false},
+		{"git error", "/test/repo/.worktrees/feature", "", errors.New("not a git repository"), false},
+		{"unexpected output", "/test/main", "bare", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitOutputFn = func(dir string, args ...string) (string, error) {
+				return tt.output, tt.gitErr
+			}
+			if got := wm.IsMainWorktree(tt.path); got != tt.want {
+				t.Errorf("IsMainWorktree(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWorktreePorcelain(t *testing.T) {
+	tests := []struct {
+		name       string
+		out        string
+		want       []Worktree
+		wantErrMsg string
+	}{
+		{
+			name: "main and linked worktrees",
+			out: "worktree /repo\n" +
+				"HEAD 1111111111111111111111111111111111111111\n" +
+				"branch refs/heads/main\n" +
+				"\n" +
+				"worktree /repo/.worktrees/feature-a\n" +
+				"HEAD 2222222222222222222222222222222222222222\n" +
+				"branch refs/heads/feature-a\n",
+			want: []Worktree{
+				{Name: "repo", Path: "/repo", Branch: "main", Head: "1111111111111111111111111111111111111111"},
+				{Name: "feature-a", Path: "/repo/.worktrees/feature-a", Branch: "feature-a", Head: "2222222222222222222222222222222222222222"},
+			},
+		},
+		{
+			name: "detached worktree",
+			out:  "worktree /repo/.worktrees/spike\nHEAD 3333333333333333333333333333333333333333\ndetached\n",
+			want: []Worktree{{Name: "spike", Path: "/repo/.worktrees/spike", Head: "3333333333333333333333333333333333333333", Detached: true}},
+		},
+		{
+			name: "bare repository",
+			out:  "worktree /srv/repo.git\nbare\n",
+			want: []Worktree{{Name: "repo.git", Path: "/srv/repo.git", Bare: true}},
+		},
+		{
+			name: "prunable worktree",
+			out:  "worktree /repo/.worktrees/gone\nHEAD abc\nbranch refs/heads/gone\nprunable gitdir file points to non-existent location\n",
+			want: []Worktree{{Name: "gone", Path: "/repo/.worktrees/gone", Head: "abc", Branch: "gone", Prunable: "gitdir file points to non-existent location"}},
+		},
+		{
+			name: "locked worktrees with and without a reason",
+			out: "worktree /repo/.worktrees/usb\nHEAD abc\nbranch refs/heads/usb\nlocked on a removable drive\n\n" +
+				"worktree /repo/.worktrees/keep\nHEAD def\ndetached\nlocked\n",
+			want: []Worktree{
+				{Name: "usb", Path: "/repo/.worktrees/usb", Head: "abc", Branch: "usb", Locked: true, LockReason: "on a removable drive"},
+				{Name: "keep", Path: "/repo/.worktrees/keep", Head: "def", Detached: true, Locked: true},
+			},
+		},
+		{
+			name: "unknown attributes are skipped",
+			out:  "worktree /repo\nHEAD abc\nbranch refs/heads/main\nsparse\n",
+			want: []Worktree{{Name: "repo", Path: "/repo", Head: "abc", Branch: "main"}},
+		},
+		{
+			name: "empty output",
+			out:  "",
+		},
+		{
+			name:       "attribute before any worktree line",
+			out:        "HEAD abc\nworktree /repo\n",
+			wantErrMsg: `line 1: "HEAD abc" is not part of a worktree record`,
+		},
+		{
+			name:       "record without a worktree line",
+			out:        "worktree /repo\nHEAD abc\n\nHEAD def\nbranch refs/heads/feat\n",
+			wantErrMsg: `line 4: "HEAD def" is not part of a worktree record`,
+		},
+		{
+			name:       "worktree without a path",
+			out:        "worktree\nHEAD abc\n",
+			wantErrMsg: "line 1: worktree without a path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWorktreePorcelain(tt.out)
+			if tt.wantErrMsg != "" {
+				if err == nil || err.Error() != tt.wantErrMsg {
+					t.Errorf("parseWorktreePorcelain() error = %v, want %q", err, tt.wantErrMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWorktreePorcelain() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWorktreePorcelain() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultListWorktreeInfos(t *testing.T) {
+	origGitMainRoot := gitMainRootFn
+	origGitOutput := gitOutputFn
+	defer func() {
+		gitMainRootFn = origGitMainRoot
+		gitOutputFn = origGitOutput
+	}()
+
+	tmpDir := t.TempDir()
+	gitMainRootFn = func() (string, error) {
+		return tmpDir, nil
+	}
+
+	t.Run("parses git worktree list", func(t *testing.T) {
+		var gotArgs []string
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			gotArgs = args
+			return "worktree " + tmpDir + "\nHEAD abc\nbranch refs/heads/main", nil
+		}
+
+		infos, err := listWorktreeInfos()
+		if err != nil {
+			t.Fatalf("listWorktreeInfos() unexpected error: %v", err)
+		}
+		if len(infos) != 1 || infos[0].Path != tmpDir || infos[0].Branch != "main" {
+			t.Errorf("listWorktreeInfos() = %+v, want main worktree", infos)
+		}
+		if !reflect.DeepEqual(gotArgs, []string{"worktree", "list", "--porcelain"}) {
+			t.Errorf("listWorktreeInfos() ran git %v", gotArgs)
+		}
+	})
+
+	t.Run("git error", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "", errors.New("exit status 128")
+		}
+
+		_, err := listWorktreeInfos()
+		if err == nil || err.Error() != "failed to list git worktrees: exit status 128" {
+			t.Errorf("listWorktreeInfos() error = %v, want git error", err)
+		}
+	})
+
+	t.Run("malformed output", func(t *testing.T) {
+		gitOutputFn = func(dir string, args ...string) (string, error) {
+			return "HEAD abc\n", nil
+		}
+
+		_, err := listWorktreeInfos()
+		if err == nil || err.Error() != `failed to parse git worktree list: line 1: "HEAD abc" is not part of a worktree record` {
+			t.Errorf("listWorktreeInfos() error = %v, want parse error", err)
+		}
+	})
+
+	t.Run("git root error", func(t *testing.T) {
+		gitMainRootFn = func() (string, error) {
+			return "", errors.New("not in a git repository")
+		}
+
+		_, err := listWorktreeInfos()
+		if err == nil || err.Error() != "not in a git repository" {
+			t.Errorf("listWorktreeInfos() error = %v, want root error", err)
+		}
+	})
+}
+
+func TestResolvePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	realDir := filepath.Join(tmpDir, "real")
+	linkDir := filepath.Join(tmpDir, "link")
+	os.Mkdir(realDir, 0755)
+	os.Symlink(realDir, linkDir)
+
+	want, _ := filepath.EvalSymlinks(realDir)
+	if got := resolvePath(linkDir); got != want {
+		t.Errorf("resolvePath(link) = %q, want %q", got, want)
+	}
+	missing := filepath.Join(tmpDir, "missing", "..", "gone")
+	if got := resolvePath(missing); got != filepath.Join(tmpDir, "gone") {
+		t.Errorf("resolvePath(missing) = %q, want cleaned path", got)
+	}
+}
+
+func TestDetectDuplicateBranches(t *testing.T) {
+	t.Run("duplicate branch", func(t *testing.T) {
+		infos, _ := parseWorktreePorcelain("worktree /repo\nHEAD a\nbranch refs/heads/main\n\n" +
+			"worktree /repo/.worktrees/feat\nHEAD b\nbranch refs/heads/feat\n\n" +
+			"worktree /repo/.worktrees/feat-copy\nHEAD b\nbranch refs/heads/feat\n\n" +
+			"worktree /repo/.worktrees/spike\nHEAD c\ndetached\n\n" +
+			"worktree /repo/.worktrees/spike-2\nHEAD c\ndetached\n")
+
+		got := detectDuplicateBranches(infos)
+		want := []string{"branch feat is checked out in 2 worktrees: /repo/.worktrees/feat, /repo/.worktrees/feat-copy"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("detectDuplicateBranches() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("clean set", func(t *testing.T) {
+		infos, _ := parseWorktreePorcelain("worktree /repo\nHEAD a\nbranch refs/heads/main\n\n" +
+			"worktree /repo/.worktrees/feat\nHEAD b\nbranch refs/heads/feat\n")
+
+		if got := detectDuplicateBranches(infos); len(got) != 0 {
+			t.Errorf("detectDuplicateBranches() = %q, want none", got)
+		}
+	})
+}
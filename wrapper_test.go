@@ -150,3 +150,38 @@ func TestFishWrapperCompletePassthrough(t *testing.T) {
 	}
 	// Success - command executed without the wrapper swallowing output
 }
+
+func TestBashWrapperRepoRootPassthrough(t *testing.T) {
+	binPath := buildWtBinary(t)
+
+	wrapperContent, err := os.ReadFile("wt.sh")
+	if err != nil {
+		t.Fatalf("failed to read wt.sh: %v", err)
+	}
+	wrapperPath := filepath.Join(t.TempDir(), "wt.sh")
+	if err := os.WriteFile(wrapperPath, wrapperContent, 0644); err != nil {
+		t.Fatalf("failed to write wrapper: %v", err)
+	}
+
+	// Run from a subdirectory of a repo: repo-root must print the root without cd-ing into it
+	repoDir, _ := filepath.EvalSymlinks(t.TempDir())
+	subDir := filepath.Join(repoDir, "sub")
+	os.Mkdir(subDir, 0755)
+	if out, err := exec.Command("git", "init", "-q", repoDir).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, out)
+	}
+
+	binDir := filepath.Dir(binPath)
+	script := "export PATH=" + binDir + ":$PATH && source " + wrapperPath + " && wt repo-root && pwd"
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Dir = subDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("wt repo-root failed: %v\n%s", err, output)
+	}
+	want := repoDir + "\n" + subDir + "\n"
+	if string(output) != want {
+		t.Errorf("bash wrapper output = %q, want root printed and cwd unchanged %q", output, want)
+	}
+}
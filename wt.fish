@@ -21,7 +21,7 @@ function wt --description "Git worktree manager with auto-cd"
     end
 
     switch $argv[1]
-        case completion __complete list version
+        case completion __complete list config repo-root version
             $wt_bin $argv
             return $status
     end
@@ -18,7 +18,7 @@ wt() {
 
     # Pass through commands that produce non-directory output
     case "$1" in
-        completion|__complete|list|version|"")
+        completion|__complete|list|config|repo-root|version|"")
             "$wt_bin" "$@"
             return $?
             ;;